@@ -13,13 +13,25 @@ func runDoctor(ctx context.Context, args []string) error {
 	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
 	fix := fs.Bool("fix", false, "Attempt to install missing tools and fix issues")
 	outputJSON := fs.Bool("json", false, "Emit report as JSON")
+	runtimeName := fs.String("runtime", "", "Container runtime to check selection for: docker, podman, nerdctl or docker-engine")
+	profile := fs.String("profile", "", "Profile to check (defaults to the one last brought up)")
+	instanceName := fs.String("instance", "", "Named instance to check")
 	_ = fs.Parse(args)
 
-	manifest, _, _, _ := loadProfile("")
+	in, err := newInstance(*instanceName)
+	if err != nil {
+		return err
+	}
+	manifest, _, prof, _ := loadActiveProfile(in, *profile)
+	runtimes := newRuntimeFactory()
+	cfg, reason := runtimeChoice(*runtimeName, prof, recordedRuntime(runtimes, in))
 
 	report := doctor.Run(ctx, doctor.Options{
-		Manifest: manifest,
-		Fix:      *fix,
+		Manifest:      manifest,
+		Fix:           *fix,
+		Runtimes:      runtimes,
+		Runtime:       cfg,
+		RuntimeReason: reason,
 	})
 
 	doctor.PrintReport(os.Stdout, report, *outputJSON)
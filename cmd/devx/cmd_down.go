@@ -2,52 +2,71 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/dever-labs/devx/internal/k8s"
+	"github.com/dever-labs/devx/internal/runtime"
 )
 
 func runDown(ctx context.Context, args []string) error {
 	fs := flag.NewFlagSet("down", flag.ExitOnError)
 	volumes := fs.Bool("volumes", false, "Remove volumes")
-	_ = fs.Parse(args)
+	target := addTargetFlags(fs)
+	services := parseArgs(fs, args)
 
-	manifest, profName, prof, err := loadProfile("")
+	in, err := newInstance(*target.instance)
+	if err != nil {
+		return err
+	}
+	manifest, profName, prof, err := loadActiveProfile(in, *target.profile)
 	if err != nil {
 		return err
 	}
 
-	rt, err := selectRuntime(ctx)
+	rt, err := selectRuntime(ctx, *target.runtime, prof, in)
 	if err != nil {
 		return err
 	}
 
-	enableTelemetry := telemetryFromState()
 	runtimeMode := profileRuntime(prof)
 	if runtimeMode == "k8s" {
+		if len(services) > 0 {
+			return errors.New("stopping individual services is not supported by the k8s runtime")
+		}
+		if in.name != "" {
+			return errors.New("named instances are not supported by the k8s runtime")
+		}
 		return runDownK8s(ctx)
 	}
 
-	composePath := filepath.Join(devxDir, composeFile)
-	if !fileExists(composePath) {
-		if err := ensureDevxDir(); err != nil {
-			return err
-		}
-		if err := writeCompose(composePath, manifest, profName, prof, nil, enableTelemetry); err != nil {
+	composePath, err := prepareCompose(in, manifest, profName, prof, *target.force)
+	if err != nil {
+		return err
+	}
+	projectName := in.projectName(manifest)
+
+	if len(services) > 0 {
+		if err := checkServiceNames(prof, services); err != nil {
 			return err
 		}
+		return rt.Down(ctx, composePath, projectName, runtime.DownOptions{Volumes: *volumes, Services: services})
 	}
 
 	if len(prof.Hooks.BeforeDown) > 0 {
 		fmt.Println("Running beforeDown hooks...")
-		if _, err := runHooks(ctx, rt, composePath, manifest.Project.Name, prof.Hooks.BeforeDown); err != nil {
+		if _, err := runHooks(ctx, rt, composePath, projectName, prof.Hooks.BeforeDown); err != nil {
 			return err
 		}
 	}
 
-	return rt.Down(ctx, composePath, manifest.Project.Name, *volumes)
+	if err := rt.Down(ctx, composePath, projectName, runtime.DownOptions{Volumes: *volumes}); err != nil {
+		return err
+	}
+	return os.RemoveAll(in.secretsPath())
 }
 
 func runDownK8s(ctx context.Context) error {
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/dever-labs/devx/internal/runtime"
+)
+
+func runEvents(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("events", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Emit one JSON event per line")
+	since := fs.String("since", "", "Replay events since a duration (10m) or timestamp")
+	target := addTargetFlags(fs)
+	services := parseArgs(fs, args)
+
+	in, err := newInstance(*target.instance)
+	if err != nil {
+		return err
+	}
+	manifest, profName, prof, err := loadActiveProfile(in, *target.profile)
+	if err != nil {
+		return err
+	}
+
+	if profileRuntime(prof) == "k8s" {
+		return errors.New("events for k8s runtime are not supported yet")
+	}
+	if err := checkServiceNames(prof, services); err != nil {
+		return err
+	}
+
+	rt, err := selectRuntime(ctx, *target.runtime, prof, in)
+	if err != nil {
+		return err
+	}
+
+	composePath, err := prepareCompose(in, manifest, profName, prof, *target.force)
+	if err != nil {
+		return err
+	}
+	projectName := in.projectName(manifest)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	events, errs := rt.Events(ctx, composePath, projectName, runtime.EventsOptions{Since: *since})
+	if err := printEvents(os.Stdout, events, services, *jsonOut); err != nil {
+		return err
+	}
+	if err := <-errs; err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+// printEvents writes each event for services (all when empty) to w until
+// events is closed, as a line of text or, with jsonOut, of JSON.
+func printEvents(w io.Writer, events <-chan runtime.Event, services []string, jsonOut bool) error {
+	want := map[string]bool{}
+	for _, name := range services {
+		want[name] = true
+	}
+	enc := json.NewEncoder(w)
+	for e := range events {
+		if len(want) > 0 && !want[e.Service] {
+			continue
+		}
+		if jsonOut {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Fprintln(w, formatEvent(e))
+	}
+	return nil
+}
+
+// formatEvent describes e on one line, e.g. "12:04:05  api  die (exit 137)"
+// or "12:04:05  db  health: unhealthy".
+func formatEvent(e runtime.Event) string {
+	service := e.Service
+	if service == "" {
+		service = e.Container
+	}
+	action := e.Action
+	if e.Action == runtime.EventHealth && e.Health != "" {
+		action = "health: " + e.Health
+	}
+	line := fmt.Sprintf("%s  %s  %s", e.Time.Local().Format("15:04:05"), service, action)
+	if e.ExitCode != nil {
+		line += fmt.Sprintf(" (exit %d)", *e.ExitCode)
+	}
+	return line
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
+)
+
+func TestPrintEvents(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	exit := 137
+	send := func() <-chan devxruntime.Event {
+		ch := make(chan devxruntime.Event, 3)
+		ch <- devxruntime.Event{Time: at, Project: "demo", Service: "api", Container: "demo-api-1", Action: devxruntime.EventDie, ExitCode: &exit}
+		ch <- devxruntime.Event{Time: at, Project: "demo", Service: "db", Container: "demo-db-1", Action: devxruntime.EventHealth, Health: "unhealthy"}
+		ch <- devxruntime.Event{Time: at, Project: "demo", Service: "api", Container: "demo-api-1", Action: devxruntime.EventStart}
+		close(ch)
+		return ch
+	}
+
+	var text bytes.Buffer
+	if err := printEvents(&text, send(), nil, false); err != nil {
+		t.Fatal(err)
+	}
+	want := "03:04:05  api  die (exit 137)\n03:04:05  db  health: unhealthy\n03:04:05  api  start\n"
+	if text.String() != want {
+		t.Fatalf("text output:\n%s\nwant:\n%s", text.String(), want)
+	}
+
+	var ndjson bytes.Buffer
+	if err := printEvents(&ndjson, send(), []string{"api"}, true); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(ndjson.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected the two api events, got:\n%s", ndjson.String())
+	}
+	var first map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if first["action"] != "die" || first["service"] != "api" || first["exitCode"] != float64(137) || first["container"] != "demo-api-1" {
+		t.Errorf("unexpected event: %s", lines[0])
+	}
+	if strings.Contains(lines[1], "exitCode") || strings.Contains(lines[1], "health") {
+		t.Errorf("expected empty fields to be omitted: %s", lines[1])
+	}
+}
@@ -3,8 +3,13 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
-	"path/filepath"
+	"io"
+	"os"
+	"strings"
+
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
 )
 
 func runExec(ctx context.Context, args []string) error {
@@ -23,38 +28,136 @@ func runExec(ctx context.Context, args []string) error {
 		return errors.New("exec requires a command after --")
 	}
 
-	service := args[0]
-	cmdArgs := args[sep+1:]
+	fs := flag.NewFlagSet("exec", flag.ExitOnError)
+	flags := addExecFlags(fs)
+	positional := parseArgs(fs, args[:sep])
+	if len(positional) != 1 {
+		return errors.New("exec requires exactly one service name before --")
+	}
 
-	manifest, profName, prof, err := loadProfile("")
+	session, err := flags.open(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	code, err := session.exec(ctx, args[sep+1:])
 	if err != nil {
 		return err
 	}
+	if code != 0 {
+		return &exitCodeError{code: code}
+	}
+	return nil
+}
 
-	if profileRuntime(prof) == "k8s" {
-		return errors.New("exec for k8s runtime is not supported yet")
+// execFlags are the flags devx exec and devx shell share.
+type execFlags struct {
+	*targetFlags
+	user    *string
+	workdir *string
+	env     stringList
+	noTTY   *bool
+}
+
+func addExecFlags(fs *flag.FlagSet) *execFlags {
+	f := &execFlags{
+		targetFlags: addTargetFlags(fs),
+		user:        fs.String("user", "", "User to run the command as, e.g. root or 1000:1000"),
+		workdir:     fs.String("workdir", "", "Directory to run the command in, inside the container"),
 	}
+	fs.Var(&f.env, "env", "Set an environment variable, as KEY=VALUE or KEY to pass the host's value (repeatable)")
+	f.noTTY = fs.Bool("no-tty", false, "Do not allocate a terminal, even when devx runs in one")
+	fs.BoolVar(f.noTTY, "T", false, "Shorthand for --no-tty")
+	return f
+}
+
+// execSession runs commands in one service's container.
+type execSession struct {
+	rt          devxruntime.Runtime
+	composePath string
+	projectName string
+	service     string
+	opts        devxruntime.ExecOptions
+}
 
-	rt, err := selectRuntime(ctx)
+// open resolves the environment the flags select and checks that service
+// is in it.
+func (f *execFlags) open(ctx context.Context, service string) (*execSession, error) {
+	in, err := newInstance(*f.instance)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	manifest, profName, prof, err := loadActiveProfile(in, *f.profile)
+	if err != nil {
+		return nil, err
 	}
 
-	enableTelemetry := telemetryFromState()
-	composePath := filepath.Join(devxDir, composeFile)
-	if err := ensureDevxDir(); err != nil {
-		return err
+	if profileRuntime(prof) == "k8s" {
+		return nil, errors.New("exec for k8s runtime is not supported yet")
 	}
-	if err := writeCompose(composePath, manifest, profName, prof, nil, enableTelemetry); err != nil {
-		return err
+	if err := checkServiceNames(prof, []string{service}); err != nil {
+		return nil, err
+	}
+	env, err := execEnv(f.env)
+	if err != nil {
+		return nil, err
 	}
 
-	code, err := rt.Exec(ctx, composePath, manifest.Project.Name, service, cmdArgs)
+	rt, err := selectRuntime(ctx, *f.runtime, prof, in)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if code != 0 {
-		return fmt.Errorf("exec exited with code %d", code)
+
+	composePath, err := prepareCompose(in, manifest, profName, prof, *f.force)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return &execSession{
+		rt:          rt,
+		composePath: composePath,
+		projectName: in.projectName(manifest),
+		service:     service,
+		opts: devxruntime.ExecOptions{
+			User:    *f.user,
+			Workdir: *f.workdir,
+			Env:     env,
+			TTY:     !*f.noTTY && isTerminal(os.Stdin) && isTerminal(os.Stdout),
+			Stdin:   os.Stdin,
+			Stdout:  os.Stdout,
+			Stderr:  os.Stderr,
+		},
+	}, nil
+}
+
+// exec runs cmd connected to devx's own stdin, stdout and stderr, with a
+// terminal when both stdin and stdout are one.
+func (s *execSession) exec(ctx context.Context, cmd []string) (int, error) {
+	opts := s.opts
+	opts.Cmd = cmd
+	return s.rt.Exec(ctx, s.composePath, s.projectName, s.service, opts)
+}
+
+// probe runs cmd without input, output or a terminal, for its exit code.
+func (s *execSession) probe(ctx context.Context, cmd []string) (int, error) {
+	opts := s.opts
+	opts.Cmd = cmd
+	opts.TTY = false
+	opts.Stdin, opts.Stdout, opts.Stderr = nil, io.Discard, io.Discard
+	return s.rt.Exec(ctx, s.composePath, s.projectName, s.service, opts)
+}
+
+// execEnv turns --env values into KEY=VALUE pairs, taking the value of a
+// bare KEY from devx's own environment.
+func execEnv(values []string) ([]string, error) {
+	env := make([]string, 0, len(values))
+	for _, v := range values {
+		key, _, hasValue := strings.Cut(v, "=")
+		if key == "" {
+			return nil, fmt.Errorf("invalid --env %q: use KEY=VALUE or KEY", v)
+		}
+		if !hasValue {
+			v = key + "=" + os.Getenv(key)
+		}
+		env = append(env, v)
+	}
+	return env, nil
 }
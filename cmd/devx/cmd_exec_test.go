@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
+)
+
+func TestExecEnv(t *testing.T) {
+	t.Setenv("DEVX_TEST_TOKEN", "s3cret")
+	got, err := execEnv([]string{"A=1", "B=", "DEVX_TEST_TOKEN", "C=x=y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"A=1", "B=", "DEVX_TEST_TOKEN=s3cret", "C=x=y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, err := execEnv([]string{"=1"}); err == nil {
+		t.Fatal("expected an error for an empty name")
+	}
+}
+
+// execRuntime records the options of each exec.
+type execRuntime struct {
+	devxruntime.Runtime
+	execs []devxruntime.ExecOptions
+}
+
+func (r *execRuntime) Exec(_ context.Context, _, _, _ string, opts devxruntime.ExecOptions) (int, error) {
+	r.execs = append(r.execs, opts)
+	return 0, nil
+}
+
+func TestExecSession(t *testing.T) {
+	rt := &execRuntime{}
+	s := &execSession{rt: rt, service: "api", opts: devxruntime.ExecOptions{User: "root", TTY: true, Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}}
+	if _, err := s.probe(context.Background(), []string{"sh", "-c", "command -v sh"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.exec(context.Background(), []string{"sh"}); err != nil {
+		t.Fatal(err)
+	}
+
+	probe, exec := rt.execs[0], rt.execs[1]
+	if probe.TTY || probe.Stdin != nil || probe.Stdout != io.Discard || probe.User != "root" {
+		t.Fatalf("expected the probe to run detached from the terminal as the same user, got %+v", probe)
+	}
+	if !exec.TTY || exec.Stdin != os.Stdin || !reflect.DeepEqual(exec.Cmd, []string{"sh"}) {
+		t.Fatalf("expected the command to get the terminal, got %+v", exec)
+	}
+}
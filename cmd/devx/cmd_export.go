@@ -31,7 +31,7 @@ func runExport(ctx context.Context, args []string) error {
 	switch *format {
 	case "compose":
 		lockfile, _ := lock.Load(lockFile)
-		output, err := buildCompose(manifest, profName, prof, lockfile, false)
+		output, err := buildCompose(manifest, "", profName, prof, lockfile, false)
 		if err != nil {
 			return err
 		}
@@ -44,7 +44,11 @@ func runExport(ctx context.Context, args []string) error {
 	case "k8s":
 		prof = resolveDepImages(prof)
 		prof = resolveConnections(manifest, prof)
-		output, err := k8s.Render(manifest, profName, prof, "")
+		secretValues, err := resolveSecrets(ctx, manifest, prof)
+		if err != nil {
+			return err
+		}
+		output, err := k8s.Render(manifest, profName, prof, "", secretValues)
 		if err != nil {
 			return err
 		}
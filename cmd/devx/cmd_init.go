@@ -4,6 +4,8 @@ import (
 	"flag"
 	"fmt"
 	"os"
+
+	"github.com/dever-labs/devx/internal/config"
 )
 
 func runInit(args []string) error {
@@ -14,7 +16,7 @@ func runInit(args []string) error {
 		return fmt.Errorf("%s already exists", manifestFile)
 	}
 
-	stub := "version: 1\n\nproject:\n  name: my-app\n  defaultProfile: local\n\nprofiles:\n  local:\n    services:\n      api:\n        build:\n          context: ./api\n          dockerfile: Dockerfile\n        ports:\n          - \"8080:8080\"\n        env:\n          ASPNETCORE_ENVIRONMENT: Development\n        dependsOn: [db]\n        health:\n          httpGet: \"http://localhost:8080/health\"\n          interval: 5s\n          retries: 30\n\n    deps:\n      db:\n        kind: postgres\n        version: \"16\"\n        env:\n          POSTGRES_PASSWORD: postgres\n        ports: [\"5432:5432\"]\n        volume: \"db-data:/var/lib/postgresql/data\"\n"
+	stub := "# yaml-language-server: $schema=" + config.SchemaID + "\n\nversion: 2\n\nproject:\n  name: my-app\n  defaultProfile: local\n\nprofiles:\n  local:\n    services:\n      api:\n        build:\n          context: ./api\n          dockerfile: Dockerfile\n        ports:\n          - \"8080:8080\"\n        env:\n          ASPNETCORE_ENVIRONMENT: Development\n        dependsOn: [db]\n        health:\n          http:\n            url: \"http://localhost:8080/health\"\n          interval: 5s\n          retries: 30\n\n    deps:\n      db:\n        kind: postgres\n        version: \"16\"\n        env:\n          POSTGRES_PASSWORD: postgres\n        ports: [\"5432:5432\"]\n        volume: \"db-data:/var/lib/postgresql/data\"\n"
 
 	if err := os.WriteFile(manifestFile, []byte(stub), 0644); err != nil {
 		return err
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 
 	"github.com/dever-labs/devx/internal/lock"
@@ -14,13 +15,20 @@ func runLock(ctx context.Context, args []string) error {
 	if len(args) == 0 || args[0] != "update" {
 		return errors.New("lock requires 'update'")
 	}
+	fs := flag.NewFlagSet("lock update", flag.ExitOnError)
+	runtimeName := fs.String("runtime", "", "Container runtime: docker, podman, nerdctl or docker-engine (default: detected)")
+	_ = fs.Parse(args[1:])
 
 	manifest, profName, prof, err := loadProfile("")
 	if err != nil {
 		return err
 	}
+	in, err := newInstance("")
+	if err != nil {
+		return err
+	}
 
-	rt, err := selectRuntime(ctx)
+	rt, err := selectRuntime(ctx, *runtimeName, prof, in)
 	if err != nil {
 		return err
 	}
@@ -4,24 +4,49 @@ import (
 	"context"
 	"errors"
 	"flag"
-	"path/filepath"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
 
 	"github.com/dever-labs/devx/internal/runtime"
+	"github.com/dever-labs/devx/internal/util"
 )
 
 func runLogs(ctx context.Context, args []string) error {
 	fs := flag.NewFlagSet("logs", flag.ExitOnError)
 	follow := fs.Bool("follow", false, "Follow logs")
+	fs.BoolVar(follow, "f", false, "Shorthand for --follow")
 	since := fs.String("since", "", "Show logs since")
-	jsonOut := fs.Bool("json", false, "JSON output")
-	_ = fs.Parse(args)
+	tail := fs.Int("tail", 0, "Show only the last N lines of each container's logs")
+	grep := fs.String("grep", "", "Show only lines whose message matches this regular expression")
+	level := fs.String("level", "", "Show only lines at this level or above: trace, debug, info, warn, error or fatal")
+	jsonOut := fs.Bool("json", false, "Emit one JSON object per line with time, service, container, stream, level and message")
+	noColor := fs.Bool("no-color", false, "Do not colour service names and levels")
+	target := addTargetFlags(fs)
+	services := parseArgs(fs, args)
 
-	var service string
-	if fs.NArg() > 0 {
-		service = fs.Arg(0)
+	if *tail < 0 {
+		return errors.New("--tail must not be negative")
+	}
+	opts := logViewOptions{
+		JSON:  *jsonOut,
+		Color: !*jsonOut && !*noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout),
+		Level: *level,
+	}
+	if *grep != "" {
+		re, err := regexp.Compile(*grep)
+		if err != nil {
+			return fmt.Errorf("invalid --grep: %w", err)
+		}
+		opts.Grep = re
 	}
 
-	manifest, profName, prof, err := loadProfile("")
+	in, err := newInstance(*target.instance)
+	if err != nil {
+		return err
+	}
+	manifest, profName, prof, err := loadActiveProfile(in, *target.profile)
 	if err != nil {
 		return err
 	}
@@ -29,31 +54,60 @@ func runLogs(ctx context.Context, args []string) error {
 	if profileRuntime(prof) == "k8s" {
 		return errors.New("logs for k8s runtime are not supported yet")
 	}
+	if err := checkServiceNames(prof, services); err != nil {
+		return err
+	}
 
-	rt, err := selectRuntime(ctx)
+	rt, err := selectRuntime(ctx, *target.runtime, prof, in)
 	if err != nil {
 		return err
 	}
 
-	enableTelemetry := telemetryFromState()
-	composePath := filepath.Join(devxDir, composeFile)
-	if err := ensureDevxDir(); err != nil {
+	composePath, err := prepareCompose(in, manifest, profName, prof, *target.force)
+	if err != nil {
 		return err
 	}
-	if err := writeCompose(composePath, manifest, profName, prof, nil, enableTelemetry); err != nil {
+	projectName := in.projectName(manifest)
+
+	shown := services
+	if len(shown) == 0 {
+		shown = append(util.SortedKeys(prof.Services), util.SortedKeys(prof.Deps)...)
+	}
+	for _, name := range shown {
+		opts.Width = max(opts.Width, len(name))
+	}
+	view, err := newLogView(os.Stdout, projectName, opts)
+	if err != nil {
 		return err
 	}
 
-	reader, err := rt.Logs(ctx, composePath, manifest.Project.Name, runtime.LogsOptions{
-		Service: service,
-		Follow:  *follow,
-		Since:   *since,
-		JSON:    *jsonOut,
+	stderrR, stderrW := io.Pipe()
+	reader, err := rt.Logs(ctx, composePath, projectName, runtime.LogsOptions{
+		Services: services,
+		Follow:   *follow,
+		Since:    *since,
+		JSON:     *jsonOut,
+		Tail:     *tail,
+		Stderr:   stderrW,
 	})
 	if err != nil {
 		return err
 	}
 	defer reader.Close()
 
-	return streamLogs(reader, *jsonOut)
+	stderrDone := make(chan error, 1)
+	go func() {
+		err := view.scan(stderrR, runtime.StreamStderr)
+		if err != nil {
+			// Keep the runtime from blocking on a stream nobody reads.
+			_, _ = io.Copy(io.Discard, stderrR)
+		}
+		stderrDone <- err
+	}()
+	err = view.scan(reader, runtime.StreamStdout)
+	stderrW.Close()
+	if stderrErr := <-stderrDone; err == nil {
+		err = stderrErr
+	}
+	return err
 }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dever-labs/devx/internal/runtime"
+	"github.com/dever-labs/devx/internal/ui"
+)
+
+// instanceSummary is one running or stopped devx environment found by ls.
+type instanceSummary struct {
+	Project  string `json:"project"`
+	Instance string `json:"instance"`
+	Profile  string `json:"profile"`
+	Running  int    `json:"running"`
+	Total    int    `json:"total"`
+	Dir      string `json:"dir,omitempty"`
+}
+
+func runLs(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("ls", flag.ExitOnError)
+	outputJSON := fs.Bool("json", false, "Emit instances as JSON")
+	runtimeName := fs.String("runtime", "", "Container runtime: docker, podman, nerdctl or docker-engine (default: detected)")
+	_ = fs.Parse(args)
+
+	in, err := newInstance("")
+	if err != nil {
+		return err
+	}
+	rt, err := selectRuntime(ctx, *runtimeName, nil, in)
+	if err != nil {
+		return err
+	}
+	lister, ok := rt.(runtime.Lister)
+	if !ok {
+		return fmt.Errorf("%s cannot list containers across projects", rt.Name())
+	}
+	containers, err := lister.List(ctx)
+	if err != nil {
+		return err
+	}
+	summaries := summarizeInstances(containers)
+
+	if *outputJSON {
+		data, err := json.MarshalIndent(summaries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if len(summaries) == 0 {
+		fmt.Println("No devx environments found")
+		return nil
+	}
+	headers := []string{"Project", "Instance", "Profile", "Running", "Directory"}
+	rows := make([][]string, 0, len(summaries))
+	for _, s := range summaries {
+		rows = append(rows, []string{s.Project, s.Instance, s.Profile, fmt.Sprintf("%d/%d", s.Running, s.Total), s.Dir})
+	}
+	ui.PrintTable(os.Stdout, headers, rows)
+	return nil
+}
+
+// summarizeInstances groups containers by the devx project and instance
+// they belong to, sorted by project then instance.
+func summarizeInstances(containers []runtime.Container) []instanceSummary {
+	byKey := map[string]*instanceSummary{}
+	for _, c := range containers {
+		instance := c.Labels["devx.instance"]
+		if instance == "" {
+			instance = "default"
+		}
+		key := c.Labels["devx.project"] + "\x00" + instance
+		s, ok := byKey[key]
+		if !ok {
+			s = &instanceSummary{
+				Project:  c.Labels["devx.project"],
+				Instance: instance,
+				Profile:  c.Labels["devx.profile"],
+				Dir:      projectDir(c.Labels["com.docker.compose.project.working_dir"]),
+			}
+			byKey[key] = s
+		}
+		s.Total++
+		if c.State == "running" {
+			s.Running++
+		}
+	}
+
+	out := make([]instanceSummary, 0, len(byKey))
+	for _, s := range byKey {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Project != out[j].Project {
+			return out[i].Project < out[j].Project
+		}
+		return out[i].Instance < out[j].Instance
+	})
+	return out
+}
+
+// projectDir turns the compose working directory, which is .devx or an
+// instance directory inside it, into the directory holding devx.yaml.
+func projectDir(workingDir string) string {
+	sep := string(filepath.Separator)
+	if i := strings.LastIndex(workingDir, sep+devxDir); i >= 0 {
+		return workingDir[:i]
+	}
+	return workingDir
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dever-labs/devx/internal/config"
+	"github.com/dever-labs/devx/internal/util"
+)
+
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	file := fs.String("file", manifestFile, "Path to devx.yaml to migrate")
+	dryRun := fs.Bool("dry-run", false, "Print a diff of the changes instead of writing them")
+	_ = fs.Parse(args)
+
+	files, err := config.Migrate(*file)
+	if err != nil {
+		return err
+	}
+
+	changed := 0
+	for _, f := range files {
+		if bytes.Equal(f.Original, f.Migrated) {
+			continue
+		}
+		changed++
+		if *dryRun {
+			name := filepath.ToSlash(f.Path)
+			fmt.Print(util.UnifiedDiff("a/"+name, "b/"+name, string(f.Original), string(f.Migrated)))
+			continue
+		}
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(f.Path, f.Migrated, info.Mode().Perm()); err != nil {
+			return err
+		}
+		fmt.Printf("Migrated %s to version %d\n", f.Path, config.CurrentVersion)
+	}
+
+	if changed == 0 {
+		fmt.Printf("%s is already at version %d\n", *file, config.CurrentVersion)
+	}
+	return nil
+}
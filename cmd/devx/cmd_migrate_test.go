@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dever-labs/devx/internal/config"
+)
+
+const migrateManifest = `version: 1
+project:
+  name: my-app
+  defaultProfile: local
+profiles:
+  local:
+    services:
+      api:
+        image: nginx:alpine
+        ports: ["8080:80"]
+        health:
+          httpGet: http://localhost:8080/health
+`
+
+func TestRunMigrate(t *testing.T) {
+	path, cleanup := writeManifest(t, migrateManifest)
+	defer cleanup()
+
+	if err := runMigrate([]string{"--file", path, "--dry-run"}); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != migrateManifest {
+		t.Fatalf("dry run modified the file:\n%s", data)
+	}
+
+	if err := runMigrate([]string{"--file", path}); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	m, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("migrated manifest does not load: %v", err)
+	}
+	if m.Version != 2 || len(m.Unknown) != 0 {
+		t.Fatalf("unexpected migrated manifest: version %d, unknown %v", m.Version, m.Unknown)
+	}
+	if issues, _ := collectIssues(path); len(issues) != 0 {
+		t.Fatalf("expected no issues after migration, got %v", issues)
+	}
+}
@@ -1,15 +1,20 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/dever-labs/devx/internal/config"
 	"github.com/dever-labs/devx/internal/k8s"
 	"github.com/dever-labs/devx/internal/lock"
+	"github.com/dever-labs/devx/internal/ui"
+	"github.com/dever-labs/devx/internal/util"
 )
 
 func runRender(ctx context.Context, args []string) error {
@@ -18,18 +23,20 @@ func runRender(ctx context.Context, args []string) error {
 	}
 
 	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	profile := fs.String("profile", "", "Profile to render (extends chains are merged)")
 	write := fs.Bool("write", false, "Write to .devx/compose.yaml")
 	noTelemetry := fs.Bool("no-telemetry", false, "Disable telemetry stack")
+	showEnv := fs.Bool("show-env", false, "Prefix output with the interpolated variables and where each value came from")
 	_ = fs.Parse(args[1:])
 
-	manifest, profName, prof, err := loadProfile("")
+	manifest, profName, prof, err := loadProfile(*profile)
 	if err != nil {
 		return err
 	}
 
 	lockfile, _ := lock.Load(lockFile)
 
-	composed, err := buildCompose(manifest, profName, prof, lockfile, !*noTelemetry)
+	composed, err := buildCompose(manifest, "", profName, prof, lockfile, !*noTelemetry)
 	if err != nil {
 		return err
 	}
@@ -39,13 +46,39 @@ func runRender(ctx context.Context, args []string) error {
 			return err
 		}
 		composePath := filepath.Join(devxDir, composeFile)
-		return writeCompose(composePath, manifest, profName, prof, lockfile, !*noTelemetry)
+		return writeCompose(composePath, manifest, "", profName, prof, lockfile, !*noTelemetry)
 	}
 
+	if *showEnv {
+		fmt.Print(variablesComment(manifest.VariablesFor(profName)))
+	}
 	fmt.Print(composed)
 	return nil
 }
 
+// variablesComment renders the interpolated variables as a YAML comment block
+// so the output stays valid compose. Values of secret-looking names are masked.
+func variablesComment(vars []config.Variable) string {
+	if len(vars) == 0 {
+		return "# No ${VAR} references.\n"
+	}
+	rows := make([][]string, 0, len(vars))
+	for _, v := range vars {
+		value, source := util.MaskValue(v.Name, v.Value), v.Source
+		if !v.Resolved() {
+			value, source = "", "unset"
+		}
+		rows = append(rows, []string{v.Name, value, source, strings.Join(v.Paths, ", ")})
+	}
+	buf := &bytes.Buffer{}
+	ui.PrintTable(buf, []string{"Variable", "Value", "Source", "Used by"}, rows)
+	var out strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		out.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+	}
+	return out.String()
+}
+
 func runRenderK8s(ctx context.Context, args []string) error {
 	if len(args) == 0 || args[0] != "k8s" {
 		return errors.New("render requires 'compose' or 'k8s'")
@@ -62,7 +95,11 @@ func runRenderK8s(ctx context.Context, args []string) error {
 		return err
 	}
 
-	output, err := k8s.Render(manifest, profName, prof, *namespace)
+	secretValues, err := resolveSecrets(ctx, manifest, prof)
+	if err != nil {
+		return err
+	}
+	output, err := k8s.Render(manifest, profName, prof, *namespace, secretValues)
 	if err != nil {
 		return err
 	}
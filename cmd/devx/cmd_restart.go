@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dever-labs/devx/internal/graph"
+)
+
+func runRestart(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("restart", flag.ExitOnError)
+	target := addTargetFlags(fs)
+	services := parseArgs(fs, args)
+	if len(services) == 0 {
+		return errors.New("restart requires at least one service name")
+	}
+
+	in, err := newInstance(*target.instance)
+	if err != nil {
+		return err
+	}
+	manifest, profName, prof, err := loadActiveProfile(in, *target.profile)
+	if err != nil {
+		return err
+	}
+	if profileRuntime(prof) == "k8s" {
+		return errors.New("restart for k8s runtime is not supported yet")
+	}
+	if err := checkServiceNames(prof, services); err != nil {
+		return err
+	}
+
+	rt, err := selectRuntime(ctx, *target.runtime, prof, in)
+	if err != nil {
+		return err
+	}
+
+	composePath, err := prepareCompose(in, manifest, profName, prof, *target.force)
+	if err != nil {
+		return err
+	}
+	projectName := in.projectName(manifest)
+
+	fmt.Printf("Restarting %s\n", strings.Join(services, ", "))
+	if err := rt.Restart(ctx, composePath, projectName, services); err != nil {
+		return err
+	}
+	failures := waitForHealth(ctx, rt, composePath, projectName, prof, services, &plainProgress{w: os.Stdout})
+	if len(failures) > 0 {
+		g, err := graph.Build(prof)
+		if err != nil {
+			return err
+		}
+		return &startupError{graph: g, failures: failures}
+	}
+	return nil
+}
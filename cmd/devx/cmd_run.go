@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/dever-labs/devx/internal/config"
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
+	"github.com/dever-labs/devx/internal/util"
+)
+
+func runRun(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	target := addTargetFlags(fs)
+	noTTY := fs.Bool("no-tty", false, "Do not allocate a terminal, even when devx runs in one")
+	fs.BoolVar(noTTY, "T", false, "Shorthand for --no-tty")
+	positional := parseArgs(fs, args)
+	if len(positional) == 0 {
+		return errors.New("run requires a task name")
+	}
+	name, taskArgs := positional[0], positional[1:]
+
+	in, err := newInstance(*target.instance)
+	if err != nil {
+		return err
+	}
+	manifest, profName, prof, err := loadActiveProfile(in, *target.profile)
+	if err != nil {
+		return err
+	}
+	if err := config.ValidateTasks(manifest); err != nil {
+		return err
+	}
+	order, err := config.TaskOrder(manifest.Tasks, name)
+	if err != nil {
+		return err
+	}
+
+	// The container runtime is only set up once a task needs it, so host
+	// tasks run without one.
+	var (
+		runner      devxruntime.Runner
+		composePath string
+		projectName string
+	)
+	containerRunner := func() error {
+		if runner != nil {
+			return nil
+		}
+		if profileRuntime(prof) == "k8s" {
+			return errors.New("run for k8s runtime is not supported yet")
+		}
+		rt, err := selectRuntime(ctx, *target.runtime, prof, in)
+		if err != nil {
+			return err
+		}
+		r, ok := rt.(devxruntime.Runner)
+		if !ok {
+			return fmt.Errorf("%s cannot run one-off containers", rt.Name())
+		}
+		if composePath, err = prepareCompose(in, manifest, profName, prof, *target.force); err != nil {
+			return err
+		}
+		projectName = in.projectName(manifest)
+		runner = r
+		return nil
+	}
+
+	for _, taskName := range order {
+		task := manifest.Tasks[taskName]
+		var extra []string
+		if taskName == name {
+			extra = taskArgs
+		}
+		if len(order) > 1 {
+			fmt.Fprintf(os.Stderr, "▶ %s\n", taskName)
+		}
+
+		var code int
+		if task.Service == "" {
+			cmd := shellCommand(task.Run + shellArgs(runtime.GOOS, extra))
+			cmd.Dir = manifest.Dir
+			cmd.Env = append(os.Environ(), taskEnv(task.Env)...)
+			code, err = devxruntime.RunCommand(cmd, devxruntime.ExecOptions{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr})
+		} else {
+			if err := checkServiceNames(prof, []string{task.Service}); err != nil {
+				return fmt.Errorf("task '%s': %w in profile '%s'", taskName, err, profName)
+			}
+			if err := containerRunner(); err != nil {
+				return err
+			}
+			code, err = runner.Run(ctx, composePath, projectName, task.Service, devxruntime.ExecOptions{
+				Cmd:    append(append([]string{}, task.Command...), extra...),
+				Env:    taskEnv(task.Env),
+				TTY:    !*noTTY && isTerminal(os.Stdin) && isTerminal(os.Stdout),
+				Stdin:  os.Stdin,
+				Stdout: os.Stdout,
+				Stderr: os.Stderr,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("task '%s': %w", taskName, err)
+		}
+		if code != 0 {
+			if taskName != name {
+				fmt.Fprintf(os.Stderr, "task '%s' failed with exit code %d\n", taskName, code)
+			}
+			return &exitCodeError{code: code}
+		}
+	}
+	return nil
+}
+
+// taskEnv returns a task's environment as sorted KEY=VALUE pairs.
+func taskEnv(env map[string]string) []string {
+	out := make([]string, 0, len(env))
+	for _, k := range util.SortedKeys(env) {
+		out = append(out, k+"="+env[k])
+	}
+	return out
+}
+
+// shellArgs quotes args for the shell goos runs host tasks with and joins
+// them, each preceded by a space, to append to the task's command.
+func shellArgs(goos string, args []string) string {
+	var b strings.Builder
+	for _, arg := range args {
+		b.WriteByte(' ')
+		if goos == "windows" {
+			b.WriteString(cmdQuote(arg))
+		} else {
+			b.WriteString(posixQuote(arg))
+		}
+	}
+	return b.String()
+}
+
+// posixQuote quotes arg for sh, leaving it alone when it has nothing the
+// shell would interpret.
+func posixQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:,+@%") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// cmdQuote quotes arg for cmd.exe and the program it starts, leaving it
+// alone when it has no spaces or characters cmd treats specially.
+func cmdQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"&|<>^()") {
+		return arg
+	}
+	return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+}
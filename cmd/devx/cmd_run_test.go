@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestShellArgs(t *testing.T) {
+	args := []string{"-run", "TestAPI", "a b", "it's", "$HOME", ""}
+	if got, want := shellArgs("linux", args), ` -run TestAPI 'a b' 'it'\''s' '$HOME' ''`; got != want {
+		t.Errorf("posix: got %s, want %s", got, want)
+	}
+	if got, want := shellArgs("windows", []string{"-v", "a b", `say "hi"`, ""}), ` -v "a b" "say \"hi\"" ""`; got != want {
+		t.Errorf("windows: got %s, want %s", got, want)
+	}
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	out, err := exec.Command("sh", "-c", `printf '%s\n'`+shellArgs("linux", args)).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n"); strings.Join(got, "|") != strings.Join(args, "|") {
+		t.Errorf("sh saw %q, want %q", got, args)
+	}
+}
+
+func TestTaskEnv(t *testing.T) {
+	got := taskEnv(map[string]string{"B": "2", "A": "1=1"})
+	if strings.Join(got, ",") != "A=1=1,B=2" {
+		t.Errorf("got %v", got)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dever-labs/devx/internal/config"
+)
+
+// runSchema prints the JSON Schema for devx.yaml, generated from the manifest
+// types, for use with editors and CI validators.
+func runSchema(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	out := fs.String("out", "", "Write the schema to this file instead of stdout")
+	_ = fs.Parse(args)
+
+	data, err := config.SchemaJSON()
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", *out)
+	return nil
+}
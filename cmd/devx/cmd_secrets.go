@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dever-labs/devx/internal/secrets"
+	"github.com/dever-labs/devx/internal/ui"
+)
+
+// runSecrets manages the local encrypted store used by `store:` secrets.
+func runSecrets(args []string) error {
+	if len(args) == 0 {
+		return errors.New("secrets requires 'set', 'list' or 'rm'")
+	}
+
+	store, err := secrets.OpenStore(filepath.Join(filepath.Dir(manifestFile), secrets.StorePath))
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "set":
+		if len(args) != 2 {
+			return errors.New("usage: devx secrets set <name>  (value is read from stdin)")
+		}
+		if isTerminal(os.Stdin) {
+			fmt.Fprintf(os.Stderr, "Enter value for %s, then press Ctrl+D: ", args[1])
+		}
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		store.Set(args[1], strings.TrimRight(string(data), "\r\n"))
+		if err := store.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Stored secret %s\n", args[1])
+
+	case "list":
+		names := store.Names()
+		if len(names) == 0 {
+			fmt.Println("No secrets stored.")
+			return nil
+		}
+		rows := make([][]string, 0, len(names))
+		for _, name := range names {
+			rows = append(rows, []string{name})
+		}
+		ui.PrintTable(os.Stdout, []string{"Name"}, rows)
+
+	case "rm":
+		if len(args) != 2 {
+			return errors.New("usage: devx secrets rm <name>")
+		}
+		if _, ok := store.Get(args[1]); !ok {
+			return fmt.Errorf("secret %s is not stored", args[1])
+		}
+		store.Delete(args[1])
+		if err := store.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Removed secret %s\n", args[1])
+
+	default:
+		return fmt.Errorf("unknown secrets command %q — use set, list or rm", args[0])
+	}
+	return nil
+}
+
+// isTerminal reports whether f is an interactive terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+)
+
+// shellScript starts the first of bash, zsh and ash the container has,
+// falling back to sh.
+const shellScript = `for s in bash zsh ash; do if command -v "$s" >/dev/null 2>&1; then exec "$s"; fi; done; exec sh`
+
+func runShell(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("shell", flag.ExitOnError)
+	flags := addExecFlags(fs)
+	shell := fs.String("shell", "", "Shell to start instead of the best one found, e.g. /bin/bash")
+	positional := parseArgs(fs, args)
+	if len(positional) != 1 {
+		return errors.New("shell requires exactly one service name")
+	}
+	service := positional[0]
+
+	session, err := flags.open(ctx, service)
+	if err != nil {
+		return err
+	}
+	cmd := []string{*shell}
+	if *shell == "" {
+		// Check for sh before starting it: once an interactive shell has
+		// run, its exit code is that of the last command typed in it.
+		code, err := session.probe(ctx, []string{"sh", "-c", "command -v sh"})
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return fmt.Errorf("no shell found in service '%s': its image has no sh", service)
+		}
+		cmd = []string{"sh", "-c", shellScript}
+	}
+	code, err := session.exec(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return &exitCodeError{code: code}
+	}
+	return nil
+}
@@ -7,7 +7,6 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
 	"sort"
 
 	"github.com/dever-labs/devx/internal/runtime"
@@ -17,9 +16,14 @@ import (
 func runStatus(ctx context.Context, args []string) error {
 	fs := flag.NewFlagSet("status", flag.ExitOnError)
 	outputJSON := fs.Bool("json", false, "Emit status as JSON")
+	target := addTargetFlags(fs)
 	_ = fs.Parse(args)
 
-	manifest, profName, prof, err := loadProfile("")
+	in, err := newInstance(*target.instance)
+	if err != nil {
+		return err
+	}
+	manifest, profName, prof, err := loadActiveProfile(in, *target.profile)
 	if err != nil {
 		return err
 	}
@@ -28,21 +32,18 @@ func runStatus(ctx context.Context, args []string) error {
 		return errors.New("status for k8s runtime is not supported yet")
 	}
 
-	rt, err := selectRuntime(ctx)
+	rt, err := selectRuntime(ctx, *target.runtime, prof, in)
 	if err != nil {
 		return err
 	}
 
-	enableTelemetry := telemetryFromState()
-	composePath := filepath.Join(devxDir, composeFile)
-	if err := ensureDevxDir(); err != nil {
-		return err
-	}
-	if err := writeCompose(composePath, manifest, profName, prof, nil, enableTelemetry); err != nil {
+	composePath, err := prepareCompose(in, manifest, profName, prof, *target.force)
+	if err != nil {
 		return err
 	}
+	projectName := in.projectName(manifest)
 
-	statuses, err := rt.Status(ctx, composePath, manifest.Project.Name)
+	statuses, err := rt.Status(ctx, composePath, projectName)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+)
+
+func runStop(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("stop", flag.ExitOnError)
+	target := addTargetFlags(fs)
+	services := parseArgs(fs, args)
+	if len(services) == 0 {
+		return errors.New("stop requires at least one service name; use 'devx down' to stop everything")
+	}
+
+	in, err := newInstance(*target.instance)
+	if err != nil {
+		return err
+	}
+	manifest, profName, prof, err := loadActiveProfile(in, *target.profile)
+	if err != nil {
+		return err
+	}
+	if profileRuntime(prof) == "k8s" {
+		return errors.New("stop for k8s runtime is not supported yet")
+	}
+	if err := checkServiceNames(prof, services); err != nil {
+		return err
+	}
+
+	rt, err := selectRuntime(ctx, *target.runtime, prof, in)
+	if err != nil {
+		return err
+	}
+
+	composePath, err := prepareCompose(in, manifest, profName, prof, *target.force)
+	if err != nil {
+		return err
+	}
+	projectName := in.projectName(manifest)
+	return rt.Stop(ctx, composePath, projectName, services)
+}
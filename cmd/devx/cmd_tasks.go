@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dever-labs/devx/internal/config"
+	"github.com/dever-labs/devx/internal/ui"
+	"github.com/dever-labs/devx/internal/util"
+)
+
+// taskSummary is one task as listed by devx tasks --json.
+type taskSummary struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Run         string   `json:"run,omitempty"`
+	Service     string   `json:"service,omitempty"`
+	Command     []string `json:"command,omitempty"`
+	DependsOn   []string `json:"dependsOn,omitempty"`
+}
+
+func runTasks(args []string) error {
+	fs := flag.NewFlagSet("tasks", flag.ExitOnError)
+	outputJSON := fs.Bool("json", false, "Emit tasks as JSON")
+	_ = fs.Parse(args)
+
+	manifest, err := loadManifestOnly()
+	if err != nil {
+		return err
+	}
+	if err := config.ValidateTasks(manifest); err != nil {
+		return err
+	}
+
+	summaries := make([]taskSummary, 0, len(manifest.Tasks))
+	for _, name := range util.SortedKeys(manifest.Tasks) {
+		t := manifest.Tasks[name]
+		summaries = append(summaries, taskSummary{
+			Name:        name,
+			Description: t.Description,
+			Run:         t.Run,
+			Service:     t.Service,
+			Command:     t.Command,
+			DependsOn:   t.DependsOn,
+		})
+	}
+
+	if *outputJSON {
+		data, err := json.MarshalIndent(summaries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if len(summaries) == 0 {
+		fmt.Println("No tasks declared in devx.yaml")
+		return nil
+	}
+	headers := []string{"Task", "Runs in", "Depends on", "Description"}
+	rows := make([][]string, 0, len(summaries))
+	for _, s := range summaries {
+		where := "host"
+		if s.Service != "" {
+			where = s.Service
+		}
+		rows = append(rows, []string{s.Name, where, strings.Join(s.DependsOn, ", "), s.Description})
+	}
+	ui.PrintTable(os.Stdout, headers, rows)
+	return nil
+}
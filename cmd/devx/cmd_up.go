@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/dever-labs/devx/internal/config"
 	"github.com/dever-labs/devx/internal/k8s"
@@ -20,14 +22,26 @@ func runUp(ctx context.Context, args []string) error {
 	build := fs.Bool("build", false, "Build images")
 	pull := fs.Bool("pull", false, "Always pull images")
 	noTelemetry := fs.Bool("no-telemetry", false, "Disable telemetry stack")
-	_ = fs.Parse(args)
+	noDeps := fs.Bool("no-deps", false, "Start only the named services, not what they depend on")
+	watchFiles := fs.Bool("watch", false, "Sync, restart or rebuild services when their files change")
+	progressMode := fs.String("progress", "auto", "Startup progress: auto, tty, json or plain")
+	instanceName := fs.String("instance", "", "Start a separate, named instance of the environment")
+	runtimeName := fs.String("runtime", "", "Container runtime: docker, podman, nerdctl or docker-engine (default: detected)")
+	services := parseArgs(fs, args)
+	if *noDeps && len(services) == 0 {
+		return errors.New("--no-deps requires service names")
+	}
 
+	in, err := newInstance(*instanceName)
+	if err != nil {
+		return err
+	}
 	manifest, profName, prof, err := loadProfile(*profile)
 	if err != nil {
 		return err
 	}
 
-	rt, err := selectRuntime(ctx)
+	rt, err := selectRuntime(ctx, *runtimeName, prof, in)
 	if err != nil {
 		return err
 	}
@@ -42,39 +56,88 @@ func runUp(ctx context.Context, args []string) error {
 
 	runtimeMode := profileRuntime(prof)
 	if runtimeMode == "k8s" {
-		return runUpK8s(ctx, manifest, profName, prof)
+		if len(services) > 0 {
+			return errors.New("starting individual services is not supported by the k8s runtime")
+		}
+		if in.name != "" {
+			return errors.New("named instances are not supported by the k8s runtime")
+		}
+		return runUpK8s(ctx, in, manifest, profName, prof)
 	}
 
-	composePath := filepath.Join(devxDir, composeFile)
-	enableTelemetry := !*noTelemetry
-	if err := writeCompose(composePath, manifest, profName, prof, lockfile, enableTelemetry); err != nil {
+	composePath := in.composePath()
+	projectName := in.projectName(manifest)
+
+	// Named instances run beside others, so they always move ports that
+	// are in use.
+	autoPorts := manifest.Ports.Auto || in.name != ""
+	var previous map[string]int
+	if st := readState(in); st != nil && st.Profile == profName {
+		previous = st.Ports
+	}
+	assigned, err := allocatePorts(prof, previous, publishedPorts(ctx, rt, composePath, projectName), autoPorts)
+	if err != nil {
 		return err
 	}
+	applyPorts(prof, assigned)
+	if moved := describePorts(assigned); len(moved) > 0 {
+		fmt.Printf("Host ports in use were moved: %s\n", strings.Join(moved, ", "))
+	}
 
-	if err := rt.Up(ctx, composePath, manifest.Project.Name, runtime.UpOptions{Build: *build, Pull: *pull}); err != nil {
+	if err := os.MkdirAll(in.dir, 0755); err != nil {
+		return err
+	}
+	enableTelemetry := !*noTelemetry
+	if err := writeCompose(composePath, manifest, in.name, profName, prof, lockfile, enableTelemetry); err != nil {
+		return err
+	}
+	if err := writeSecretFiles(ctx, in, manifest, prof); err != nil {
 		return err
 	}
 
-	if err := waitForHealth(prof); err != nil {
+	progress, err := newStartupProgress(os.Stdout, *progressMode, isTerminal(os.Stdout))
+	if err != nil {
 		return err
 	}
+	opts := runtime.UpOptions{Build: *build, Pull: *pull, Services: services, NoDeps: *noDeps}
+	if err := startServices(ctx, rt, composePath, projectName, prof, opts, progress); err != nil {
+		var failed *startupError
+		if errors.As(err, &failed) {
+			reportFailure(ctx, rt, composePath, projectName, profName, prof, failed)
+		}
+		return err
+	}
+	if enableTelemetry && len(services) == 0 {
+		// The telemetry stack is not part of the profile's graph; starting
+		// the whole project brings it up alongside what is already running.
+		if err := rt.Up(ctx, composePath, projectName, opts); err != nil {
+			return err
+		}
+	}
 
 	var bgCmds []*exec.Cmd
-	if len(prof.Hooks.AfterUp) > 0 {
+	// Hooks describe the whole environment, so they run only when all of
+	// it was started.
+	if len(prof.Hooks.AfterUp) > 0 && len(services) == 0 {
 		fmt.Println("Running afterUp hooks...")
 		var err error
-		bgCmds, err = runHooks(ctx, rt, composePath, manifest.Project.Name, prof.Hooks.AfterUp)
+		bgCmds, err = runHooks(ctx, rt, composePath, projectName, prof.Hooks.AfterUp)
 		if err != nil {
 			return err
 		}
 	}
 
-	if err := writeState(state{Profile: profName, Runtime: rt.Name(), Telemetry: enableTelemetry}); err != nil {
+	st := state{Profile: profName, Runtime: rt.Name(), Telemetry: enableTelemetry, ManifestHash: manifestHash(manifest, profName, prof), Ports: assigned}
+	if err := writeState(in, st); err != nil {
 		fmt.Fprintf(os.Stderr, "warning: failed to write state: %v\n", err)
 	}
 
 	fmt.Println("Environment is up")
-	printLinks(ctx, rt, composePath, manifest.Project.Name)
+	printLinks(ctx, rt, composePath, projectName, prof, movedPorts(assigned))
+
+	if *watchFiles {
+		return runWatch(ctx, rt, composePath, projectName, manifest, prof, bgCmds)
+	}
 
 	if len(bgCmds) > 0 {
 		fmt.Println("\nBackground processes running — Ctrl+C to stop.")
@@ -84,10 +147,14 @@ func runUp(ctx context.Context, args []string) error {
 	return nil
 }
 
-func runUpK8s(ctx context.Context, manifest *config.Manifest, profName string, prof *config.Profile) error {
+func runUpK8s(ctx context.Context, in instance, manifest *config.Manifest, profName string, prof *config.Profile) error {
 	prof = resolveDepImages(prof)
 	prof = resolveConnections(manifest, prof)
-	output, err := k8s.Render(manifest, profName, prof, "")
+	secretValues, err := resolveSecrets(ctx, manifest, prof)
+	if err != nil {
+		return err
+	}
+	output, err := k8s.Render(manifest, profName, prof, "", secretValues)
 	if err != nil {
 		return err
 	}
@@ -104,7 +171,7 @@ func runUpK8s(ctx context.Context, manifest *config.Manifest, profName string, p
 		return err
 	}
 
-	if err := writeState(state{Profile: profName, Runtime: "k8s", Telemetry: false}); err != nil {
+	if err := writeState(in, state{Profile: profName, Runtime: "k8s", Telemetry: false, ManifestHash: manifestHash(manifest, profName, prof)}); err != nil {
 		fmt.Fprintf(os.Stderr, "warning: failed to write state: %v\n", err)
 	}
 
@@ -1,70 +1,331 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/dever-labs/devx/internal/config"
+	"github.com/dever-labs/devx/internal/ui"
+	"github.com/dever-labs/devx/internal/util"
 )
 
 func runValidate(args []string) error {
 	fs := flag.NewFlagSet("validate", flag.ExitOnError)
 	file := fs.String("file", manifestFile, "Path to devx.yaml to validate")
+	format := fs.String("format", "text", "Output format: text|json|sarif|github")
 	_ = fs.Parse(args)
 
-	manifest, err := config.Load(*file)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Parse error: %v\n", err)
-		return fmt.Errorf("validation failed")
+	var write func(w io.Writer, file string, issues []config.Issue) error
+	switch *format {
+	case "text":
+	case "json":
+		write = writeIssuesJSON
+	case "sarif":
+		write = writeIssuesSARIF
+	case "github":
+		write = writeIssuesGitHub
+	default:
+		return fmt.Errorf("unknown format %q — use text, json, sarif or github", *format)
 	}
 
-	var issues []string
+	issues, manifest := collectIssues(*file)
+	sortIssues(issues)
 
-	if err := config.Validate(manifest); err != nil {
-		if ve, ok := err.(*config.ValidationError); ok {
-			issues = append(issues, ve.Issues...)
-		} else {
-			issues = append(issues, err.Error())
+	if write != nil {
+		if err := write(os.Stdout, *file, issues); err != nil {
+			return err
+		}
+		if hasErrors(issues) {
+			return fmt.Errorf("validation failed")
 		}
+		return nil
 	}
 
-	for profName := range manifest.Profiles {
-		if err := config.ValidateProfile(manifest, profName); err != nil {
-			if ve, ok := err.(*config.ValidationError); ok {
-				for _, issue := range ve.Issues {
-					issues = append(issues, fmt.Sprintf("[profile:%s] %s", profName, issue))
-				}
-			} else {
-				issues = append(issues, fmt.Sprintf("[profile:%s] %s", profName, err.Error()))
+	if manifest != nil && manifest.MultiFile() {
+		printOrigins(manifest)
+	}
+
+	if len(issues) > 0 {
+		fmt.Fprintf(os.Stderr, "devx.yaml has %d issue(s):\n", len(issues))
+		for _, issue := range issues {
+			prefix := ""
+			if issue.Profile != "" {
+				prefix = fmt.Sprintf("[profile:%s] ", issue.Profile)
+			}
+			if issue.Severity == config.SeverityWarning {
+				prefix = "warning: " + prefix
 			}
+			fmt.Fprintf(os.Stderr, "  - %s%s\n", prefix, issue.String())
 		}
+		if hasErrors(issues) {
+			return fmt.Errorf("validation failed")
+		}
+		fmt.Println("devx.yaml is valid ✓ (with warnings)")
+		return nil
 	}
 
-	if err := config.ValidateTools(manifest); err != nil {
-		if ve, ok := err.(*config.ValidationError); ok {
-			issues = append(issues, ve.Issues...)
-		} else {
-			issues = append(issues, err.Error())
+	fmt.Println("devx.yaml is valid ✓")
+	return nil
+}
+
+// collectIssues runs every validation over the manifest at file, warnings
+// included. The manifest is nil when it could not be loaded at all.
+func collectIssues(file string) ([]config.Issue, *config.Manifest) {
+	manifest, err := config.Load(file)
+	if err != nil {
+		return []config.Issue{loadIssue(file, "", err)}, nil
+	}
+
+	issues := config.ManifestIssues(manifest)
+	for _, profName := range util.SortedKeys(manifest.Profiles) {
+		// Reload so each profile is checked against its own .env.<profile>.
+		profManifest, err := config.LoadWithOptions(file, config.LoadOptions{Profile: profName})
+		if err != nil {
+			issues = append(issues, loadIssue(file, profName, err))
+			continue
 		}
+		issues = append(issues, config.ProfileIssues(profManifest, profName)...)
+	}
+	issues = append(issues, config.ToolIssues(manifest)...)
+	issues = append(issues, config.SetupIssues(manifest)...)
+	issues = append(issues, config.TaskIssues(manifest)...)
+	return issues, manifest
+}
+
+var yamlLineRe = regexp.MustCompile(`line (\d+)`)
+
+// loadIssue wraps an error that prevented the manifest from loading, picking
+// up the line number from YAML syntax errors.
+func loadIssue(file, profile string, err error) config.Issue {
+	issue := config.Issue{
+		Rule:     "parse-error",
+		Severity: config.SeverityError,
+		Message:  err.Error(),
+		Profile:  profile,
+		File:     file,
+	}
+	if m := yamlLineRe.FindStringSubmatch(err.Error()); m != nil {
+		issue.Line, _ = strconv.Atoi(m[1])
+		issue.Column = 1
 	}
+	return issue
+}
+
+func sortIssues(issues []config.Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.Message < b.Message
+	})
+}
 
-	if err := config.ValidateSetup(manifest); err != nil {
-		if ve, ok := err.(*config.ValidationError); ok {
-			issues = append(issues, ve.Issues...)
-		} else {
-			issues = append(issues, err.Error())
+func hasErrors(issues []config.Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == config.SeverityError {
+			return true
 		}
 	}
+	return false
+}
 
-	if len(issues) > 0 {
-		fmt.Fprintf(os.Stderr, "devx.yaml has %d issue(s):\n", len(issues))
-		for _, issue := range issues {
-			fmt.Fprintf(os.Stderr, "  - %s\n", issue)
+func writeIssuesJSON(w io.Writer, file string, issues []config.Issue) error {
+	if issues == nil {
+		issues = []config.Issue{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{
+		"file":   file,
+		"valid":  !hasErrors(issues),
+		"issues": issues,
+	})
+}
+
+// sarifSrcRoot is the base SARIF locations are relative to: the root of
+// the repository devx.yaml is in.
+const sarifSrcRoot = "%SRCROOT%"
+
+// sarifRoot returns the repository root for file, the nearest directory
+// above it with a .git, or else file's own directory, so code scanning can
+// map locations to files in the repository.
+func sarifRoot(file string) string {
+	start, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return filepath.Dir(file)
+	}
+	for dir := start; ; {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return start
 		}
-		return fmt.Errorf("validation failed")
+		dir = parent
 	}
+}
 
-	fmt.Println("devx.yaml is valid ✓")
+// fileURI returns the file:// URI of an absolute path.
+func fileURI(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // Windows drive letters
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// writeIssuesSARIF emits a SARIF 2.1.0 log for code scanning uploads, with
+// locations relative to the repository root.
+func writeIssuesSARIF(w io.Writer, file string, issues []config.Issue) error {
+	type region struct {
+		StartLine   int `json:"startLine,omitempty"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+	type location struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI       string `json:"uri"`
+				URIBaseID string `json:"uriBaseId,omitempty"`
+			} `json:"artifactLocation"`
+			Region *region `json:"region,omitempty"`
+		} `json:"physicalLocation"`
+	}
+	type message struct {
+		Text string `json:"text"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations"`
+	}
+	type rule struct {
+		ID               string  `json:"id"`
+		ShortDescription message `json:"shortDescription"`
+	}
+
+	rules := make([]rule, 0, len(config.Rules))
+	for _, id := range util.SortedKeys(config.Rules) {
+		rules = append(rules, rule{ID: id, ShortDescription: message{Text: config.Rules[id]}})
+	}
+
+	root := sarifRoot(file)
+	results := make([]result, 0, len(issues))
+	for _, issue := range issues {
+		var loc location
+		uri := issue.File
+		if uri == "" {
+			uri = file
+		}
+		loc.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(uri)
+		if abs, err := filepath.Abs(uri); err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				loc.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(rel)
+				loc.PhysicalLocation.ArtifactLocation.URIBaseID = sarifSrcRoot
+			}
+		}
+		if issue.Line > 0 {
+			loc.PhysicalLocation.Region = &region{StartLine: issue.Line, StartColumn: issue.Column}
+		}
+		level := "error"
+		if issue.Severity == config.SeverityWarning {
+			level = "warning"
+		}
+		results = append(results, result{
+			RuleID:    issue.Rule,
+			Level:     level,
+			Message:   message{Text: issue.Message},
+			Locations: []location{loc},
+		})
+	}
+
+	log := map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []any{map[string]any{
+			"tool": map[string]any{"driver": map[string]any{
+				"name":           "devx",
+				"version":        version,
+				"informationUri": "https://github.com/dever-labs/devx",
+				"rules":          rules,
+			}},
+			"originalUriBaseIds": map[string]any{
+				sarifSrcRoot: map[string]string{"uri": fileURI(root) + "/"},
+			},
+			"results": results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// writeIssuesGitHub emits GitHub Actions workflow commands, which show up as
+// annotations on the pull request diff.
+func writeIssuesGitHub(w io.Writer, file string, issues []config.Issue) error {
+	for _, issue := range issues {
+		level := "error"
+		if issue.Severity == config.SeverityWarning {
+			level = "warning"
+		}
+		path := issue.File
+		if path == "" {
+			path = file
+		}
+		props := []string{"file=" + ghEscapeProperty(filepath.ToSlash(path))}
+		if issue.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", issue.Line), fmt.Sprintf("col=%d", issue.Column))
+		}
+		props = append(props, "title="+ghEscapeProperty("devx "+issue.Rule))
+		msg := issue.Message
+		if issue.Profile != "" {
+			msg = fmt.Sprintf("[profile:%s] %s", issue.Profile, msg)
+		}
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", level, strings.Join(props, ","), ghEscapeData(msg)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+func ghEscapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func ghEscapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// printOrigins lists every profile definition alongside the file it came
+// from. Only used when devx.yaml pulls in fragments via include.
+func printOrigins(manifest *config.Manifest) {
+	var rows [][]string
+	for _, path := range util.SortedKeys(manifest.Origins) {
+		// Skip top-level keys and whole profiles; list services, deps, tools and steps.
+		isEntry := strings.Count(path, ".") == 3 || strings.HasPrefix(path, "tools.") || strings.HasPrefix(path, "setup.")
+		if !isEntry {
+			continue
+		}
+		rows = append(rows, []string{path, manifest.Origins[path].String()})
+	}
+	fmt.Println("Definitions:")
+	ui.PrintTable(os.Stdout, []string{"Definition", "Source"}, rows)
+	fmt.Println()
+}
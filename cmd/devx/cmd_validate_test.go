@@ -1,9 +1,14 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/dever-labs/devx/internal/config"
 )
 
 // writeManifest writes content to devx.yaml in a temp dir and returns
@@ -192,3 +197,109 @@ func TestSetupStatusIcon(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateFormats(t *testing.T) {
+	path, cleanup := writeManifest(t, `version: 1
+project:
+  name: my-app
+  defaultProfile: local
+profiles:
+  local:
+    services:
+      api:
+        imgae: nginx:alpine
+`)
+	defer cleanup()
+
+	issues, _ := collectIssues(path)
+	sortIssues(issues)
+
+	var buf bytes.Buffer
+	if err := writeIssuesJSON(&buf, path, issues); err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Valid  bool           `json:"valid"`
+		Issues []config.Issue `json:"issues"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if report.Valid || len(report.Issues) == 0 {
+		t.Fatalf("expected invalid report, got %+v", report)
+	}
+	last := report.Issues[len(report.Issues)-1]
+	if last.Rule != "unknown-field" || last.Line != 9 || last.Column != 9 || last.File != path {
+		t.Fatalf("unexpected unknown-field issue: %+v", last)
+	}
+
+	buf.Reset()
+	if err := writeIssuesSARIF(&buf, path, issues); err != nil {
+		t.Fatal(err)
+	}
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI       string `json:"uri"`
+							URIBaseID string `json:"uriBaseId"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &sarif); err != nil {
+		t.Fatalf("invalid SARIF: %v", err)
+	}
+	results := sarif.Runs[0].Results
+	if sarif.Version != "2.1.0" || results[len(results)-1].RuleID != "unknown-field" ||
+		results[len(results)-1].Locations[0].PhysicalLocation.Region.StartLine != 9 {
+		t.Fatalf("unexpected SARIF output:\n%s", buf.String())
+	}
+	// Outside a git checkout locations are relative to the manifest's directory.
+	if loc := results[len(results)-1].Locations[0].PhysicalLocation.ArtifactLocation; loc.URI != "devx.yaml" || loc.URIBaseID != "%SRCROOT%" {
+		t.Fatalf("expected a repository-relative location, got %+v", loc)
+	}
+
+	buf.Reset()
+	if err := writeIssuesGitHub(&buf, path, issues); err != nil {
+		t.Fatal(err)
+	}
+	want := "::error file=" + filepath.ToSlash(path) + ",line=9,col=9,title=devx unknown-field::unknown field 'imgae'"
+	if !strings.Contains(buf.String(), want) {
+		t.Fatalf("expected GitHub annotation starting with %q, got:\n%s", want, buf.String())
+	}
+}
+
+func TestRunValidate_WarningsOnly(t *testing.T) {
+	path, cleanup := writeManifest(t, `version: 2
+project:
+  name: my-app
+  defaultProfile: local
+profiles:
+  local:
+    services:
+      api:
+        image: nginx:alpine
+        health:
+          interval: 5s
+`)
+	defer cleanup()
+
+	if err := runValidate([]string{"--file", path}); err != nil {
+		t.Fatalf("warnings should not fail validation, got: %v", err)
+	}
+
+	issues, _ := collectIssues(path)
+	if len(issues) != 1 || issues[0].Severity != config.SeverityWarning || issues[0].Rule != "unused-field" {
+		t.Fatalf("expected one unused-field warning, got %+v", issues)
+	}
+}
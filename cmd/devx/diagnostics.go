@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dever-labs/devx/internal/compose"
+	"github.com/dever-labs/devx/internal/config"
+	"github.com/dever-labs/devx/internal/diagnostics"
+	"github.com/dever-labs/devx/internal/graph"
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
+	"github.com/dever-labs/devx/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	diagnosticsDir = "diagnostics"
+	// diagnosticsLogLines is how many log lines are kept per failed service.
+	diagnosticsLogLines = 50
+)
+
+// reportFailure prints what is known about each service that kept devx up
+// from finishing and saves it as a bundle under .devx/diagnostics.
+func reportFailure(ctx context.Context, rt devxruntime.Runtime, composePath, projectName, profName string, prof *config.Profile, failed *startupError) {
+	report := collectDiagnostics(ctx, rt, composePath, projectName, profName, prof, failed)
+	fmt.Println()
+	report.WriteText(os.Stdout)
+
+	path, err := report.WriteBundle(filepath.Join(devxDir, diagnosticsDir))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to save diagnostics: %v\n", err)
+		return
+	}
+	fmt.Printf("\nDiagnostics saved to %s\n", path)
+}
+
+func collectDiagnostics(ctx context.Context, rt devxruntime.Runtime, composePath, projectName, profName string, prof *config.Profile, failed *startupError) *diagnostics.Report {
+	report := &diagnostics.Report{
+		Time:    time.Now(),
+		Project: projectName,
+		Profile: profName,
+		Runtime: rt.Name(),
+	}
+	env, envErr := composeEnv(composePath)
+
+	for _, name := range failed.names() {
+		svc := diagnostics.Service{
+			Name:       name,
+			Kind:       failed.graph.Nodes[name].Kind,
+			Blocks:     graph.Dependents(failed.graph, name),
+			Probe:      describeProbe(prof, name),
+			LastResult: failed.failures[name].Error(),
+			Env:        util.MaskEnv(env[name]),
+		}
+		if envErr != nil {
+			svc.Problems = append(svc.Problems, fmt.Sprintf("env: %v", envErr))
+		}
+
+		if inspector, ok := rt.(devxruntime.Inspector); ok {
+			containers, err := inspector.Inspect(ctx, composePath, projectName, name)
+			if err != nil {
+				svc.Problems = append(svc.Problems, fmt.Sprintf("container state: %v", err))
+			}
+			svc.Containers = containers
+		} else {
+			svc.Problems = append(svc.Problems, fmt.Sprintf("container state: %s cannot inspect containers", rt.Name()))
+		}
+
+		logs, err := tailLogs(ctx, rt, composePath, projectName, name)
+		if err != nil {
+			svc.Problems = append(svc.Problems, fmt.Sprintf("logs: %v", err))
+		}
+		svc.Logs = logs
+
+		report.Services = append(report.Services, svc)
+	}
+	return report
+}
+
+// composeEnv returns each service's environment as written to the compose
+// file, which is what its container was started with.
+func composeEnv(composePath string) (map[string]map[string]string, error) {
+	data, err := os.ReadFile(composePath)
+	if err != nil {
+		return nil, err
+	}
+	var file compose.File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	out := map[string]map[string]string{}
+	for name, svc := range file.Services {
+		env := map[string]string{}
+		for k, v := range svc.Environment {
+			env[k] = strings.ReplaceAll(v, "$$", "$")
+		}
+		out[name] = env
+	}
+	return out, nil
+}
+
+// tailLogs returns the last diagnosticsLogLines lines of a service's logs.
+func tailLogs(ctx context.Context, rt devxruntime.Runtime, composePath, projectName, name string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	rc, err := rt.Logs(ctx, composePath, projectName, devxruntime.LogsOptions{Services: []string{name}, Tail: diagnosticsLogLines})
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return string(data), err
+	}
+	return string(data), nil
+}
+
+// describeProbe says how devx checked a service, matching healthCheck.
+func describeProbe(prof *config.Profile, name string) string {
+	svc, ok := prof.Services[name]
+	if !ok || svc.Health == nil || svc.Health.ProbeKind() == "" {
+		return "container state"
+	}
+	h := svc.Health
+	switch h.ProbeKind() {
+	case "http":
+		if url, ok := h.HTTP.HostURL(svc.Ports); ok {
+			return "http " + url
+		}
+	case "tcp":
+		if port, ok := config.ResolvePort(svc.Ports, h.TCP.Port); ok {
+			if addr, ok := port.HostAddress(); ok {
+				return "tcp " + addr
+			}
+		}
+	case "exec":
+		return "container healthcheck (exec " + strings.Join(h.Exec.Command, " ") + ")"
+	}
+	return "container healthcheck (" + h.ProbeKind() + ")"
+}
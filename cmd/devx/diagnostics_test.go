@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dever-labs/devx/internal/config"
+	"github.com/dever-labs/devx/internal/graph"
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
+)
+
+// inspectRuntime serves fixed logs and container state for diagnostics.
+type inspectRuntime struct {
+	waveRuntime
+	logs map[string]string
+}
+
+func (r *inspectRuntime) Name() string { return "docker" }
+
+func (r *inspectRuntime) Logs(_ context.Context, _, _ string, opts devxruntime.LogsOptions) (io.ReadCloser, error) {
+	if opts.Tail != diagnosticsLogLines {
+		return nil, errors.New("expected a tail")
+	}
+	return io.NopCloser(strings.NewReader(r.logs[opts.Services[0]])), nil
+}
+
+func (r *inspectRuntime) Inspect(_ context.Context, _, _, service string) ([]devxruntime.ContainerState, error) {
+	return []devxruntime.ContainerState{{Name: "my-app-" + service + "-1", Status: "exited", ExitCode: 1}}, nil
+}
+
+func TestCollectDiagnostics(t *testing.T) {
+	composePath := filepath.Join(t.TempDir(), "compose.yaml")
+	compose := `services:
+  api:
+    image: api
+    environment:
+      DATABASE_URL: postgres://app:hunter2@db:5432/app
+      GREETING: $$HOME
+`
+	if err := os.WriteFile(composePath, []byte(compose), 0600); err != nil {
+		t.Fatal(err)
+	}
+	prof := &config.Profile{
+		Services: map[string]config.Service{
+			"api": {Image: "api", Ports: []config.Port{{Host: config.PortRange{First: 8080}, Container: config.PortRange{First: 80}}},
+				Health: &config.Health{HTTP: &config.HTTPProbe{Port: "80", Path: "/health"}}},
+			"web": {Image: "web", DependsOn: []string{"api"}},
+		},
+	}
+	g, err := graph.Build(prof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := &startupError{graph: g, failures: map[string]error{"api": errors.New("http://localhost:8080/health returned 503 Service Unavailable")}}
+	rt := &inspectRuntime{logs: map[string]string{"api": "panic: no database\n"}}
+
+	report := collectDiagnostics(context.Background(), rt, composePath, "my-app", "local", prof, failed)
+	if len(report.Services) != 1 {
+		t.Fatalf("expected one service, got %+v", report.Services)
+	}
+	svc := report.Services[0]
+	if svc.Probe != "http http://localhost:8080/health" || !reflect.DeepEqual(svc.Blocks, []string{"web"}) {
+		t.Errorf("unexpected probe or blocks: %+v", svc)
+	}
+	wantEnv := map[string]string{"DATABASE_URL": "postgres://app:****@db:5432/app", "GREETING": "$HOME"}
+	if !reflect.DeepEqual(svc.Env, wantEnv) {
+		t.Errorf("got env %v, want %v", svc.Env, wantEnv)
+	}
+	if svc.Logs != "panic: no database\n" || len(svc.Containers) != 1 || len(svc.Problems) != 0 {
+		t.Errorf("unexpected logs, containers or problems: %+v", svc)
+	}
+}
@@ -3,16 +3,20 @@ package main
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
-	"net/http"
+	"net"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,7 +28,10 @@ import (
 	"github.com/dever-labs/devx/internal/providers"
 	devxruntime "github.com/dever-labs/devx/internal/runtime"
 	"github.com/dever-labs/devx/internal/runtime/docker"
+	"github.com/dever-labs/devx/internal/runtime/engine"
+	"github.com/dever-labs/devx/internal/runtime/nerdctl"
 	"github.com/dever-labs/devx/internal/runtime/podman"
+	"github.com/dever-labs/devx/internal/secrets"
 )
 
 // loadManifestOnly loads and parses devx.yaml without resolving a profile.
@@ -34,7 +41,7 @@ func loadManifestOnly() (*config.Manifest, error) {
 }
 
 func loadProfile(profile string) (*config.Manifest, string, *config.Profile, error) {
-	manifest, err := config.Load(manifestFile)
+	manifest, err := config.LoadWithOptions(manifestFile, config.LoadOptions{Profile: profile})
 	if err != nil {
 		return nil, "", nil, err
 	}
@@ -63,8 +70,92 @@ func loadProfile(profile string) (*config.Manifest, string, *config.Profile, err
 	return manifest, profName, prof, nil
 }
 
-func writeCompose(path string, manifest *config.Manifest, profName string, prof *config.Profile, lockfile *lock.Lockfile, enableTelemetry bool) error {
-	composed, err := buildCompose(manifest, profName, prof, lockfile, enableTelemetry)
+// targetFlags pick the environment a command acts on. Every command that
+// works on a running environment takes them.
+type targetFlags struct {
+	profile  *string
+	force    *bool
+	instance *string
+	runtime  *string
+}
+
+func addTargetFlags(fs *flag.FlagSet) *targetFlags {
+	return &targetFlags{
+		profile:  fs.String("profile", "", "Profile to use (defaults to the one last brought up)"),
+		force:    fs.Bool("force", false, "Use the compose file from the last up even if devx.yaml changed since"),
+		instance: fs.String("instance", "", "Named instance to act on"),
+		runtime:  fs.String("runtime", "", "Container runtime: docker, podman, nerdctl or docker-engine (default: detected)"),
+	}
+}
+
+// loadActiveProfile loads the profile a command acts on in the instance:
+// override when given, otherwise the one `devx up` last started, otherwise
+// the manifest's defaultProfile. Host ports are those the instance was
+// started with.
+func loadActiveProfile(in instance, override string) (*config.Manifest, string, *config.Profile, error) {
+	st := readState(in)
+	profile := override
+	if profile == "" && st != nil {
+		profile = st.Profile
+	}
+	manifest, profName, prof, err := loadProfile(profile)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if st != nil && st.Profile == profName {
+		applyPorts(prof, st.Ports)
+	}
+	return manifest, profName, prof, nil
+}
+
+// manifestHash fingerprints everything in the manifest that shapes the
+// running environment for profName.
+func manifestHash(manifest *config.Manifest, profName string, prof *config.Profile) string {
+	data, err := json.Marshal(struct {
+		Project config.Project
+		Profile string
+		Config  *config.Profile
+		Secrets map[string]config.Secret
+	}{manifest.Project, profName, prof, manifest.Secrets})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// manifestChanged reports whether devx.yaml has changed for profName since
+// `devx up` recorded its hash in the instance's state.
+func manifestChanged(in instance, manifest *config.Manifest, profName string, prof *config.Profile) bool {
+	st := readState(in)
+	if st == nil || st.Profile != profName || st.ManifestHash == "" {
+		return false
+	}
+	return st.ManifestHash != manifestHash(manifest, profName, prof)
+}
+
+// prepareCompose writes the instance's compose file from devx.yaml, with
+// images pinned by devx.lock as `devx up` does, and returns its path. When
+// devx.yaml has changed since `devx up` a warning is printed; force keeps
+// the compose file `devx up` wrote instead, which matches the containers
+// that are running.
+func prepareCompose(in instance, manifest *config.Manifest, profName string, prof *config.Profile, force bool) (string, error) {
+	if err := os.MkdirAll(in.dir, 0755); err != nil {
+		return "", err
+	}
+	composePath := in.composePath()
+	if manifestChanged(in, manifest, profName, prof) {
+		if force && fileExists(composePath) {
+			return composePath, nil
+		}
+		fmt.Fprintf(os.Stderr, "warning: devx.yaml has changed since 'devx up' for profile '%s'; regenerating the compose file from it. Run 'devx up' to apply the changes, or pass --force to use the compose file from then.\n", profName)
+	}
+	lockfile, _ := lock.Load(lockFile)
+	return composePath, writeCompose(composePath, manifest, in.name, profName, prof, lockfile, telemetryFromState(in))
+}
+
+func writeCompose(path string, manifest *config.Manifest, instanceName, profName string, prof *config.Profile, lockfile *lock.Lockfile, enableTelemetry bool) error {
+	composed, err := buildCompose(manifest, instanceName, profName, prof, lockfile, enableTelemetry)
 	if err != nil {
 		return err
 	}
@@ -91,7 +182,7 @@ func writeCompose(path string, manifest *config.Manifest, profName string, prof
 	return nil
 }
 
-func buildCompose(manifest *config.Manifest, profName string, prof *config.Profile, lockfile *lock.Lockfile, enableTelemetry bool) (string, error) {
+func buildCompose(manifest *config.Manifest, instanceName, profName string, prof *config.Profile, lockfile *lock.Lockfile, enableTelemetry bool) (string, error) {
 	prof = resolveDepImages(prof)
 	prof = resolveConnections(manifest, prof)
 
@@ -109,11 +200,31 @@ func buildCompose(manifest *config.Manifest, profName string, prof *config.Profi
 		RegistryPrefix: manifest.Registry.Prefix,
 		Lockfile:       lockfile,
 		DepFragments:   depFragments,
+		Instance:       instanceName,
 	}
 
 	return compose.Render(manifest, profName, prof, rewrite, enableTelemetry)
 }
 
+// resolveSecrets looks up the value of every secret the profile references.
+func resolveSecrets(ctx context.Context, manifest *config.Manifest, prof *config.Profile) (map[string]string, error) {
+	return secrets.NewResolver(filepath.Dir(manifestFile)).ResolveProfile(ctx, manifest, prof)
+}
+
+// writeSecretFiles resolves the profile's secrets into the instance's
+// secrets directory, where the rendered compose file mounts them from.
+func writeSecretFiles(ctx context.Context, in instance, manifest *config.Manifest, prof *config.Profile) error {
+	values, err := resolveSecrets(ctx, manifest, prof)
+	if err != nil {
+		return err
+	}
+	dir := in.secretsPath()
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return secrets.WriteFiles(dir, values)
+}
+
 func profileRuntime(prof *config.Profile) string {
 	if prof == nil || prof.Runtime == "" {
 		return "compose"
@@ -157,19 +268,19 @@ func fileExists(path string) bool {
 	return err == nil
 }
 
-func writeState(s state) error {
-	if err := ensureDevxDir(); err != nil {
+func writeState(in instance, s state) error {
+	if err := os.MkdirAll(in.dir, 0755); err != nil {
 		return err
 	}
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filepath.Join(devxDir, stateFile), data, 0600)
+	return os.WriteFile(in.statePath(), data, 0600)
 }
 
-func readState() *state {
-	data, err := os.ReadFile(filepath.Join(devxDir, stateFile))
+func readState(in instance) *state {
+	data, err := os.ReadFile(in.statePath())
 	if err != nil {
 		return nil
 	}
@@ -180,95 +291,16 @@ func readState() *state {
 	return &s
 }
 
-func telemetryFromState() bool {
-	st := readState()
+func telemetryFromState(in instance) bool {
+	st := readState(in)
 	if st == nil {
 		return true
 	}
 	return st.Telemetry
 }
 
-func streamLogs(reader io.Reader, jsonOut bool) error {
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if !jsonOut {
-			fmt.Println(line)
-			continue
-		}
-		entry := map[string]string{"line": line}
-		data, err := json.Marshal(entry)
-		if err != nil {
-			return err
-		}
-		fmt.Println(string(data))
-	}
-	return scanner.Err()
-}
-
-func waitForHealth(profile *config.Profile) error {
-	if profile == nil {
-		return nil
-	}
-
-	type check struct {
-		name string
-		url  string
-	}
-
-	var checks []check
-	for name, svc := range profile.Services {
-		if svc.Health == nil || svc.Health.HttpGet == "" {
-			continue
-		}
-		checks = append(checks, check{name: name, url: svc.Health.HttpGet})
-	}
-
-	if len(checks) == 0 {
-		return nil
-	}
-
-	deadline := time.Now().Add(2 * time.Minute)
-	pending := map[string]string{}
-	for _, c := range checks {
-		pending[c.name] = c.url
-	}
-
-	for len(pending) > 0 && time.Now().Before(deadline) {
-		for name, url := range pending {
-			if checkHTTP(url) {
-				delete(pending, name)
-			}
-		}
-		if len(pending) == 0 {
-			break
-		}
-		time.Sleep(2 * time.Second)
-	}
-
-	if len(pending) > 0 {
-		var parts []string
-		for name, url := range pending {
-			parts = append(parts, fmt.Sprintf("%s (%s)", name, url))
-		}
-		return fmt.Errorf("health checks failed: %s", strings.Join(parts, ", "))
-	}
-
-	return nil
-}
-
-func checkHTTP(url string) bool {
-	client := http.Client{Timeout: 2 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return false
-	}
-	defer resp.Body.Close()
-	return resp.StatusCode >= 200 && resp.StatusCode < 300
-}
-
 func collectImages(manifest *config.Manifest, profileName string, prof *config.Profile) ([]string, error) {
-	composed, err := buildCompose(manifest, profileName, prof, nil, true)
+	composed, err := buildCompose(manifest, "", profileName, prof, nil, true)
 	if err != nil {
 		return nil, err
 	}
@@ -281,22 +313,110 @@ func collectImages(manifest *config.Manifest, profileName string, prof *config.P
 	return imgs, nil
 }
 
-func selectRuntime(ctx context.Context) (devxruntime.Runtime, error) {
-	d := docker.New()
-	if ok, _ := d.Detect(ctx); ok {
-		return d, nil
+// runtimeEnv names the environment variable that picks the container
+// runtime, like --runtime.
+const runtimeEnv = "DEVX_RUNTIME"
+
+// newRuntimeFactory returns the container runtimes devx supports, in the
+// order they are detected when none is chosen.
+func newRuntimeFactory() *devxruntime.Factory {
+	f := devxruntime.NewFactory()
+	f.Register("docker", func(cfg devxruntime.Config) devxruntime.Runtime {
+		r := docker.New()
+		if cfg.Binary != "" {
+			r.Binary = cfg.Binary
+		}
+		r.Context, r.Host = cfg.Context, cfg.Host
+		return r
+	})
+	f.Register("podman", func(cfg devxruntime.Config) devxruntime.Runtime {
+		r := podman.New()
+		if cfg.Binary != "" {
+			r.Binary = cfg.Binary
+		}
+		return r
+	})
+	f.Register("nerdctl", func(cfg devxruntime.Config) devxruntime.Runtime {
+		r := nerdctl.New()
+		if cfg.Binary != "" {
+			r.Binary = cfg.Binary
+		}
+		return r
+	})
+	// Without either CLI, talk to the daemon's API directly, e.g. over a
+	// socket mounted into a CI container.
+	f.Register("docker-engine", func(cfg devxruntime.Config) devxruntime.Runtime {
+		r := engine.New()
+		if cfg.Host != "" {
+			r.Host = cfg.Host
+		}
+		return r
+	})
+	return f
+}
+
+// runtimeChoice decides which container runtime to use and why: --runtime
+// (flagValue), then DEVX_RUNTIME, then the profile's containerRuntime, then
+// the runtime `devx up` recorded; with none of them, the first one
+// detected. The profile's binary, context and host apply whenever its
+// runtime is the one chosen.
+func runtimeChoice(flagValue string, prof *config.Profile, recorded string) (devxruntime.Config, string) {
+	var configured config.ContainerRuntime
+	if prof != nil {
+		configured = prof.ContainerRuntime
+	}
+	var cfg devxruntime.Config
+	var reason string
+	switch {
+	case flagValue != "":
+		cfg.Name, reason = flagValue, "--runtime"
+	case os.Getenv(runtimeEnv) != "":
+		cfg.Name, reason = os.Getenv(runtimeEnv), runtimeEnv
+	case configured.Name != "":
+		cfg.Name, reason = configured.Name, "containerRuntime in devx.yaml"
+	case recorded != "":
+		cfg.Name, reason = recorded, "the runtime devx up used"
+	}
+	if cfg.Name != "" && cfg.Name == configured.Name {
+		cfg.Binary, cfg.Context, cfg.Host = configured.Binary, configured.Context, configured.Host
+	}
+	return cfg, reason
+}
+
+// recordedRuntime returns the container runtime `devx up` used for the
+// instance, if it is one devx can select.
+func recordedRuntime(f *devxruntime.Factory, in instance) string {
+	st := readState(in)
+	if st == nil {
+		return ""
 	}
-	p := podman.New()
-	if ok, _ := p.Detect(ctx); ok {
-		return p, nil
+	for _, name := range f.Names() {
+		if name == st.Runtime {
+			return name
+		}
 	}
-	return nil, devxruntime.ErrNoRuntime
+	return ""
+}
+
+// selectRuntime returns the container runtime for the instance, chosen as
+// runtimeChoice describes.
+func selectRuntime(ctx context.Context, flagValue string, prof *config.Profile, in instance) (devxruntime.Runtime, error) {
+	f := newRuntimeFactory()
+	cfg, reason := runtimeChoice(flagValue, prof, recordedRuntime(f, in))
+	sel, err := f.Select(ctx, cfg, reason)
+	if err != nil {
+		return nil, err
+	}
+	return sel.Runtime, nil
 }
 
 // printLinks queries the running stack for actual host-port bindings and prints
 // http://localhost:<port> for every published port. Using the runtime (not the
-// compose YAML) ensures randomly-assigned ports are reflected correctly.
-func printLinks(ctx context.Context, rt devxruntime.Runtime, composePath, projectName string) {
+// compose YAML) ensures randomly-assigned ports are reflected correctly. The
+// profile's port declarations name the links and leave out non-TCP ports.
+// Links on a port in moved, which maps a chosen host port to the one in
+// devx.yaml it replaced, say so.
+func printLinks(ctx context.Context, rt devxruntime.Runtime, composePath, projectName string, prof *config.Profile, moved map[int]string) {
 	statuses, err := rt.Status(ctx, composePath, projectName)
 	if err != nil {
 		return
@@ -305,23 +425,41 @@ func printLinks(ctx context.Context, rt devxruntime.Runtime, composePath, projec
 	type link struct {
 		label string
 		url   string
+		note  string
 	}
 	var links []link
 	seen := map[string]bool{}
 
 	for _, svc := range statuses {
-		label := serviceLabel(svc.Name)
+		declared := declaredPorts(prof, svc.Name)
 		for _, pub := range svc.Publishers {
-			if pub.PublishedPort == 0 {
+			if pub.PublishedPort == 0 || (pub.Protocol != "" && pub.Protocol != "tcp") {
 				continue
 			}
-			url := fmt.Sprintf("http://localhost:%d", pub.PublishedPort)
+			label := serviceLabel(svc.Name)
+			if port, ok := matchPort(declared, pub.TargetPort); ok {
+				if port.Proto() != "tcp" {
+					continue
+				}
+				if port.Name != "" {
+					label += " (" + port.Name + ")"
+				}
+			}
+			host := "localhost"
+			if !config.IsWildcardIP(pub.URL) {
+				host = pub.URL
+			}
+			url := fmt.Sprintf("http://%s", net.JoinHostPort(host, strconv.Itoa(pub.PublishedPort)))
 			key := label + url
 			if seen[key] {
 				continue
 			}
 			seen[key] = true
-			links = append(links, link{label: label, url: url})
+			l := link{label: label, url: url}
+			if from, ok := moved[pub.PublishedPort]; ok {
+				l.note = fmt.Sprintf("  (%s was in use)", from)
+			}
+			links = append(links, l)
 		}
 	}
 
@@ -339,8 +477,31 @@ func printLinks(ctx context.Context, rt devxruntime.Runtime, composePath, projec
 		}
 	}
 	for _, l := range links {
-		fmt.Printf("  %-*s  %s\n", maxLen, l.label, l.url)
+		fmt.Printf("  %-*s  %s%s\n", maxLen, l.label, l.url, l.note)
+	}
+}
+
+// declaredPorts returns the ports the profile declares for a service or dep.
+func declaredPorts(prof *config.Profile, name string) []config.Port {
+	if prof == nil {
+		return nil
 	}
+	if svc, ok := prof.Services[name]; ok {
+		return svc.Ports
+	}
+	return prof.Deps[name].Ports
+}
+
+// matchPort finds the declared port whose container side includes target.
+func matchPort(ports []config.Port, target int) (config.Port, bool) {
+	for _, p := range ports {
+		for _, n := range p.Container.Ports() {
+			if n == target {
+				return p, true
+			}
+		}
+	}
+	return config.Port{}, false
 }
 
 // wellKnownLabels maps telemetry service name suffixes to display labels.
@@ -380,7 +541,11 @@ func runHooks(ctx context.Context, rt devxruntime.Runtime, composePath, projectN
 		if h.Exec != "" {
 			fmt.Printf("  [hook %d] exec in %s: %s\n", i+1, h.Service, h.Exec)
 			cmd := strings.Fields(h.Exec)
-			code, err := rt.Exec(ctx, composePath, projectName, h.Service, cmd)
+			code, err := rt.Exec(ctx, composePath, projectName, h.Service, devxruntime.ExecOptions{
+				Cmd:    cmd,
+				Stdout: os.Stdout,
+				Stderr: os.Stderr,
+			})
 			if err != nil {
 				return bgCmds, fmt.Errorf("hook %d exec failed: %w", i+1, err)
 			}
@@ -411,14 +576,17 @@ func runHooks(ctx context.Context, rt devxruntime.Runtime, composePath, projectN
 	return bgCmds, nil
 }
 
-// runShellCommand runs a command string via the system shell with stdout/stderr inherited.
-func runShellCommand(command string) error {
-	var cmd *exec.Cmd
+// shellCommand returns a command running a command string via the system shell.
+func shellCommand(command string) *exec.Cmd {
 	if runtime.GOOS == "windows" {
-		cmd = exec.Command("cmd", "/c", command)
-	} else {
-		cmd = exec.Command("sh", "-c", command)
+		return exec.Command("cmd", "/c", command)
 	}
+	return exec.Command("sh", "-c", command)
+}
+
+// runShellCommand runs a command string via the system shell with stdout/stderr inherited.
+func runShellCommand(command string) error {
+	cmd := shellCommand(command)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -427,12 +595,7 @@ func runShellCommand(command string) error {
 // runShellCommandBackground starts a command via the system shell without waiting for it
 // to exit. stdout and stderr are streamed to the terminal with a label prefix.
 func runShellCommandBackground(command, label string) (*exec.Cmd, error) {
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		cmd = exec.Command("cmd", "/c", command)
-	} else {
-		cmd = exec.Command("sh", "-c", command)
-	}
+	cmd := shellCommand(command)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -569,7 +732,7 @@ func buildDepFragments(prof *config.Profile) map[string]*compose.DepFragment {
 		input := providers.DepInput{
 			Name:   name,
 			Image:  dep.Image,
-			Ports:  dep.Ports,
+			Ports:  config.PortSpecs(dep.Ports),
 			Env:    dep.Env,
 			Volume: dep.Volume,
 		}
@@ -745,3 +908,47 @@ func depProviderSource(dep config.Dep) string {
 	}
 	return "devx-labs/" + dep.Kind
 }
+
+// stringList is a flag that can be given several times.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// parseArgs parses fs's flags wherever they appear among args, so
+// `devx up api --build` works as well as `devx up --build api`, and returns
+// the positional arguments. Everything after "--" is positional.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		_ = fs.Parse(args)
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional
+		}
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...)
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// checkServiceNames reports the first name that is neither a service nor a
+// dep of prof.
+func checkServiceNames(prof *config.Profile, names []string) error {
+	for _, name := range names {
+		_, isService := prof.Services[name]
+		_, isDep := prof.Deps[name]
+		if !isService && !isDep {
+			return fmt.Errorf("unknown service '%s'", name)
+		}
+	}
+	return nil
+}
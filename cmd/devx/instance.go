@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/dever-labs/devx/internal/config"
+)
+
+const instancesDir = "instances"
+
+// instanceNamePattern matches names that are valid in compose project names.
+var instanceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// instance is one environment of the project. The default instance keeps
+// its files directly in .devx; a named instance keeps them in
+// .devx/instances/<name> and runs as its own compose project, so its
+// containers, networks and volumes are separate from every other one.
+type instance struct {
+	// name is empty for the default instance.
+	name string
+	dir  string
+}
+
+func newInstance(name string) (instance, error) {
+	if name == "" {
+		return instance{dir: devxDir}, nil
+	}
+	if !instanceNamePattern.MatchString(name) {
+		return instance{}, fmt.Errorf("invalid instance name '%s': use lowercase letters, digits, '-' and '_'", name)
+	}
+	return instance{name: name, dir: filepath.Join(devxDir, instancesDir, name)}, nil
+}
+
+func (in instance) composePath() string {
+	return filepath.Join(in.dir, composeFile)
+}
+
+func (in instance) statePath() string {
+	return filepath.Join(in.dir, stateFile)
+}
+
+func (in instance) secretsPath() string {
+	return filepath.Join(in.dir, secretsDir)
+}
+
+// projectName returns the compose project the instance runs as.
+func (in instance) projectName(manifest *config.Manifest) string {
+	if in.name == "" {
+		return manifest.Project.Name
+	}
+	return manifest.Project.Name + "-" + in.name
+}
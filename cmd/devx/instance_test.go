@@ -0,0 +1,60 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dever-labs/devx/internal/config"
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
+)
+
+func TestNewInstance(t *testing.T) {
+	def, err := newInstance("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &config.Manifest{Project: config.Project{Name: "my-app"}}
+	if def.composePath() != filepath.Join(devxDir, composeFile) || def.projectName(m) != "my-app" {
+		t.Fatalf("unexpected default instance: %+v", def)
+	}
+
+	feature, err := newInstance("feature-x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feature.composePath() != filepath.Join(devxDir, instancesDir, "feature-x", composeFile) {
+		t.Fatalf("unexpected compose path %s", feature.composePath())
+	}
+	if feature.projectName(m) != "my-app-feature-x" {
+		t.Fatalf("unexpected project name %s", feature.projectName(m))
+	}
+
+	if _, err := newInstance("Feature/X"); err == nil || !strings.Contains(err.Error(), "invalid instance name") {
+		t.Fatalf("expected an invalid name error, got %v", err)
+	}
+}
+
+func TestSummarizeInstances(t *testing.T) {
+	labels := func(instance, profile string) map[string]string {
+		l := map[string]string{"devx.project": "my-app", "devx.profile": profile, "com.docker.compose.project.working_dir": "/src/my-app/.devx"}
+		if instance != "" {
+			l["devx.instance"] = instance
+			l["com.docker.compose.project.working_dir"] = "/src/my-app/.devx/instances/" + instance
+		}
+		return l
+	}
+	got := summarizeInstances([]devxruntime.Container{
+		{Name: "my-app-feature-api-1", State: "running", Labels: labels("feature", "ci")},
+		{Name: "my-app-api-1", State: "running", Labels: labels("", "local")},
+		{Name: "my-app-db-1", State: "exited", Labels: labels("", "local")},
+	})
+	want := []instanceSummary{
+		{Project: "my-app", Instance: "default", Profile: "local", Running: 1, Total: 2, Dir: "/src/my-app"},
+		{Project: "my-app", Instance: "feature", Profile: "ci", Running: 1, Total: 1, Dir: "/src/my-app"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
+)
+
+// logLevels are the levels --level accepts, lowest first.
+var logLevels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+// levelAliases maps the other names applications give levels to one of
+// logLevels.
+var levelAliases = map[string]string{
+	"trc": "trace", "dbg": "debug", "information": "info", "notice": "info",
+	"warning": "warn", "wrn": "warn", "err": "error", "eror": "error",
+	"crit": "fatal", "critical": "fatal", "panic": "fatal", "emerg": "fatal", "alert": "fatal",
+}
+
+// numericLevels maps bunyan and pino's numeric levels.
+var numericLevels = map[float64]string{10: "trace", 20: "debug", 30: "info", 40: "warn", 50: "error", 60: "fatal"}
+
+// Keys JSON application logs keep their message, level and time under.
+var (
+	messageKeys = []string{"msg", "message", "@message"}
+	levelKeys   = []string{"level", "lvl", "severity", "@level", "log.level"}
+	timeKeys    = []string{"time", "ts", "timestamp", "@timestamp"}
+)
+
+// textLevelPatterns find the level in plain-text log lines: logfmt's
+// level=, an upper-case level word, or a lower-case one in brackets.
+var textLevelPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?:^|\s)level="?([A-Za-z]+)`),
+	regexp.MustCompile(`(?:^|[\s\[])(TRACE|DEBUG|INFO|NOTICE|WARN|WARNING|ERROR|FATAL|PANIC|CRITICAL)(?:[\]:\s]|$)`),
+	regexp.MustCompile(`\[(trace|debug|info|notice|warn|warning|error|fatal|crit|emerg|alert)\]`),
+}
+
+// serviceColors are the ANSI colours service prefixes are drawn in, the
+// same ones compose uses.
+var serviceColors = []string{"36", "33", "32", "35", "34", "96", "93", "92", "95", "94"}
+
+// logRecord is one log line as devx logs shows it; --json prints one per
+// line.
+type logRecord struct {
+	Time      string `json:"time,omitempty"`
+	Service   string `json:"service,omitempty"`
+	Container string `json:"container,omitempty"`
+	Stream    string `json:"stream,omitempty"`
+	Level     string `json:"level,omitempty"`
+	Message   string `json:"message"`
+	// Fields are the other fields of a JSON application log line.
+	Fields map[string]any `json:"fields,omitempty"`
+
+	time time.Time
+	// structured is set when the line was a JSON object.
+	structured bool
+}
+
+// newLogRecord fills in a record from a log entry, taking the message,
+// level and fields from the line when it is a JSON object and looking for
+// a level in it otherwise.
+func newLogRecord(e devxruntime.LogEntry) logRecord {
+	rec := logRecord{Service: e.Service, Container: e.Container, Stream: e.Stream, Message: e.Message, time: e.Time}
+	if !e.Time.IsZero() {
+		rec.Time = e.Time.UTC().Format(time.RFC3339Nano)
+	}
+
+	trimmed := strings.TrimSpace(e.Message)
+	var fields map[string]any
+	if strings.HasPrefix(trimmed, "{") && json.Unmarshal([]byte(trimmed), &fields) == nil {
+		rec.structured = true
+		if msg, ok := takeField(fields, messageKeys).(string); ok {
+			rec.Message = msg
+		} else {
+			rec.Message = ""
+		}
+		rec.Level = normalizeLevel(takeField(fields, levelKeys))
+		takeField(fields, timeKeys)
+		if len(fields) > 0 {
+			rec.Fields = fields
+		}
+		return rec
+	}
+
+	head := e.Message
+	if len(head) > 80 {
+		head = head[:80]
+	}
+	for _, re := range textLevelPatterns {
+		if m := re.FindStringSubmatch(head); m != nil {
+			if level := normalizeLevel(m[1]); level != "" {
+				rec.Level = level
+				break
+			}
+		}
+	}
+	return rec
+}
+
+// takeField removes the first of keys present in fields and returns its
+// value.
+func takeField(fields map[string]any, keys []string) any {
+	for _, k := range keys {
+		if v, ok := fields[k]; ok {
+			delete(fields, k)
+			return v
+		}
+	}
+	return nil
+}
+
+// normalizeLevel returns one of logLevels for a level name or number, or
+// "" when it is not one.
+func normalizeLevel(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return numericLevels[v]
+	case string:
+		level := strings.ToLower(v)
+		if alias, ok := levelAliases[level]; ok {
+			return alias
+		}
+		if levelRank(level) >= 0 {
+			return level
+		}
+	}
+	return ""
+}
+
+// levelRank is level's position in logLevels, or -1.
+func levelRank(level string) int {
+	for i, l := range logLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+type logViewOptions struct {
+	JSON  bool
+	Color bool
+	// Grep keeps only lines whose message matches.
+	Grep *regexp.Regexp
+	// Level keeps only lines at this level or above; lines without a
+	// recognisable level count as info.
+	Level string
+	// Width pads service names to line up the lines of different services.
+	Width int
+}
+
+// logView prints log lines from any number of streams, parsed, filtered
+// and formatted.
+type logView struct {
+	w           io.Writer
+	opts        logViewOptions
+	projectName string
+	minRank     int
+	mu          sync.Mutex
+}
+
+func newLogView(w io.Writer, projectName string, opts logViewOptions) (*logView, error) {
+	v := &logView{w: w, opts: opts, projectName: projectName, minRank: -1}
+	if opts.Level != "" {
+		v.minRank = levelRank(normalizeLevel(opts.Level))
+		if v.minRank < 0 {
+			return nil, fmt.Errorf("invalid --level %q: use %s", opts.Level, strings.Join(logLevels, ", "))
+		}
+	}
+	return v, nil
+}
+
+// scan prints each line of r, the logs' stream.
+func (v *logView) scan(r io.Reader, stream string) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		e := devxruntime.ParseLogLine(scanner.Text(), v.projectName)
+		e.Stream = stream
+		if err := v.print(e); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// print writes e unless the filters drop it.
+func (v *logView) print(e devxruntime.LogEntry) error {
+	if v.opts.Grep != nil && !v.opts.Grep.MatchString(e.Message) {
+		return nil
+	}
+	rec := newLogRecord(e)
+	if v.minRank >= 0 {
+		level := rec.Level
+		if level == "" {
+			level = "info"
+		}
+		if levelRank(level) < v.minRank {
+			return nil
+		}
+	}
+
+	var line string
+	if v.opts.JSON {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		line = string(data)
+	} else {
+		line = v.format(rec)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	_, err := fmt.Fprintln(v.w, line)
+	return err
+}
+
+// format renders a record as text: "api  | 15:04:05.000 message", with a
+// JSON application line shown as its level, message and key=value fields.
+func (v *logView) format(rec logRecord) string {
+	var b strings.Builder
+	if label := rec.Service; label != "" || rec.Container != "" {
+		if label == "" {
+			label = rec.Container
+		}
+		b.WriteString(v.paint(serviceColor(label), fmt.Sprintf("%-*s |", v.opts.Width, label)))
+		b.WriteByte(' ')
+	}
+	if !rec.time.IsZero() {
+		b.WriteString(rec.time.Local().Format("15:04:05.000"))
+		b.WriteByte(' ')
+	}
+	if !rec.structured {
+		b.WriteString(v.paint(levelColor(rec.Level), rec.Message))
+		return b.String()
+	}
+
+	if rec.Level != "" {
+		b.WriteString(v.paint(levelColor(rec.Level), fmt.Sprintf("%-5s", strings.ToUpper(rec.Level))))
+		b.WriteByte(' ')
+	}
+	b.WriteString(rec.Message)
+	keys := make([]string, 0, len(rec.Fields))
+	for k := range rec.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteByte(' ')
+		b.WriteString(v.paint("2", k+"="))
+		b.WriteString(fieldValue(rec.Fields[k]))
+	}
+	return b.String()
+}
+
+// paint wraps s in an ANSI colour when colour is on.
+func (v *logView) paint(color, s string) string {
+	if !v.opts.Color || color == "" {
+		return s
+	}
+	return "\x1b[" + color + "m" + s + "\x1b[0m"
+}
+
+// serviceColor picks a service's colour from its name, so it keeps the
+// same one from run to run.
+func serviceColor(service string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(service))
+	return serviceColors[h.Sum32()%uint32(len(serviceColors))]
+}
+
+func levelColor(level string) string {
+	switch level {
+	case "error", "fatal":
+		return "31"
+	case "warn":
+		return "33"
+	case "debug", "trace":
+		return "2"
+	}
+	return ""
+}
+
+// fieldValue formats a JSON field's value: strings bare unless they need
+// quoting, anything else as JSON.
+func fieldValue(value any) string {
+	if s, ok := value.(string); ok {
+		if s != "" && !strings.ContainsAny(s, " \t\n\"=") {
+			return s
+		}
+		return fmt.Sprintf("%q", s)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
+)
+
+func TestNewLogRecord(t *testing.T) {
+	for _, tc := range []struct {
+		message string
+		level   string
+		text    string
+		fields  map[string]any
+	}{
+		{`{"level":"warn","msg":"slow query","ms":812,"time":"2024-01-02T03:04:05Z"}`, "warn", "slow query", map[string]any{"ms": float64(812)}},
+		{`{"level":50,"msg":"boom","pid":7}`, "error", "boom", map[string]any{"pid": float64(7)}},
+		{`{"severity":"CRITICAL","message":"disk full"}`, "fatal", "disk full", nil},
+		{`{"event":"no message"}`, "", "", map[string]any{"event": "no message"}},
+		{`time=2024-01-02T03:04:05Z level=debug msg="cache miss"`, "debug", `time=2024-01-02T03:04:05Z level=debug msg="cache miss"`, nil},
+		{`2024-01-02 03:04:05.123 UTC [1] ERROR:  relation "users" does not exist`, "error", `2024-01-02 03:04:05.123 UTC [1] ERROR:  relation "users" does not exist`, nil},
+		{`2024/01/02 03:04:05 [warn] 29#29: upstream timed out`, "warn", `2024/01/02 03:04:05 [warn] 29#29: upstream timed out`, nil},
+		{`request finished with no error`, "", `request finished with no error`, nil},
+	} {
+		rec := newLogRecord(devxruntime.LogEntry{Service: "api", Message: tc.message})
+		if rec.Level != tc.level || rec.Message != tc.text || !reflect.DeepEqual(rec.Fields, tc.fields) {
+			t.Errorf("%s: got level %q, message %q, fields %v", tc.message, rec.Level, rec.Message, rec.Fields)
+		}
+	}
+}
+
+func TestLogView(t *testing.T) {
+	stamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	lines := []devxruntime.LogEntry{
+		{Time: stamp, Service: "api", Container: "api-1", Stream: "stdout", Message: `{"level":"info","msg":"listening","addr":":8080"}`},
+		{Time: stamp, Service: "db", Container: "db-1", Stream: "stderr", Message: "ERROR:  relation does not exist"},
+		{Time: stamp, Service: "db", Container: "db-1", Stream: "stdout", Message: "checkpoint complete"},
+		{Message: "no such service: web"},
+	}
+	render := func(opts logViewOptions) string {
+		t.Helper()
+		var out bytes.Buffer
+		v, err := newLogView(&out, "demo", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range lines {
+			if err := v.print(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return out.String()
+	}
+
+	clock := stamp.Local().Format("15:04:05.000")
+	want := "api | " + clock + " INFO  listening addr=:8080\n" +
+		"db  | " + clock + " ERROR:  relation does not exist\n" +
+		"db  | " + clock + " checkpoint complete\n" +
+		"no such service: web\n"
+	if got := render(logViewOptions{Width: 3}); got != want {
+		t.Errorf("text:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := render(logViewOptions{Level: "warning"}); strings.Count(got, "\n") != 1 || !strings.Contains(got, "relation does not exist") {
+		t.Errorf("--level warning kept:\n%s", got)
+	}
+	if got := render(logViewOptions{Grep: regexp.MustCompile(`check|listen`)}); strings.Count(got, "\n") != 2 {
+		t.Errorf("--grep kept:\n%s", got)
+	}
+	if got := render(logViewOptions{Color: true}); !strings.Contains(got, "\x1b[31mERROR:") {
+		t.Errorf("expected the error line in red, got %q", got)
+	}
+	if _, err := newLogView(&bytes.Buffer{}, "demo", logViewOptions{Level: "loud"}); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+
+	var first logRecord
+	if err := json.Unmarshal([]byte(strings.SplitN(render(logViewOptions{JSON: true}), "\n", 2)[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Time != "2024-01-02T03:04:05Z" || first.Service != "api" || first.Container != "api-1" || first.Stream != "stdout" ||
+		first.Level != "info" || first.Message != "listening" || first.Fields["addr"] != ":8080" {
+		t.Errorf("unexpected JSON record: %+v", first)
+	}
+}
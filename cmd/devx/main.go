@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 )
@@ -16,12 +17,19 @@ const (
 	k8sFile      = "k8s.yaml"
 	stateFile    = "state.json"
 	lockFile     = "devx.lock"
+	secretsDir   = "secrets"
 )
 
 type state struct {
 	Profile   string `json:"profile"`
 	Runtime   string `json:"runtime"`
 	Telemetry bool   `json:"telemetry"`
+	// ManifestHash identifies the profile as it was when `devx up` ran, so
+	// later commands can tell that devx.yaml has changed since.
+	ManifestHash string `json:"manifestHash,omitempty"`
+	// Ports maps each published port, as "<service>:<host port in
+	// devx.yaml>", to the host port `devx up` chose for it.
+	Ports map[string]int `json:"ports,omitempty"`
 }
 
 func main() {
@@ -42,12 +50,26 @@ func main() {
 		err = runUp(ctx, args)
 	case "down":
 		err = runDown(ctx, args)
+	case "restart":
+		err = runRestart(ctx, args)
+	case "stop":
+		err = runStop(ctx, args)
 	case "status":
 		err = runStatus(ctx, args)
+	case "ls":
+		err = runLs(ctx, args)
 	case "logs":
 		err = runLogs(ctx, args)
 	case "exec":
 		err = runExec(ctx, args)
+	case "shell":
+		err = runShell(ctx, args)
+	case "events":
+		err = runEvents(ctx, args)
+	case "run":
+		err = runRun(ctx, args)
+	case "tasks":
+		err = runTasks(args)
 	case "doctor":
 		err = runDoctor(ctx, args)
 	case "setup":
@@ -56,12 +78,18 @@ func main() {
 		err = runValidate(args)
 	case "render":
 		err = runRender(ctx, args)
+	case "schema":
+		err = runSchema(args)
+	case "migrate":
+		err = runMigrate(args)
 	case "lock":
 		err = runLock(ctx, args)
 	case "providers":
 		err = runProviders(ctx, args)
 	case "export":
 		err = runExport(ctx, args)
+	case "secrets":
+		err = runSecrets(args)
 	case "version", "--version", "-v":
 		fmt.Println("devx " + version)
 		return
@@ -74,29 +102,53 @@ func main() {
 		os.Exit(1)
 	}
 
+	var exitErr *exitCodeError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.code)
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
 }
 
+// exitCodeError makes devx exit with a command's exit code, as exec, shell
+// and run do with the command they ran, without printing anything.
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exited with code %d", e.code)
+}
+
 func printUsage() {
 	fmt.Println("devx - cross-platform dev orchestrator")
 	fmt.Println("\nUsage:")
 	fmt.Println("  devx init")
 	fmt.Println("  devx setup [--fix] [--json]")
-	fmt.Println("  devx up [--profile local|ci|k8s] [--build] [--pull] [--no-telemetry]")
-	fmt.Println("  devx down [--volumes]")
-	fmt.Println("  devx status [--json]")
-	fmt.Println("  devx logs [service] [--follow] [--since 10m] [--json]")
-	fmt.Println("  devx exec <service> -- <cmd...>")
-	fmt.Println("  devx doctor [--fix] [--json]")
-	fmt.Println("  devx validate [--file path]")
-	fmt.Println("  devx render compose [--write] [--no-telemetry]")
+	fmt.Println("  devx up [service...] [--profile local|ci|k8s] [--instance name] [--runtime name] [--build] [--pull] [--no-deps] [--no-telemetry] [--watch] [--progress auto|tty|json|plain]")
+	fmt.Println("  devx down [service...] [--profile name] [--instance name] [--volumes] [--force]")
+	fmt.Println("  devx restart <service...> [--profile name] [--instance name] [--force]")
+	fmt.Println("  devx stop <service...> [--profile name] [--instance name] [--force]")
+	fmt.Println("  devx status [--profile name] [--instance name] [--json] [--force]")
+	fmt.Println("  devx ls [--json] [--runtime name]")
+	fmt.Println("  devx logs [service...] [--profile name] [--instance name] [--follow] [--since 10m] [--tail n] [--grep regexp] [--level warn] [--json] [--no-color] [--force]")
+	fmt.Println("  devx events [service...] [--profile name] [--instance name] [--since 10m] [--json]")
+	fmt.Println("  devx exec <service> [--profile name] [--instance name] [--user u] [--workdir dir] [--env KEY=VALUE] [--no-tty] [--force] -- <cmd...>")
+	fmt.Println("  devx shell <service> [--profile name] [--instance name] [--user u] [--workdir dir] [--env KEY=VALUE] [--shell path]")
+	fmt.Println("  devx run <task> [--profile name] [--instance name] [--no-tty] [--force] [-- args...]")
+	fmt.Println("  devx tasks [--json]")
+	fmt.Println("  devx doctor [--fix] [--json] [--runtime name]")
+	fmt.Println("  devx validate [--file path] [--format text|json|sarif|github]")
+	fmt.Println("  devx schema [--out path]")
+	fmt.Println("  devx migrate [--file path] [--dry-run]")
+	fmt.Println("  devx render compose [--profile name] [--write] [--no-telemetry] [--show-env]")
 	fmt.Println("  devx render k8s [--profile name] [--namespace ns] [--write]")
-	fmt.Println("  devx lock update")
+	fmt.Println("  devx lock update [--runtime name]")
 	fmt.Println("  devx providers install")
 	fmt.Println("  devx providers list")
 	fmt.Println("  devx export --format compose|k8s|helm|terraform [--profile name] [--out dir]")
+	fmt.Println("  devx secrets set <name> | list | rm <name>")
 	fmt.Println("  devx version")
 }
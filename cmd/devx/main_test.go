@@ -1,9 +1,15 @@
 package main
 
 import (
+	"flag"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/dever-labs/devx/internal/config"
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
 )
 
 const validManifest = `version: 1
@@ -113,3 +119,113 @@ func TestLoadProfile_NoManifest(t *testing.T) {
 		t.Fatal("expected error when devx.yaml is missing")
 	}
 }
+
+func TestLoadActiveProfile(t *testing.T) {
+	defer chdirTemp(t, validManifest)()
+	in, _ := newInstance("")
+
+	if _, profName, _, _ := loadActiveProfile(in, ""); profName != "local" {
+		t.Fatalf("expected the default profile without state, got %q", profName)
+	}
+	if err := writeState(in, state{Profile: "ci", Runtime: "docker"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, profName, _, _ := loadActiveProfile(in, ""); profName != "ci" {
+		t.Fatalf("expected the profile from state, got %q", profName)
+	}
+	if _, profName, _, _ := loadActiveProfile(in, "local"); profName != "local" {
+		t.Fatalf("expected --profile to override state, got %q", profName)
+	}
+
+	// Each instance has its own state.
+	feature, _ := newInstance("feature")
+	if _, profName, _, _ := loadActiveProfile(feature, ""); profName != "local" {
+		t.Fatalf("expected a new instance to use the default profile, got %q", profName)
+	}
+}
+
+func TestRuntimeChoice(t *testing.T) {
+	prof := &config.Profile{ContainerRuntime: config.ContainerRuntime{Name: "docker", Binary: "/opt/docker", Context: "colima"}}
+
+	t.Setenv(runtimeEnv, "")
+	cfg, reason := runtimeChoice("", nil, "")
+	if cfg.Name != "" || reason != "" {
+		t.Fatalf("expected detection without any choice, got %+v (%s)", cfg, reason)
+	}
+	cfg, reason = runtimeChoice("", nil, "podman")
+	if cfg.Name != "podman" || reason != "the runtime devx up used" {
+		t.Fatalf("expected the recorded runtime, got %+v (%s)", cfg, reason)
+	}
+	cfg, reason = runtimeChoice("", prof, "podman")
+	if cfg != (devxruntime.Config{Name: "docker", Binary: "/opt/docker", Context: "colima"}) || reason != "containerRuntime in devx.yaml" {
+		t.Fatalf("expected the profile's runtime, got %+v (%s)", cfg, reason)
+	}
+
+	t.Setenv(runtimeEnv, "podman")
+	cfg, reason = runtimeChoice("", prof, "")
+	if cfg != (devxruntime.Config{Name: "podman"}) || reason != runtimeEnv {
+		t.Fatalf("expected DEVX_RUNTIME without the profile's docker settings, got %+v (%s)", cfg, reason)
+	}
+	cfg, reason = runtimeChoice("docker", prof, "")
+	if cfg.Name != "docker" || cfg.Binary != "/opt/docker" || reason != "--runtime" {
+		t.Fatalf("expected --runtime with the profile's docker settings, got %+v (%s)", cfg, reason)
+	}
+}
+
+func TestPrepareCompose_ManifestChanged(t *testing.T) {
+	defer chdirTemp(t, validManifest)()
+	in, _ := newInstance("")
+
+	manifest, profName, prof, err := loadProfile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	composePath, err := prepareCompose(in, manifest, profName, prof, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeState(in, state{Profile: profName, Runtime: "docker", ManifestHash: manifestHash(manifest, profName, prof)}); err != nil {
+		t.Fatal(err)
+	}
+	if manifestChanged(in, manifest, profName, prof) {
+		t.Fatal("expected the manifest to be unchanged")
+	}
+
+	svc := prof.Services["api"]
+	svc.Image = "nginx:1.27"
+	prof.Services["api"] = svc
+	if !manifestChanged(in, manifest, profName, prof) {
+		t.Fatal("expected a changed image to change the manifest hash")
+	}
+
+	// The compose file is regenerated from devx.yaml unless forced.
+	if _, err := prepareCompose(in, manifest, profName, prof, true); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(composePath); strings.Contains(string(data), "nginx:1.27") {
+		t.Fatal("expected --force to keep the compose file from up")
+	}
+	if err := os.WriteFile(lockFile, []byte(`{"version":1,"images":{"nginx:1.27":"sha256:abc"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prepareCompose(in, manifest, profName, prof, false); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(composePath); !strings.Contains(string(data), "nginx@sha256:abc") {
+		t.Fatalf("expected the compose file to be regenerated with the lockfile's pins, got:\n%s", data)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	fs := flag.NewFlagSet("up", flag.ContinueOnError)
+	build := fs.Bool("build", false, "")
+	profile := fs.String("profile", "", "")
+
+	got := parseArgs(fs, []string{"api", "--build", "worker", "--profile", "ci", "--", "--not-a-flag"})
+	if want := []string{"api", "worker", "--not-a-flag"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if !*build || *profile != "ci" {
+		t.Fatalf("flags not parsed: build=%v profile=%q", *build, *profile)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dever-labs/devx/internal/config"
+	"github.com/dever-labs/devx/internal/ports"
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
+	"github.com/dever-labs/devx/internal/util"
+)
+
+// portKey identifies a published port in state: the service or dep and the
+// host port devx.yaml asks for, with its protocol unless it is tcp.
+func portKey(name string, p config.Port) string {
+	key := name + ":" + p.Host.String()
+	if p.Proto() != "tcp" {
+		key += "/" + p.Proto()
+	}
+	return key
+}
+
+// allocatePorts checks that every host port the profile publishes is free
+// before `devx up`, and returns the host port chosen for each, keyed by
+// portKey, for state. Ports in own are published by the instance's running
+// containers and count as free. When a port is in use and auto is set, the
+// next free port above it is chosen instead, and a port chosen by an
+// earlier run (previous) is kept while it stays free; otherwise the error
+// names each port in use and the process holding it.
+func allocatePorts(prof *config.Profile, previous map[string]int, own map[int]bool, auto bool) (map[string]int, error) {
+	assigned := map[string]int{}
+	// taken holds the ports already chosen, by protocol, since tcp and udp
+	// can publish the same port.
+	taken := map[string]map[int]bool{}
+	var conflicts []string
+
+	// available reports the first port of the run starting at first that
+	// cannot be used, or 0 when all of them can.
+	available := func(p config.Port, first int) int {
+		for port := first; port < first+p.Host.Size(); port++ {
+			if taken[p.Proto()][port] || (!own[port] && !ports.Free(p.HostIP, port, p.Protocol)) {
+				return port
+			}
+		}
+		return 0
+	}
+	allocate := func(kind, name string, list []config.Port) {
+		for _, p := range list {
+			if p.Host.IsZero() || p.Host.Size() != p.Container.Size() {
+				// A single container port published on a host range lets
+				// the runtime pick any free port in it.
+				continue
+			}
+			key := portKey(name, p)
+			first := p.Host.First
+			if prev, ok := previous[key]; ok && auto {
+				first = prev
+			}
+			busy := available(p, first)
+			if busy != 0 && first != p.Host.First {
+				first = p.Host.First
+				busy = available(p, first)
+			}
+			if busy != 0 {
+				if !auto {
+					conflicts = append(conflicts, describeConflict(kind, name, busy, p.Protocol))
+					continue
+				}
+				var err error
+				if first, err = ports.FindFree(p.HostIP, p.Host.First, p.Host.Size(), p.Protocol, taken[p.Proto()]); err != nil {
+					conflicts = append(conflicts, fmt.Sprintf("  %s '%s': %v", kind, name, err))
+					continue
+				}
+			}
+			assigned[key] = first
+			if taken[p.Proto()] == nil {
+				taken[p.Proto()] = map[int]bool{}
+			}
+			for i := 0; i < p.Host.Size(); i++ {
+				taken[p.Proto()][first+i] = true
+			}
+		}
+	}
+	for _, name := range util.SortedKeys(prof.Deps) {
+		allocate("dep", name, prof.Deps[name].Ports)
+	}
+	for _, name := range util.SortedKeys(prof.Services) {
+		allocate("service", name, prof.Services[name].Ports)
+	}
+
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("host ports are already in use:\n%s\nStop what is using them, change the host ports in devx.yaml, or set ports.auto: true to pick free ones", strings.Join(conflicts, "\n"))
+	}
+	return assigned, nil
+}
+
+func describeConflict(kind, name string, port int, protocol string) string {
+	owner := "in use by another process"
+	if o, ok := ports.FindOwner(port, protocol); ok {
+		owner = "in use by " + o.String()
+	}
+	return fmt.Sprintf("  %d (%s '%s'): %s", port, kind, name, owner)
+}
+
+// publishedPorts returns the host ports the instance's running containers
+// already publish, so a second `devx up` does not see them as taken.
+func publishedPorts(ctx context.Context, rt devxruntime.Runtime, composePath, projectName string) map[int]bool {
+	own := map[int]bool{}
+	if !fileExists(composePath) {
+		return own
+	}
+	statuses, err := rt.Status(ctx, composePath, projectName)
+	if err != nil {
+		return own
+	}
+	for _, st := range statuses {
+		for _, pub := range st.Publishers {
+			if pub.PublishedPort != 0 {
+				own[pub.PublishedPort] = true
+			}
+		}
+	}
+	return own
+}
+
+// applyPorts rewrites the profile's host ports to those chosen by
+// allocatePorts, and the localhost URLs of HTTP health probes that name
+// them. Ports without an assignment are left as they are.
+func applyPorts(prof *config.Profile, assigned map[string]int) {
+	if len(assigned) == 0 {
+		return
+	}
+	remapped := func(name string, list []config.Port) []config.Port {
+		out := make([]config.Port, len(list))
+		for i, p := range list {
+			if first, ok := assigned[portKey(name, p)]; ok {
+				size := p.Host.Size()
+				p.Host = config.PortRange{First: first}
+				if size > 1 {
+					p.Host.Last = first + size - 1
+				}
+			}
+			out[i] = p
+		}
+		return out
+	}
+	for name, dep := range prof.Deps {
+		dep.Ports = remapped(name, dep.Ports)
+		prof.Deps[name] = dep
+	}
+	for name, svc := range prof.Services {
+		declared := svc.Ports
+		svc.Ports = remapped(name, svc.Ports)
+		if svc.Health != nil && svc.Health.HTTP != nil && svc.Health.HTTP.URL != "" {
+			h, probe := *svc.Health, *svc.Health.HTTP
+			probe.URL = probe.MoveURL(declared, svc.Ports)
+			h.HTTP = &probe
+			svc.Health = &h
+		}
+		prof.Services[name] = svc
+	}
+}
+
+// movedPorts maps each host port chosen in place of the one in devx.yaml
+// to the one it replaces.
+func movedPorts(assigned map[string]int) map[int]string {
+	moved := map[int]string{}
+	for key, first := range assigned {
+		host := key[strings.LastIndex(key, ":")+1:]
+		spec, _, _ := strings.Cut(host, "/")
+		if declared, _, _ := strings.Cut(spec, "-"); declared != strconv.Itoa(first) {
+			moved[first] = host
+		}
+	}
+	return moved
+}
+
+// describePorts lists the host ports that differ from devx.yaml, e.g.
+// "api 8080→8081".
+func describePorts(assigned map[string]int) []string {
+	moved := movedPorts(assigned)
+	var out []string
+	for _, key := range util.SortedKeys(assigned) {
+		if host, ok := moved[assigned[key]]; ok {
+			out = append(out, fmt.Sprintf("%s %s→%d", key[:strings.LastIndex(key, ":")], host, assigned[key]))
+		}
+	}
+	return out
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dever-labs/devx/internal/config"
+)
+
+func parsePorts(t *testing.T, specs ...string) []config.Port {
+	t.Helper()
+	var out []config.Port
+	for _, spec := range specs {
+		p, err := config.ParsePort(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func TestAllocatePorts(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	busy := l.Addr().(*net.TCPAddr).Port
+	busySpec := strconv.Itoa(busy)
+	newProfile := func() *config.Profile {
+		return &config.Profile{
+			Services: map[string]config.Service{
+				"api": {Ports: parsePorts(t, "127.0.0.1:"+busySpec+":80", "9229")},
+			},
+			Deps: map[string]config.Dep{
+				"db": {Ports: parsePorts(t, "127.0.0.1:"+strconv.Itoa(busy+1)+":5432")},
+			},
+		}
+	}
+
+	// Without auto, a port in use fails with its owner named.
+	_, err = allocatePorts(newProfile(), nil, nil, false)
+	if err == nil || !strings.Contains(err.Error(), busySpec+" (service 'api'): in use by ") || !strings.Contains(err.Error(), "ports.auto: true") {
+		t.Fatalf("expected a conflict naming the api port, got %v", err)
+	}
+
+	// A port the instance itself publishes is not a conflict.
+	if _, err := allocatePorts(newProfile(), nil, map[int]bool{busy: true}, false); err != nil {
+		t.Fatalf("expected the instance's own port to be accepted: %v", err)
+	}
+
+	// With auto, the busy port moves and a free port from state is kept.
+	prof := newProfile()
+	dbKey, apiKey := "db:"+strconv.Itoa(busy+1), "api:"+busySpec
+	assigned, err := allocatePorts(prof, map[string]int{dbKey: 41000}, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assigned[apiKey] <= busy || assigned[apiKey] == 41000 {
+		t.Fatalf("expected the busy api port to move to a free one, got %v", assigned)
+	}
+	if assigned[dbKey] != 41000 {
+		t.Fatalf("expected the db port from state to be kept, got %v", assigned)
+	}
+	if len(assigned) != 2 {
+		t.Fatalf("expected unpublished ports to be left alone, got %v", assigned)
+	}
+
+	applyPorts(prof, assigned)
+	if got := prof.Services["api"].Ports[0].Host.First; got != assigned[apiKey] {
+		t.Fatalf("expected api to publish %d, got %d", assigned[apiKey], got)
+	}
+	if got := prof.Deps["db"].Ports[0].Host.First; got != 41000 {
+		t.Fatalf("expected db to publish 41000, got %d", got)
+	}
+}
+
+func TestAllocatePorts_Protocols(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	busy := l.Addr().(*net.TCPAddr).Port
+	spec := strconv.Itoa(busy)
+	prof := &config.Profile{
+		Services: map[string]config.Service{
+			"dns": {Ports: parsePorts(t, "127.0.0.1:"+spec+":53/tcp", "127.0.0.1:"+spec+":53/udp")},
+		},
+	}
+	tcpKey, udpKey := "dns:"+spec, "dns:"+spec+"/udp"
+
+	// The same port over tcp and udp is not a conflict with itself.
+	l.Close()
+	assigned, err := allocatePorts(prof, nil, nil, false)
+	if err != nil {
+		t.Fatalf("expected tcp and udp to share a port: %v", err)
+	}
+	if assigned[tcpKey] != busy || assigned[udpKey] != busy {
+		t.Fatalf("expected both protocols to keep %d, got %v", busy, assigned)
+	}
+
+	// With auto, only the protocol in use moves.
+	if l, err = net.Listen("tcp", "127.0.0.1:"+spec); err != nil {
+		t.Skipf("port %d was taken meanwhile: %v", busy, err)
+	}
+	defer l.Close()
+	assigned, err = allocatePorts(prof, nil, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assigned[tcpKey] == busy || assigned[udpKey] != busy {
+		t.Fatalf("expected only the tcp port to move, got %v", assigned)
+	}
+	if got, want := describePorts(assigned), []string{"dns " + spec + "→" + strconv.Itoa(assigned[tcpKey])}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestApplyPorts_MovesHealthURL(t *testing.T) {
+	probe := &config.HTTPProbe{URL: "http://localhost:8080/health"}
+	prof := &config.Profile{
+		Services: map[string]config.Service{
+			"api": {Ports: parsePorts(t, "8080:80"), Health: &config.Health{HTTP: probe}},
+		},
+	}
+	applyPorts(prof, map[string]int{"api:8080": 8081})
+
+	api := prof.Services["api"]
+	if host, _ := api.Health.HTTP.HostURL(api.Ports); host != "http://localhost:8081/health" {
+		t.Fatalf("expected the host probe to follow the moved port, got %s", host)
+	}
+	if container, err := api.Health.HTTP.ContainerURL(api.Ports); err != nil || container != "http://localhost:80/health" {
+		t.Fatalf("expected the container probe to use the container port, got %s (%v)", container, err)
+	}
+	if probe.URL != "http://localhost:8080/health" {
+		t.Fatalf("expected the loaded manifest to be left alone, got %s", probe.URL)
+	}
+}
+
+func TestDescribePorts(t *testing.T) {
+	assigned := map[string]int{"db:5432": 5432, "api:8080": 8081, "metrics:9000-9002": 9100}
+	if got, want := describePorts(assigned), []string{"api 8080→8081", "metrics 9000-9002→9100"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got, want := movedPorts(assigned), map[int]string{8081: "8080", 9100: "9000-9002"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Health statuses reported while devx up waits for services.
+const (
+	statusPending = "pending"
+	statusHealthy = "healthy"
+	statusFailed  = "failed"
+)
+
+// serviceHealth is what is known about one service while it is waited on.
+type serviceHealth struct {
+	Status  string
+	Checks  int
+	Elapsed time.Duration
+	Err     error
+}
+
+// startupProgress reports devx up's progress through the startup waves.
+// Implementations are safe for concurrent use.
+type startupProgress interface {
+	// wave announces that wave n of total is starting names.
+	wave(n, total int, names []string)
+	// update records the latest state of one service in the current wave.
+	update(name string, h serviceHealth)
+	// done ends the current wave's display.
+	done()
+}
+
+// newStartupProgress picks a display for mode: "tty" redraws a live table,
+// "json" writes one event per line and "plain" writes one line per result.
+// "auto" uses tty when w is a terminal and json otherwise.
+func newStartupProgress(w io.Writer, mode string, terminal bool) (startupProgress, error) {
+	if mode == "auto" {
+		mode = "json"
+		if terminal {
+			mode = "tty"
+		}
+	}
+	switch mode {
+	case "tty":
+		return &ttyProgress{w: w}, nil
+	case "json":
+		return &jsonProgress{enc: json.NewEncoder(w), last: map[string]string{}}, nil
+	case "plain":
+		return &plainProgress{w: w}, nil
+	}
+	return nil, fmt.Errorf("unknown progress mode %q — use auto, tty, json or plain", mode)
+}
+
+// ttyProgress redraws a table of the current wave in place, with a spinner
+// beside services that are still pending.
+type ttyProgress struct {
+	mu    sync.Mutex
+	w     io.Writer
+	names []string
+	rows  map[string]serviceHealth
+	drawn int
+	frame int
+	stop  chan struct{}
+	ended chan struct{}
+}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+func (p *ttyProgress) wave(n, total int, names []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "Wave %d/%d: starting %s\n", n, total, strings.Join(names, ", "))
+	p.names = names
+	p.rows = map[string]serviceHealth{}
+	for _, name := range names {
+		p.rows[name] = serviceHealth{Status: statusPending}
+	}
+	p.drawn = 0
+	p.redraw()
+
+	p.stop, p.ended = make(chan struct{}), make(chan struct{})
+	go p.spin(p.stop, p.ended)
+}
+
+func (p *ttyProgress) spin(stop, ended chan struct{}) {
+	defer close(ended)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			p.frame++
+			p.redraw()
+			p.mu.Unlock()
+		}
+	}
+}
+
+func (p *ttyProgress) update(name string, h serviceHealth) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rows[name] = h
+	p.redraw()
+}
+
+func (p *ttyProgress) done() {
+	if p.stop == nil {
+		return
+	}
+	close(p.stop)
+	<-p.ended
+	p.stop = nil
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.redraw()
+}
+
+// redraw moves the cursor back over the previous table and rewrites it.
+// Callers hold p.mu.
+func (p *ttyProgress) redraw() {
+	if p.drawn > 0 {
+		fmt.Fprintf(p.w, "\x1b[%dA", p.drawn)
+	}
+	width := 0
+	for _, name := range p.names {
+		width = max(width, len(name))
+	}
+	for _, name := range p.names {
+		h := p.rows[name]
+		icon := spinnerFrames[p.frame%len(spinnerFrames)]
+		detail := ""
+		switch h.Status {
+		case statusHealthy:
+			icon = "✓"
+			detail = h.Elapsed.Round(100 * time.Millisecond).String()
+		case statusFailed:
+			icon = "✗"
+			detail = errText(h.Err)
+		default:
+			if h.Err != nil {
+				detail = fmt.Sprintf("check %d: %s", h.Checks, errText(h.Err))
+			}
+		}
+		fmt.Fprintf(p.w, "\x1b[2K  %s %-*s  %-7s  %s\n", icon, width, name, h.Status, detail)
+	}
+	p.drawn = len(p.names)
+}
+
+// jsonProgress writes newline-delimited JSON events for CI logs and other
+// tools: one per wave and one each time a service's status changes.
+type jsonProgress struct {
+	mu   sync.Mutex
+	enc  *json.Encoder
+	last map[string]string
+}
+
+type progressEvent struct {
+	Event     string   `json:"event"`
+	Time      string   `json:"time"`
+	Wave      int      `json:"wave,omitempty"`
+	Waves     int      `json:"waves,omitempty"`
+	Services  []string `json:"services,omitempty"`
+	Service   string   `json:"service,omitempty"`
+	Status    string   `json:"status,omitempty"`
+	Checks    int      `json:"checks,omitempty"`
+	ElapsedMS int64    `json:"elapsedMs,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+func (p *jsonProgress) wave(n, total int, names []string) {
+	p.emit(progressEvent{Event: "wave", Wave: n, Waves: total, Services: names})
+}
+
+func (p *jsonProgress) update(name string, h serviceHealth) {
+	p.mu.Lock()
+	changed := p.last[name] != h.Status
+	p.last[name] = h.Status
+	p.mu.Unlock()
+	if !changed {
+		return
+	}
+	p.emit(progressEvent{
+		Event:     "health",
+		Service:   name,
+		Status:    h.Status,
+		Checks:    h.Checks,
+		ElapsedMS: h.Elapsed.Milliseconds(),
+		Error:     errText(h.Err),
+	})
+}
+
+func (p *jsonProgress) done() {}
+
+func (p *jsonProgress) emit(e progressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	_ = p.enc.Encode(e)
+}
+
+// plainProgress writes a line per wave and per finished service.
+type plainProgress struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (p *plainProgress) wave(n, total int, names []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "Wave %d/%d: starting %s\n", n, total, strings.Join(names, ", "))
+}
+
+func (p *plainProgress) update(name string, h serviceHealth) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch h.Status {
+	case statusHealthy:
+		fmt.Fprintf(p.w, "  ✓ %s (%s)\n", name, h.Elapsed.Round(100*time.Millisecond))
+	case statusFailed:
+		fmt.Fprintf(p.w, "  ✗ %s: %s\n", name, errText(h.Err))
+	}
+}
+
+func (p *plainProgress) done() {}
+
+func errText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONProgress(t *testing.T) {
+	var buf bytes.Buffer
+	p, err := newStartupProgress(&buf, "auto", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.wave(1, 2, []string{"db"})
+	p.update("db", serviceHealth{Status: statusPending, Checks: 1, Err: errors.New("refused")})
+	p.update("db", serviceHealth{Status: statusPending, Checks: 2, Err: errors.New("refused")})
+	p.update("db", serviceHealth{Status: statusHealthy, Checks: 3, Elapsed: 1500 * time.Millisecond})
+	p.done()
+
+	var events []progressEvent
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e progressEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		events = append(events, e)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected wave, pending and healthy events, got %+v", events)
+	}
+	if e := events[0]; e.Event != "wave" || e.Wave != 1 || e.Waves != 2 || len(e.Services) != 1 {
+		t.Errorf("unexpected wave event: %+v", e)
+	}
+	if e := events[1]; e.Service != "db" || e.Status != statusPending || e.Error != "refused" {
+		t.Errorf("unexpected pending event: %+v", e)
+	}
+	if e := events[2]; e.Status != statusHealthy || e.ElapsedMS != 1500 || e.Checks != 3 {
+		t.Errorf("unexpected healthy event: %+v", e)
+	}
+}
+
+func TestTTYProgress(t *testing.T) {
+	var buf bytes.Buffer
+	p, _ := newStartupProgress(&buf, "auto", true)
+	p.wave(1, 1, []string{"api", "db"})
+	p.update("db", serviceHealth{Status: statusHealthy, Elapsed: time.Second})
+	p.update("api", serviceHealth{Status: statusFailed, Err: errors.New("timed out")})
+	p.done()
+
+	out := buf.String()
+	// The last redraw moves up over the two rows and rewrites them.
+	last := out[strings.LastIndex(out, "\x1b[2A"):]
+	for _, want := range []string{"✗ api  failed   timed out", "✓ db   healthy  1s"} {
+		if !strings.Contains(last, want) {
+			t.Errorf("expected final table to contain %q, got %q", want, last)
+		}
+	}
+}
+
+func TestNewStartupProgress_UnknownMode(t *testing.T) {
+	if _, err := newStartupProgress(&bytes.Buffer{}, "fancy", true); err == nil {
+		t.Fatal("expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dever-labs/devx/internal/config"
+	"github.com/dever-labs/devx/internal/graph"
+	"github.com/dever-labs/devx/internal/health"
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
+)
+
+// startServices runs startWaves until it finishes or the user presses
+// Ctrl-C, which stops the wait. Containers already started keep running.
+func startServices(ctx context.Context, rt devxruntime.Runtime, composePath, projectName string, prof *config.Profile, opts devxruntime.UpOptions, progress startupProgress) error {
+	waitCtx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	err := startWaves(waitCtx, rt, composePath, projectName, prof, opts, progress)
+	if err != nil && waitCtx.Err() != nil && ctx.Err() == nil {
+		return fmt.Errorf("interrupted while waiting for services; run 'devx down' to stop what was started")
+	}
+	return err
+}
+
+// startWaves starts the profile in dependency order. Each wave holds the
+// services and deps whose dependencies are all in earlier waves; it starts
+// once those are healthy, or running when they have no healthcheck. When
+// opts.Services is set, only those services start, along with what they
+// depend on unless opts.NoDeps is set. Cancelling ctx stops the wait and
+// returns ctx's error.
+func startWaves(ctx context.Context, rt devxruntime.Runtime, composePath, projectName string, prof *config.Profile, opts devxruntime.UpOptions, progress startupProgress) error {
+	g, err := graph.Build(prof)
+	if err != nil {
+		return err
+	}
+	if len(opts.Services) > 0 {
+		if g, err = graph.Subgraph(g, opts.Services, !opts.NoDeps); err != nil {
+			return err
+		}
+	}
+	waves, err := graph.Waves(g)
+	if err != nil {
+		return err
+	}
+
+	for i, wave := range waves {
+		waveOpts := opts
+		waveOpts.Services = wave
+		waveOpts.NoDeps = true
+		if err := rt.Up(ctx, composePath, projectName, waveOpts); err != nil {
+			return err
+		}
+
+		progress.wave(i+1, len(waves), wave)
+		failures := waitForHealth(ctx, rt, composePath, projectName, prof, wave, progress)
+		progress.done()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if len(failures) > 0 {
+			return &startupError{graph: g, failures: failures}
+		}
+	}
+	return nil
+}
+
+// waitForHealth waits, in parallel, for each named service or dep to pass
+// its health check, and returns the error for each that did not. Services
+// use their health probe's schedule; deps and services without a probe
+// use the default one.
+func waitForHealth(ctx context.Context, rt devxruntime.Runtime, composePath, projectName string, prof *config.Profile, names []string, progress startupProgress) map[string]error {
+	var mu sync.Mutex
+	failures := map[string]error{}
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			start := time.Now()
+			check, timing := healthCheck(ctx, rt, composePath, projectName, prof, name)
+			checks := 0
+			reported := func(ctx context.Context) error {
+				err := check(ctx)
+				checks++
+				progress.update(name, serviceHealth{Status: statusPending, Checks: checks, Elapsed: time.Since(start), Err: err})
+				return err
+			}
+
+			err := health.Wait(ctx, reported, timing)
+			h := serviceHealth{Status: statusHealthy, Checks: checks, Elapsed: time.Since(start)}
+			if err != nil {
+				h.Status, h.Err = statusFailed, err
+				mu.Lock()
+				failures[name] = err
+				mu.Unlock()
+			}
+			progress.update(name, h)
+		}(name)
+	}
+	wg.Wait()
+	return failures
+}
+
+// healthCheck returns how devx checks a service or dep from the host. HTTP
+// and TCP probes on published ports run directly; everything else follows
+// the container's state and the compose healthcheck rendered for it,
+// queried under ctx rather than the probe's timeout.
+func healthCheck(ctx context.Context, rt devxruntime.Runtime, composePath, projectName string, prof *config.Profile, name string) (health.Check, config.HealthTiming) {
+	svc, ok := prof.Services[name]
+	if !ok || svc.Health == nil {
+		return containerCheck(ctx, rt, composePath, projectName, name), config.Health{}.Timing()
+	}
+
+	h := svc.Health
+	timing := h.Timing()
+	switch h.ProbeKind() {
+	case "http":
+		if url, ok := h.HTTP.HostURL(svc.Ports); ok {
+			return health.HTTP(url, h.HTTP.Accepts), timing
+		}
+	case "tcp":
+		if port, ok := config.ResolvePort(svc.Ports, h.TCP.Port); ok {
+			if addr, ok := port.HostAddress(); ok {
+				return health.TCP(addr), timing
+			}
+		}
+	}
+	return containerCheck(ctx, rt, composePath, projectName, name), timing
+}
+
+// statusTimeout bounds each runtime status query made by containerCheck.
+// It is independent of the probe timeout, which is meant for the service,
+// as `compose ps` can be slow on a loaded daemon.
+const statusTimeout = 10 * time.Second
+
+// containerCheck passes when the container is healthy, or running if it
+// has no healthcheck. The status query runs under ctx with statusTimeout,
+// not under the per-attempt context health.Wait passes in.
+func containerCheck(ctx context.Context, rt devxruntime.Runtime, composePath, projectName, name string) health.Check {
+	return func(context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, statusTimeout)
+		defer cancel()
+		statuses, err := rt.Status(ctx, composePath, projectName)
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			if st.Name != name {
+				continue
+			}
+			switch {
+			case st.Health == "healthy", st.Health == "" && st.State == "running":
+				return nil
+			case st.Health == "":
+				return fmt.Errorf("container is %s", st.State)
+			}
+			return fmt.Errorf("container is %s", st.Health)
+		}
+		return fmt.Errorf("container is not running")
+	}
+}
+
+// startupError reports the services and deps that did not become healthy.
+type startupError struct {
+	graph    *graph.Graph
+	failures map[string]error
+}
+
+// names returns the failed services and deps, sorted.
+func (e *startupError) names() []string {
+	names := make([]string, 0, len(e.failures))
+	for name := range e.failures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Error names each dependency that failed and what it blocks.
+func (e *startupError) Error() string {
+	var parts []string
+	for _, name := range e.names() {
+		kind := e.graph.Nodes[name].Kind
+		if blocked := graph.Dependents(e.graph, name); len(blocked) > 0 {
+			parts = append(parts, fmt.Sprintf("%s '%s' did not become healthy, blocking %s: %v", kind, name, strings.Join(blocked, ", "), e.failures[name]))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s '%s' did not become healthy: %v", kind, name, e.failures[name]))
+	}
+	return strings.Join(parts, "; ")
+}
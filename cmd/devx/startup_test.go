@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dever-labs/devx/internal/config"
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
+	"github.com/dever-labs/devx/internal/runtime/nerdctl"
+)
+
+// waveRuntime records the services each Up call starts and reports them
+// with the given container health.
+type waveRuntime struct {
+	devxruntime.Runtime
+	health  map[string]string
+	started [][]string
+	running []string
+}
+
+func (r *waveRuntime) Up(_ context.Context, _, _ string, opts devxruntime.UpOptions) error {
+	r.started = append(r.started, opts.Services)
+	r.running = append(r.running, opts.Services...)
+	return nil
+}
+
+func (r *waveRuntime) Status(context.Context, string, string) ([]devxruntime.ServiceStatus, error) {
+	var out []devxruntime.ServiceStatus
+	for _, name := range r.running {
+		out = append(out, devxruntime.ServiceStatus{Name: name, State: "running", Health: r.health[name]})
+	}
+	return out, nil
+}
+
+func TestStartWaves_Order(t *testing.T) {
+	prof := &config.Profile{
+		Services: map[string]config.Service{
+			"api": {Image: "api", DependsOn: []string{"db", "cache"}},
+			"web": {Image: "web", DependsOn: []string{"api"}},
+		},
+		Deps: map[string]config.Dep{
+			"db":    {Image: "postgres"},
+			"cache": {Image: "redis"},
+		},
+	}
+	rt := &waveRuntime{health: map[string]string{"db": "healthy"}}
+	if err := startWaves(context.Background(), rt, "", "my-app", prof, devxruntime.UpOptions{}, &plainProgress{w: io.Discard}); err != nil {
+		t.Fatalf("startWaves failed: %v", err)
+	}
+	want := [][]string{{"cache", "db"}, {"api"}, {"web"}}
+	if !reflect.DeepEqual(rt.started, want) {
+		t.Fatalf("got waves %v, want %v", rt.started, want)
+	}
+}
+
+func TestStartWaves_NamesBlockingDependency(t *testing.T) {
+	prof := &config.Profile{
+		Services: map[string]config.Service{
+			"auth": {Image: "auth", Health: &config.Health{
+				Exec:     &config.ExecProbe{Command: []string{"true"}},
+				Interval: "1ms",
+				Retries:  1,
+			}},
+			"api": {Image: "api", DependsOn: []string{"auth"}},
+			"web": {Image: "web", DependsOn: []string{"api"}},
+		},
+	}
+	rt := &waveRuntime{health: map[string]string{"auth": "unhealthy"}}
+	err := startWaves(context.Background(), rt, "", "my-app", prof, devxruntime.UpOptions{}, &plainProgress{w: io.Discard})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	want := "service 'auth' did not become healthy, blocking api, web: unhealthy after "
+	if !strings.HasPrefix(err.Error(), want) || !strings.HasSuffix(err.Error(), ": container is unhealthy") {
+		t.Fatalf("got %q, want %q", err, want)
+	}
+	if len(rt.started) != 1 || strings.Join(rt.started[0], ",") != "auth" {
+		t.Fatalf("expected only the first wave to start, got %v", rt.started)
+	}
+}
+
+func TestStartWaves_Cancelled(t *testing.T) {
+	prof := &config.Profile{
+		Services: map[string]config.Service{"api": {Image: "api"}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rt := &waveRuntime{health: map[string]string{"api": "starting"}}
+	err := startWaves(ctx, rt, "", "my-app", prof, devxruntime.UpOptions{}, &plainProgress{w: io.Discard})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestStartWaves_SelectedServices(t *testing.T) {
+	prof := &config.Profile{
+		Services: map[string]config.Service{
+			"api":    {Image: "api", DependsOn: []string{"db"}},
+			"worker": {Image: "worker", DependsOn: []string{"cache"}},
+			"web":    {Image: "web", DependsOn: []string{"api"}},
+		},
+		Deps: map[string]config.Dep{
+			"db":    {Image: "postgres"},
+			"cache": {Image: "redis"},
+		},
+	}
+	rt := &waveRuntime{}
+	opts := devxruntime.UpOptions{Services: []string{"api", "worker"}}
+	if err := startWaves(context.Background(), rt, "", "my-app", prof, opts, &plainProgress{w: io.Discard}); err != nil {
+		t.Fatalf("startWaves failed: %v", err)
+	}
+	if want := [][]string{{"cache", "db"}, {"api", "worker"}}; !reflect.DeepEqual(rt.started, want) {
+		t.Fatalf("with deps: got %v, want %v", rt.started, want)
+	}
+
+	rt = &waveRuntime{}
+	opts.NoDeps = true
+	if err := startWaves(context.Background(), rt, "", "my-app", prof, opts, &plainProgress{w: io.Discard}); err != nil {
+		t.Fatalf("startWaves failed: %v", err)
+	}
+	if want := [][]string{{"api", "worker"}}; !reflect.DeepEqual(rt.started, want) {
+		t.Fatalf("without deps: got %v, want %v", rt.started, want)
+	}
+}
+
+func TestStartWaves_Nerdctl(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	// The stub logs each up and reports every service running.
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "calls.log")
+	script := `#!/bin/sh
+case "$*" in
+*" up "*) echo "$*" >> '` + logFile + `' ;;
+*" ps "*) echo '[{"Service":"db","State":"running"},{"Service":"api","State":"running"}]' ;;
+esac
+`
+	bin := filepath.Join(dir, "nerdctl")
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	prof := &config.Profile{
+		Services: map[string]config.Service{"api": {Image: "api", DependsOn: []string{"db"}}},
+		Deps:     map[string]config.Dep{"db": {Image: "postgres"}},
+	}
+	rt := &nerdctl.Runtime{Binary: bin}
+	if err := startWaves(context.Background(), rt, "compose.yaml", "my-app", prof, devxruntime.UpOptions{}, &plainProgress{w: io.Discard}); err != nil {
+		t.Fatalf("startWaves failed: %v", err)
+	}
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "compose -f compose.yaml -p my-app up -d db\ncompose -f compose.yaml -p my-app up -d api\n"
+	if string(data) != want {
+		t.Fatalf("got calls:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+// slowStatusRuntime answers Status only after delay.
+type slowStatusRuntime struct {
+	waveRuntime
+	delay time.Duration
+}
+
+func (r *slowStatusRuntime) Status(ctx context.Context, composePath, projectName string) ([]devxruntime.ServiceStatus, error) {
+	select {
+	case <-time.After(r.delay):
+		return r.waveRuntime.Status(ctx, composePath, projectName)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestContainerCheck_StatusOutlivesProbeTimeout(t *testing.T) {
+	rt := &slowStatusRuntime{waveRuntime: waveRuntime{running: []string{"db"}}, delay: 50 * time.Millisecond}
+	check := containerCheck(context.Background(), rt, "", "my-app", "db")
+
+	attemptCtx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := check(attemptCtx); err != nil {
+		t.Fatalf("expected the status query to outlive the probe timeout, got %v", err)
+	}
+
+	ctx, cancelWait := context.WithCancel(context.Background())
+	cancelWait()
+	if err := containerCheck(ctx, rt, "", "my-app", "db")(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelling the wait to stop the query, got %v", err)
+	}
+}
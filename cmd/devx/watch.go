@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dever-labs/devx/internal/config"
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
+	"github.com/dever-labs/devx/internal/util"
+	"github.com/dever-labs/devx/internal/watch"
+)
+
+const (
+	watchInterval = 300 * time.Millisecond
+	// watchDebounce is how long files must stay unchanged before a batch
+	// of changes is acted on.
+	watchDebounce = 500 * time.Millisecond
+)
+
+// watchDefaultIgnores are never watched: version control metadata, devx's
+// own generated files, and dependency trees that are large to walk on
+// every poll and are installed rather than edited.
+var watchDefaultIgnores = []string{"**/.git", devxDir, "**/node_modules", "**/vendor", "**/.venv", "**/__pycache__"}
+
+// serviceWatch is everything watched for one service.
+type serviceWatch struct {
+	service  string
+	rules    []watchRule
+	watchers []*watch.Watcher
+}
+
+// watchRule is a manifest watch rule resolved against the filesystem.
+type watchRule struct {
+	config.WatchRule
+	// root is the absolute path the rule watches.
+	root   string
+	ignore []*watch.Ignore
+}
+
+// matches reports whether a changed file falls under the rule.
+func (r watchRule) matches(file string) bool {
+	rel, err := filepath.Rel(r.root, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	for _, ig := range r.ignore {
+		if ig.Match(file) {
+			return false
+		}
+	}
+	return true
+}
+
+// containerPath returns where a sync rule copies file to.
+func (r watchRule) containerPath(file string) string {
+	rel, _ := filepath.Rel(r.root, file)
+	if rel == "." {
+		return r.Target
+	}
+	return path.Join(r.Target, filepath.ToSlash(rel))
+}
+
+// newServiceWatches resolves the watch rules of every service in the
+// profile. A service without rules but with a build is rebuilt when its
+// build context changes.
+func newServiceWatches(m *config.Manifest, prof *config.Profile) ([]*serviceWatch, error) {
+	var out []*serviceWatch
+	for _, name := range util.SortedKeys(prof.Services) {
+		svc := prof.Services[name]
+		rules := svc.Watch
+		if len(rules) == 0 && svc.Build != nil {
+			rules = []config.WatchRule{{Path: svc.Build.Context, Action: config.WatchRebuild}}
+		}
+		if len(rules) == 0 {
+			continue
+		}
+
+		base, err := watchBaseIgnores(m.Dir, svc)
+		if err != nil {
+			return nil, fmt.Errorf("service '%s': %w", name, err)
+		}
+		sw := &serviceWatch{service: name}
+		for _, rule := range rules {
+			root, err := filepath.Abs(filepath.Join(m.Dir, rule.Path))
+			if err != nil {
+				return nil, err
+			}
+			own, err := watch.NewIgnore(root, rule.Ignore)
+			if err != nil {
+				return nil, fmt.Errorf("service '%s' watch '%s': %w", name, rule.Path, err)
+			}
+			r := watchRule{WatchRule: rule, root: root, ignore: append(append([]*watch.Ignore{}, base...), own)}
+			w, err := watch.NewWatcher(root, r.ignore...)
+			if err != nil {
+				return nil, fmt.Errorf("service '%s' watch '%s': %w", name, rule.Path, err)
+			}
+			sw.rules = append(sw.rules, r)
+			sw.watchers = append(sw.watchers, w)
+		}
+		out = append(out, sw)
+	}
+	return out, nil
+}
+
+// watchBaseIgnores returns the ignores shared by every rule of a service:
+// the defaults and its build context's .dockerignore.
+func watchBaseIgnores(dir string, svc config.Service) ([]*watch.Ignore, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := watch.NewIgnore(root, watchDefaultIgnores)
+	if err != nil {
+		return nil, err
+	}
+	ignores := []*watch.Ignore{defaults}
+	if svc.Build != nil && !strings.Contains(svc.Build.Context, "://") {
+		dockerignore, err := watch.LoadDockerignore(filepath.Join(root, svc.Build.Context))
+		if err != nil {
+			return nil, err
+		}
+		ignores = append(ignores, dockerignore)
+	}
+	return ignores, nil
+}
+
+// runWatch watches every service until the user presses Ctrl-C, alongside
+// any background hooks.
+func runWatch(ctx context.Context, rt devxruntime.Runtime, composePath, projectName string, m *config.Manifest, prof *config.Profile, bgCmds []*exec.Cmd) error {
+	watches, err := newServiceWatches(m, prof)
+	if err != nil {
+		return err
+	}
+	if len(watches) == 0 {
+		return errors.New("nothing to watch: add a watch block or build to a service")
+	}
+
+	watchCtx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	errCh := make(chan error, len(watches))
+	for _, sw := range watches {
+		go func(sw *serviceWatch) {
+			errCh <- watch.Run(watchCtx, sw.watchers, watchInterval, watchDebounce, func(changes []watch.Change) {
+				applyChanges(watchCtx, rt, composePath, projectName, prof, sw, changes)
+			})
+		}(sw)
+	}
+
+	fmt.Println("\nWatching for changes — Ctrl+C to stop.")
+	if len(bgCmds) > 0 {
+		waitForBackground(bgCmds)
+		stop()
+	}
+	for range watches {
+		if err := <-errCh; err != nil && !errors.Is(err, context.Canceled) {
+			stop()
+			return err
+		}
+	}
+	return nil
+}
+
+// applyChanges runs the most disruptive action of the rules the changes
+// match. Failures are reported and watching continues.
+func applyChanges(ctx context.Context, rt devxruntime.Runtime, composePath, projectName string, prof *config.Profile, sw *serviceWatch, changes []watch.Change) {
+	action, matched := "", 0
+	var synced []watch.Change
+	var syncRules []watchRule
+	for _, c := range changes {
+		hit := false
+		for _, r := range sw.rules {
+			if !r.matches(c.Path) {
+				continue
+			}
+			hit = true
+			if config.WatchRank(r.Action) > config.WatchRank(action) {
+				action = r.Action
+			}
+			if r.Action == config.WatchSync {
+				synced = append(synced, c)
+				syncRules = append(syncRules, r)
+			}
+		}
+		if hit {
+			matched++
+		}
+	}
+	if action == "" {
+		return
+	}
+
+	fmt.Printf("[%s] %d file(s) changed — %s\n", sw.service, matched, action)
+	start := time.Now()
+	var err error
+	switch action {
+	case config.WatchSync:
+		err = syncChanges(ctx, rt, composePath, projectName, sw.service, synced, syncRules)
+	case config.WatchRestart:
+		err = rt.Up(ctx, composePath, projectName, devxruntime.UpOptions{Services: []string{sw.service}, NoDeps: true, ForceRecreate: true})
+	case config.WatchRebuild:
+		err = rt.Up(ctx, composePath, projectName, devxruntime.UpOptions{Build: true, Services: []string{sw.service}, NoDeps: true})
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "[%s] %s failed: %v\n", sw.service, action, err)
+		}
+		return
+	}
+	if action != config.WatchSync {
+		if failures := waitForHealth(ctx, rt, composePath, projectName, prof, []string{sw.service}, &plainProgress{w: os.Stdout}); len(failures) > 0 {
+			return
+		}
+	}
+	fmt.Printf("[%s] %s done in %s\n", sw.service, action, time.Since(start).Round(100*time.Millisecond))
+}
+
+// syncChanges copies changed files into the container and removes deleted
+// ones. changes[i] is synced by rules[i]. Removals are made with one exec,
+// and the changed files under each rule are staged in a temporary
+// directory and copied in one go, so a branch switch is one copy rather
+// than one per file.
+func syncChanges(ctx context.Context, rt devxruntime.Runtime, composePath, projectName, service string, changes []watch.Change, rules []watchRule) error {
+	copier, ok := rt.(devxruntime.Copier)
+	if !ok {
+		return fmt.Errorf("%s cannot copy files into containers; use restart or rebuild", rt.Name())
+	}
+
+	var removed []string
+	var batches []*syncBatch
+	byRule := map[string]*syncBatch{}
+	for i, c := range changes {
+		r := rules[i]
+		if c.Removed {
+			removed = append(removed, r.containerPath(c.Path))
+			continue
+		}
+		key := r.root + "\x00" + r.Target
+		b, ok := byRule[key]
+		if !ok {
+			b = &syncBatch{rule: r}
+			byRule[key] = b
+			batches = append(batches, b)
+		}
+		b.files = append(b.files, c.Path)
+	}
+
+	if len(removed) > 0 {
+		cmd := append([]string{"rm", "-rf"}, removed...)
+		code, err := rt.Exec(ctx, composePath, projectName, service, devxruntime.ExecOptions{Cmd: cmd})
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return fmt.Errorf("rm exited with code %d", code)
+		}
+	}
+	for _, b := range batches {
+		if err := b.copy(ctx, copier, composePath, projectName, service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncBatch is the changed files one sync rule copies.
+type syncBatch struct {
+	rule  watchRule
+	files []string
+}
+
+// copy stages the batch's files under a temporary directory laid out like
+// the rule's target and copies it into the container. A rule that watches
+// a single file copies it directly.
+func (b *syncBatch) copy(ctx context.Context, copier devxruntime.Copier, composePath, projectName, service string) error {
+	rootInfo, err := os.Stat(b.rule.root)
+	if err != nil {
+		return err
+	}
+	if !rootInfo.IsDir() {
+		return copier.Copy(ctx, composePath, projectName, service, b.rule.root, b.rule.Target)
+	}
+
+	staging, err := os.MkdirTemp("", "devx-sync-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+	// The staging directory stands in for the target, so it gets the
+	// watched directory's mode rather than MkdirTemp's 0700.
+	if err := os.Chmod(staging, rootInfo.Mode().Perm()); err != nil {
+		return err
+	}
+	staged := 0
+	for _, file := range b.files {
+		rel, err := filepath.Rel(b.rule.root, file)
+		if err != nil {
+			return err
+		}
+		ok, err := stageFile(b.rule.root, staging, rel)
+		if err != nil {
+			return err
+		}
+		if ok {
+			staged++
+		}
+	}
+	if staged == 0 {
+		return nil
+	}
+	return copier.Copy(ctx, composePath, projectName, service, staging+string(filepath.Separator)+".", b.rule.Target)
+}
+
+// stageFile copies root/rel to staging/rel, creating its directories with
+// the modes they have under root. A file removed since the change was seen
+// is skipped, and ok is false.
+func stageFile(root, staging, rel string) (ok bool, err error) {
+	src, err := os.Open(filepath.Join(root, rel))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	dir := "."
+	for _, part := range strings.Split(filepath.Dir(rel), string(filepath.Separator)) {
+		if part == "." {
+			continue
+		}
+		dir = filepath.Join(dir, part)
+		dirInfo, err := os.Stat(filepath.Join(root, dir))
+		if err != nil {
+			return false, err
+		}
+		if err := os.Mkdir(filepath.Join(staging, dir), dirInfo.Mode().Perm()); err != nil && !errors.Is(err, os.ErrExist) {
+			return false, err
+		}
+	}
+
+	dst, err := os.OpenFile(filepath.Join(staging, rel), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return false, err
+	}
+	return true, dst.Close()
+}
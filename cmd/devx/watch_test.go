@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dever-labs/devx/internal/config"
+	devxruntime "github.com/dever-labs/devx/internal/runtime"
+	"github.com/dever-labs/devx/internal/watch"
+)
+
+// syncRuntime records the commands watch mode runs.
+type syncRuntime struct {
+	waveRuntime
+	calls []string
+}
+
+func (r *syncRuntime) Up(ctx context.Context, composePath, projectName string, opts devxruntime.UpOptions) error {
+	call := "up " + strings.Join(opts.Services, ",")
+	if opts.Build {
+		call += " --build"
+	}
+	if opts.ForceRecreate {
+		call += " --force-recreate"
+	}
+	r.calls = append(r.calls, call)
+	return r.waveRuntime.Up(ctx, composePath, projectName, opts)
+}
+
+func (r *syncRuntime) Exec(_ context.Context, _, _, service string, opts devxruntime.ExecOptions) (int, error) {
+	r.calls = append(r.calls, "exec "+service+" "+strings.Join(opts.Cmd, " "))
+	return 0, nil
+}
+
+// Copy records the files under src, relative to it.
+func (r *syncRuntime) Copy(_ context.Context, _, _, service, src, dst string) error {
+	var files []string
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(src, p)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	r.calls = append(r.calls, "cp "+strings.Join(files, ",")+" "+service+":"+dst)
+	return err
+}
+
+func TestServiceWatches(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"api/src/main.go", "api/package.json", "api/.dockerignore", "worker/main.go"} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(f)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, f), []byte("node_modules\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := &config.Manifest{Dir: dir}
+	prof := &config.Profile{
+		Services: map[string]config.Service{
+			"api": {Build: &config.Build{Context: "api"}, Watch: []config.WatchRule{
+				{Path: "api/src", Action: config.WatchSync, Target: "/app/src", Ignore: []string{"*_test.go"}},
+				{Path: "api/package.json", Action: config.WatchRebuild},
+			}},
+			"worker": {Build: &config.Build{Context: "worker"}},
+			"db":     {Image: "postgres"},
+		},
+	}
+	watches, err := newServiceWatches(m, prof)
+	if err != nil {
+		t.Fatalf("newServiceWatches failed: %v", err)
+	}
+	if len(watches) != 2 || watches[0].service != "api" || watches[1].service != "worker" {
+		t.Fatalf("expected api and worker to be watched, got %+v", watches)
+	}
+	if r := watches[1].rules; len(r) != 1 || r[0].Action != config.WatchRebuild {
+		t.Fatalf("expected worker to rebuild on changes, got %+v", r)
+	}
+
+	api := watches[0]
+	rt := &syncRuntime{}
+	src := filepath.Join(dir, "api", "src")
+	if api.rules[0].matches(filepath.Join(src, "node_modules", "x", "index.js")) {
+		t.Fatal("expected nested node_modules to be ignored by default")
+	}
+	if err := os.MkdirAll(filepath.Join(src, "routes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "routes", "users.go"), []byte("package routes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changes := []watch.Change{
+		{Path: filepath.Join(src, "main.go")},
+		{Path: filepath.Join(src, "handlers", "old.go"), Removed: true},
+		{Path: filepath.Join(src, "main_test.go")},
+		{Path: filepath.Join(src, "routes", "users.go")},
+		{Path: filepath.Join(src, "handlers", "new.go"), Removed: true},
+	}
+	applyChanges(context.Background(), rt, "", "my-app", prof, api, changes)
+	want := []string{
+		"exec api rm -rf /app/src/handlers/old.go /app/src/handlers/new.go",
+		"cp main.go,routes/users.go api:/app/src",
+	}
+	if !reflect.DeepEqual(rt.calls, want) {
+		t.Fatalf("got %v, want %v", rt.calls, want)
+	}
+
+	// A change matching a rebuild rule rebuilds instead of syncing.
+	rt = &syncRuntime{}
+	changes = append(changes, watch.Change{Path: filepath.Join(dir, "api", "package.json")})
+	applyChanges(context.Background(), rt, "", "my-app", prof, api, changes)
+	if !reflect.DeepEqual(rt.calls, []string{"up api --build"}) {
+		t.Fatalf("expected a rebuild, got %v", rt.calls)
+	}
+}
@@ -3,6 +3,7 @@ package compose
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/dever-labs/devx/internal/config"
@@ -15,25 +16,78 @@ type File struct {
 	Services map[string]Service `yaml:"services"`
 	Networks map[string]Network `yaml:"networks,omitempty"`
 	Volumes  map[string]Volume  `yaml:"volumes,omitempty"`
+	Secrets  map[string]Secret  `yaml:"secrets,omitempty"`
 }
 
 type Network struct{}
 
+// Secret is a top-level compose secret backed by a file that devx writes
+// under .devx/secrets before starting the stack.
+type Secret struct {
+	File string `yaml:"file"`
+}
+
 type Volume struct{}
 
 type Service struct {
 	Image       string            `yaml:"image,omitempty"`
 	Build       *Build            `yaml:"build,omitempty"`
-	Ports       []string          `yaml:"ports,omitempty"`
+	Ports       []Port            `yaml:"ports,omitempty"`
 	Environment map[string]string `yaml:"environment,omitempty"`
 	Command     []string          `yaml:"command,omitempty"`
 	WorkingDir  string            `yaml:"working_dir,omitempty"`
 	Volumes     []string          `yaml:"volumes,omitempty"`
-	DependsOn   []string          `yaml:"depends_on,omitempty"`
+	DependsOn   DependsOn         `yaml:"depends_on,omitempty"`
 	Labels      map[string]string `yaml:"labels,omitempty"`
 	Healthcheck *Healthcheck      `yaml:"healthcheck,omitempty"`
 	Networks    []string          `yaml:"networks,omitempty"`
 	Privileged  bool              `yaml:"privileged,omitempty"`
+	Secrets     []string          `yaml:"secrets,omitempty"`
+}
+
+// DependsOn maps each dependency to the condition compose waits for before
+// starting the service.
+type DependsOn map[string]Dependency
+
+type Dependency struct {
+	Condition string `yaml:"condition"`
+}
+
+const (
+	ConditionStarted = "service_started"
+	ConditionHealthy = "service_healthy"
+)
+
+// Port is a compose port entry. Named ports use the long syntax, the only
+// one that carries a name; everything else uses the short syntax.
+type Port struct {
+	Short string
+	Long  *LongPort
+}
+
+// LongPort is a port in compose's long syntax.
+type LongPort struct {
+	Name      string `yaml:"name,omitempty"`
+	Target    int    `yaml:"target"`
+	Published string `yaml:"published,omitempty"`
+	HostIP    string `yaml:"host_ip,omitempty"`
+	Protocol  string `yaml:"protocol,omitempty"`
+}
+
+func (p Port) MarshalYAML() (any, error) {
+	if p.Long != nil {
+		return p.Long, nil
+	}
+	return p.Short, nil
+}
+
+func (p *Port) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		p.Short = node.Value
+		return nil
+	}
+	p.Long = &LongPort{}
+	return node.Decode(p.Long)
 }
 
 type Build struct {
@@ -42,9 +96,11 @@ type Build struct {
 }
 
 type Healthcheck struct {
-	Test     []string `yaml:"test"`
-	Interval string   `yaml:"interval,omitempty"`
-	Retries  int      `yaml:"retries,omitempty"`
+	Test        []string `yaml:"test"`
+	Interval    string   `yaml:"interval,omitempty"`
+	Timeout     string   `yaml:"timeout,omitempty"`
+	StartPeriod string   `yaml:"start_period,omitempty"`
+	Retries     int      `yaml:"retries,omitempty"`
 }
 
 type RewriteOptions struct {
@@ -54,6 +110,9 @@ type RewriteOptions struct {
 	// dep name. If a fragment is present for a dep, its fields are merged into
 	// the rendered compose service (e.g. a healthcheck).
 	DepFragments map[string]*DepFragment
+	// Instance names the devx instance the file is rendered for, recorded
+	// in each service's devx.instance label. Empty for the default one.
+	Instance string
 }
 
 // DepFragment is the optional provider-contributed configuration for a dep
@@ -80,12 +139,13 @@ func Render(manifest *config.Manifest, profileName string, profile *config.Profi
 
 		svc := Service{
 			Image:       image,
-			Environment: dep.Env,
-			Ports:       dep.Ports,
-			DependsOn:   nil,
+			Environment: escapeEnv(dep.Env),
+			Ports:       composePorts(dep.Ports),
 			Labels:      labels(manifest, profileName, name),
 			Networks:    []string{"devx_default"},
+			Secrets:     dep.Secrets,
 		}
+		addSourceLabel(svc.Labels, manifest, "profiles."+profileName+".deps."+name)
 
 		if dep.Volume != "" {
 			svc.Volumes = []string{dep.Volume}
@@ -122,36 +182,49 @@ func Render(manifest *config.Manifest, profileName string, profile *config.Profi
 		svc := profile.Services[name]
 		service := Service{
 			Image:       rewriteImage(svc.Image, rewrite),
-			Ports:       svc.Ports,
-			Environment: svc.Env,
-			Command:     svc.Command,
+			Ports:       composePorts(svc.Ports),
+			Environment: escapeEnv(svc.Env),
+			Command:     escapeList(svc.Command),
 			WorkingDir:  svc.Workdir,
 			Volumes:     svc.Mount,
-			DependsOn:   svc.DependsOn,
+			DependsOn:   dependsOn(svc.DependsOn, profile, rewrite),
 			Labels:      labels(manifest, profileName, name),
 			Networks:    []string{"devx_default"},
+			Secrets:     svc.Secrets,
 		}
+		addSourceLabel(service.Labels, manifest, "profiles."+profileName+".services."+name)
 
 		if svc.Build != nil {
 			service.Build = &Build{Context: svc.Build.Context, Dockerfile: svc.Build.Dockerfile}
 			service.Image = ""
 		}
 
-		if svc.Health != nil && svc.Health.HttpGet != "" {
-			service.Healthcheck = &Healthcheck{
-				Test: []string{"CMD-SHELL", fmt.Sprintf("wget -qO- %s >/dev/null 2>&1 || exit 1", svc.Health.HttpGet)},
-			}
-			if svc.Health.Interval != "" {
-				service.Healthcheck.Interval = svc.Health.Interval
-			}
-			if svc.Health.Retries > 0 {
-				service.Healthcheck.Retries = svc.Health.Retries
+		if svc.Health != nil && svc.Health.ProbeKind() != "" {
+			hc, err := healthcheck(svc)
+			if err != nil {
+				return "", fmt.Errorf("service '%s' health: %w", name, err)
 			}
+			service.Healthcheck = hc
 		}
 
 		file.Services[name] = service
 	}
 
+	// Secret values never appear in the compose file; each one is mounted
+	// from a file next to it that devx writes at up time.
+	for _, name := range config.ProfileSecrets(profile) {
+		if file.Secrets == nil {
+			file.Secrets = map[string]Secret{}
+		}
+		file.Secrets[name] = Secret{File: SecretFile(name)}
+	}
+
+	if rewrite.Instance != "" {
+		for _, svc := range file.Services {
+			svc.Labels["devx.instance"] = rewrite.Instance
+		}
+	}
+
 	data, err := yaml.Marshal(file)
 	if err != nil {
 		return "", err
@@ -160,6 +233,12 @@ func Render(manifest *config.Manifest, profileName string, profile *config.Profi
 	return string(data), nil
 }
 
+// SecretFile is the path of a secret's value file, relative to the rendered
+// compose file in .devx.
+func SecretFile(name string) string {
+	return "./secrets/" + name
+}
+
 func labels(manifest *config.Manifest, profileName string, name string) map[string]string {
 	return map[string]string{
 		"devx.project": manifest.Project.Name,
@@ -168,6 +247,145 @@ func labels(manifest *config.Manifest, profileName string, name string) map[stri
 	}
 }
 
+func composePorts(ports []config.Port) []Port {
+	var out []Port
+	for _, p := range ports {
+		if p.Name == "" {
+			out = append(out, Port{Short: p.String()})
+			continue
+		}
+		long := &LongPort{Name: p.Name, Target: p.Container.First, HostIP: p.HostIP, Protocol: p.Protocol}
+		if !p.Host.IsZero() {
+			long.Published = p.Host.String()
+		}
+		out = append(out, Port{Long: long})
+	}
+	return out
+}
+
+// escapeEnv returns env with every $ doubled. Values have already been
+// interpolated by devx, so compose must not try to interpolate them again.
+func escapeEnv(env map[string]string) map[string]string {
+	if env == nil {
+		return nil
+	}
+	out := make(map[string]string, len(env))
+	for k, v := range env {
+		out[k] = strings.ReplaceAll(v, "$", "$$")
+	}
+	return out
+}
+
+// dependsOn waits for each dependency to be healthy when it has a
+// healthcheck — a service health probe or a provider-contributed dep
+// healthcheck — and for it to have started otherwise.
+func dependsOn(names []string, profile *config.Profile, rewrite RewriteOptions) DependsOn {
+	if len(names) == 0 {
+		return nil
+	}
+	out := DependsOn{}
+	for _, name := range names {
+		out[name] = Dependency{Condition: ConditionStarted}
+		if HasHealthcheck(profile, name, rewrite.DepFragments) {
+			out[name] = Dependency{Condition: ConditionHealthy}
+		}
+	}
+	return out
+}
+
+// HasHealthcheck reports whether the service or dep called name gets a
+// compose healthcheck.
+func HasHealthcheck(profile *config.Profile, name string, fragments map[string]*DepFragment) bool {
+	if svc, ok := profile.Services[name]; ok {
+		return svc.Health != nil && svc.Health.ProbeKind() != ""
+	}
+	frag := fragments[name]
+	return frag != nil && frag.Healthcheck != nil
+}
+
+// healthcheck renders a service's health probe as a compose healthcheck.
+// It runs inside the container, so HTTP URLs on published host ports are
+// rewritten to the container port. The schedule matches what devx up waits
+// for and what the k8s probes use.
+func healthcheck(svc config.Service) (*Healthcheck, error) {
+	h := svc.Health
+	var test []string
+	switch h.ProbeKind() {
+	case "http":
+		url, err := h.HTTP.ContainerURL(svc.Ports)
+		if err != nil {
+			return nil, err
+		}
+		tls := ""
+		if strings.HasPrefix(url, "https:") {
+			tls = " --no-check-certificate"
+		}
+		script := fmt.Sprintf("wget -qO-%s %s >/dev/null 2>&1 || exit 1", tls, url)
+		if len(h.HTTP.Status) > 0 {
+			// wget fails on non-2xx codes, so read the status line it
+			// prints with -S instead.
+			codes := make([]string, len(h.HTTP.Status))
+			for i, code := range h.HTTP.Status {
+				codes[i] = strconv.Itoa(code)
+			}
+			script = fmt.Sprintf(`code=$(wget -S -O /dev/null%s %s 2>&1 | awk '/^ *HTTP\//{c=$2} END{print c}'); case "$code" in %s) exit 0;; esac; exit 1`,
+				tls, url, strings.Join(codes, "|"))
+		}
+		test = []string{"CMD-SHELL", script}
+	case "tcp":
+		port, ok := config.ProbePort(svc.Ports, h.TCP.Port)
+		if !ok {
+			return nil, fmt.Errorf("port '%s' is not declared", h.TCP.Port)
+		}
+		test = []string{"CMD-SHELL", fmt.Sprintf("nc -z localhost %d || exit 1", port)}
+	case "exec":
+		test = append([]string{"CMD"}, h.Exec.Command...)
+	case "grpc":
+		port, ok := config.ProbePort(svc.Ports, h.GRPC.Port)
+		if !ok {
+			return nil, fmt.Errorf("port '%s' is not declared", h.GRPC.Port)
+		}
+		test = []string{"CMD", "grpc_health_probe", fmt.Sprintf("-addr=localhost:%d", port)}
+		if h.GRPC.Service != "" {
+			test = append(test, "-service="+h.GRPC.Service)
+		}
+	}
+
+	timing := h.Timing()
+	hc := &Healthcheck{
+		Test:     escapeList(test),
+		Interval: timing.Interval.String(),
+		Timeout:  timing.Timeout.String(),
+		Retries:  timing.Retries,
+	}
+	if timing.StartPeriod > 0 {
+		hc.StartPeriod = timing.StartPeriod.String()
+	}
+	return hc, nil
+}
+
+func escapeList(items []string) []string {
+	if items == nil {
+		return nil
+	}
+	out := make([]string, len(items))
+	for i, item := range items {
+		out[i] = strings.ReplaceAll(item, "$", "$$")
+	}
+	return out
+}
+
+// addSourceLabel records which manifest file declared a service when the
+// manifest was assembled from several files via include.
+func addSourceLabel(l map[string]string, manifest *config.Manifest, path string) {
+	if !manifest.MultiFile() {
+		return
+	}
+	if o, ok := manifest.OriginOf(path); ok {
+		l["devx.source"] = o.String()
+	}
+}
+
 func rewriteImage(image string, opts RewriteOptions) string {
 	if image == "" {
 		return image
@@ -19,7 +19,7 @@ func TestRenderCompose(t *testing.T) {
 		Services: map[string]config.Service{
 			"api": {
 				Image:     "nginx:alpine",
-				Ports:     []string{"8080:80"},
+				Ports:     []config.Port{{Host: config.PortRange{First: 8080}, Container: config.PortRange{First: 80}}},
 				DependsOn: []string{"db"},
 			},
 		},
@@ -29,7 +29,7 @@ func TestRenderCompose(t *testing.T) {
 				Env: map[string]string{
 					"POSTGRES_PASSWORD": "postgres",
 				},
-				Ports:  []string{"5432:5432"},
+				Ports:  []config.Port{{Host: config.PortRange{First: 5432}, Container: config.PortRange{First: 5432}}},
 				Volume: "db-data:/var/lib/postgresql/data",
 			},
 		},
@@ -52,7 +52,8 @@ services:
     ports:
       - "8080:80"
     depends_on:
-      - db
+      db:
+        condition: service_started
     labels:
       devx.project: my-app
       devx.profile: local
@@ -88,3 +89,226 @@ volumes:
 		t.Fatalf("compose output mismatch\nGot: %#v\nWant: %#v", got, want)
 	}
 }
+
+func TestRenderCompose_SourceLabel(t *testing.T) {
+	manifest := &config.Manifest{
+		Version: 1,
+		Project: config.Project{Name: "my-app", DefaultProfile: "local"},
+		Origins: map[string]config.Origin{
+			"profiles.local.services.api": {File: "devx.yaml", Line: 9},
+			"profiles.local.services.job": {File: "teams/jobs.yaml", Line: 4},
+		},
+	}
+	profile := &config.Profile{
+		Services: map[string]config.Service{
+			"api": {Image: "nginx:alpine"},
+			"job": {Image: "busybox"},
+		},
+	}
+
+	out, err := Render(manifest, "local", profile, RewriteOptions{}, false)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	var got File
+	if err := yaml.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("unmarshal output failed: %v", err)
+	}
+	if src := got.Services["job"].Labels["devx.source"]; src != "teams/jobs.yaml:4" {
+		t.Fatalf("expected devx.source label teams/jobs.yaml:4, got %q", src)
+	}
+}
+
+func TestRenderCompose_InstanceLabel(t *testing.T) {
+	manifest := &config.Manifest{Version: 1, Project: config.Project{Name: "my-app", DefaultProfile: "local"}}
+	profile := &config.Profile{
+		Services: map[string]config.Service{"api": {Image: "nginx:alpine"}},
+		Deps:     map[string]config.Dep{"db": {Image: "postgres:16"}},
+	}
+
+	out, err := Render(manifest, "local", profile, RewriteOptions{Instance: "feature"}, true)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	var got File
+	if err := yaml.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("unmarshal output failed: %v", err)
+	}
+	for name, svc := range got.Services {
+		if svc.Labels["devx.instance"] != "feature" {
+			t.Fatalf("expected %s to carry devx.instance=feature, got %v", name, svc.Labels)
+		}
+	}
+
+	out, _ = Render(manifest, "local", profile, RewriteOptions{}, false)
+	if err := yaml.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("unmarshal output failed: %v", err)
+	}
+	if _, ok := got.Services["api"].Labels["devx.instance"]; ok {
+		t.Fatal("expected no devx.instance label for the default instance")
+	}
+}
+
+func TestRenderCompose_Secrets(t *testing.T) {
+	manifest := &config.Manifest{
+		Version: 1,
+		Project: config.Project{Name: "my-app", DefaultProfile: "local"},
+		Secrets: map[string]config.Secret{"db_password": {Env: "DB_PASSWORD"}},
+	}
+	profile := &config.Profile{
+		Deps: map[string]config.Dep{
+			"db": {
+				Image:   "postgres:16",
+				Env:     map[string]string{"POSTGRES_PASSWORD_FILE": "/run/secrets/db_password"},
+				Secrets: []string{"db_password"},
+			},
+		},
+	}
+
+	out, err := Render(manifest, "local", profile, RewriteOptions{}, false)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	var got File
+	if err := yaml.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("unmarshal output failed: %v", err)
+	}
+	if f := got.Secrets["db_password"].File; f != "./secrets/db_password" {
+		t.Fatalf("expected top-level secret file ./secrets/db_password, got %q", f)
+	}
+	if s := got.Services["db"].Secrets; !reflect.DeepEqual(s, []string{"db_password"}) {
+		t.Fatalf("expected db to mount db_password, got %v", s)
+	}
+}
+
+func TestRenderCompose_NamedPorts(t *testing.T) {
+	manifest := &config.Manifest{
+		Version: 2,
+		Project: config.Project{Name: "my-app", DefaultProfile: "local"},
+	}
+	profile := &config.Profile{
+		Services: map[string]config.Service{
+			"api": {
+				Image: "nginx:alpine",
+				Ports: []config.Port{
+					{Name: "http", Host: config.PortRange{First: 8080}, Container: config.PortRange{First: 80}, HostIP: "127.0.0.1"},
+					{Host: config.PortRange{First: 9000, Last: 9001}, Container: config.PortRange{First: 9000, Last: 9001}, Protocol: "udp"},
+				},
+			},
+		},
+	}
+
+	out, err := Render(manifest, "local", profile, RewriteOptions{}, false)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	var got File
+	if err := yaml.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("unmarshal output failed: %v", err)
+	}
+	want := []Port{
+		{Long: &LongPort{Name: "http", Target: 80, Published: "8080", HostIP: "127.0.0.1"}},
+		{Short: "9000-9001:9000-9001/udp"},
+	}
+	if ports := got.Services["api"].Ports; !reflect.DeepEqual(ports, want) {
+		t.Fatalf("unexpected ports: %+v", ports)
+	}
+}
+
+func TestRenderCompose_Healthchecks(t *testing.T) {
+	manifest := &config.Manifest{
+		Version: 2,
+		Project: config.Project{Name: "my-app", DefaultProfile: "local"},
+	}
+	web := []config.Port{{Name: "http", Host: config.PortRange{First: 8080}, Container: config.PortRange{First: 80}}}
+	profile := &config.Profile{
+		Services: map[string]config.Service{
+			"api": {Image: "api", Ports: web, Health: &config.Health{
+				HTTP:     &config.HTTPProbe{URL: "http://localhost:8080/health"},
+				Interval: "10s",
+				Retries:  3,
+			}},
+			"auth": {Image: "auth", Ports: web, Health: &config.Health{
+				HTTP:        &config.HTTPProbe{Port: "http", Path: "/login", Status: []int{200, 302}},
+				StartPeriod: "1m",
+			}},
+			"cache": {Image: "redis", Health: &config.Health{TCP: &config.TCPProbe{Port: "6379"}}},
+			"db":    {Image: "postgres", Health: &config.Health{Exec: &config.ExecProbe{Command: []string{"pg_isready", "-U", "$POSTGRES_USER"}}}},
+		},
+	}
+
+	out, err := Render(manifest, "local", profile, RewriteOptions{}, false)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	var got File
+	if err := yaml.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("unmarshal output failed: %v", err)
+	}
+
+	want := map[string]Healthcheck{
+		"api": {
+			Test:     []string{"CMD-SHELL", "wget -qO- http://localhost:80/health >/dev/null 2>&1 || exit 1"},
+			Interval: "10s", Timeout: "2s", Retries: 3,
+		},
+		"auth": {
+			Test:     []string{"CMD-SHELL", `code=$$(wget -S -O /dev/null http://localhost:80/login 2>&1 | awk '/^ *HTTP\//{c=$$2} END{print c}'); case "$$code" in 200|302) exit 0;; esac; exit 1`},
+			Interval: "5s", Timeout: "2s", StartPeriod: "1m0s", Retries: 24,
+		},
+		"cache": {
+			Test:     []string{"CMD-SHELL", "nc -z localhost 6379 || exit 1"},
+			Interval: "5s", Timeout: "2s", Retries: 24,
+		},
+		"db": {
+			Test:     []string{"CMD", "pg_isready", "-U", "$$POSTGRES_USER"},
+			Interval: "5s", Timeout: "2s", Retries: 24,
+		},
+	}
+	for name, hc := range want {
+		if g := got.Services[name].Healthcheck; g == nil || !reflect.DeepEqual(*g, hc) {
+			t.Errorf("%s: got %+v, want %+v", name, g, hc)
+		}
+	}
+}
+
+func TestRenderCompose_DependsOnConditions(t *testing.T) {
+	manifest := &config.Manifest{
+		Version: 2,
+		Project: config.Project{Name: "my-app", DefaultProfile: "local"},
+	}
+	profile := &config.Profile{
+		Services: map[string]config.Service{
+			"api":    {Image: "api", DependsOn: []string{"auth", "db", "cache", "worker"}},
+			"auth":   {Image: "auth", Health: &config.Health{TCP: &config.TCPProbe{Port: "80"}}},
+			"worker": {Image: "worker"},
+		},
+		Deps: map[string]config.Dep{
+			"db":    {Image: "postgres"},
+			"cache": {Image: "redis"},
+		},
+	}
+	rewrite := RewriteOptions{DepFragments: map[string]*DepFragment{
+		"db": {Healthcheck: &Healthcheck{Test: []string{"CMD", "pg_isready"}}},
+	}}
+
+	out, err := Render(manifest, "local", profile, rewrite, false)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	var got File
+	if err := yaml.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("unmarshal output failed: %v", err)
+	}
+	want := DependsOn{
+		"auth":   {Condition: ConditionHealthy},
+		"db":     {Condition: ConditionHealthy},
+		"cache":  {Condition: ConditionStarted},
+		"worker": {Condition: ConditionStarted},
+	}
+	if d := got.Services["api"].DependsOn; !reflect.DeepEqual(d, want) {
+		t.Fatalf("unexpected depends_on: %+v", d)
+	}
+}
@@ -80,12 +80,12 @@ func telemetryCompose(manifest *config.Manifest, profileName string, rewrite Rew
 	alloyName := telemetryName + "-alloy"
 	cAdvisorName := telemetryName + "-cadvisor"
 
-	grafanaPorts := []string{"3000"}
+	grafanaPorts := []Port{{Short: "3000"}}
 
 	services[grafanaName] = Service{
 		Image:     rewriteImage(grafanaImage, rewrite),
 		Ports:     grafanaPorts,
-		DependsOn: []string{lokiName, promName},
+		DependsOn: DependsOn{lokiName: {Condition: ConditionStarted}, promName: {Condition: ConditionStarted}},
 		Labels:    labels(manifest, profileName, grafanaName),
 		Networks:  []string{"devx_default"},
 		Environment: map[string]string{
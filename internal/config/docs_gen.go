@@ -0,0 +1,103 @@
+// Code generated by gen_docs.go; DO NOT EDIT.
+
+package config
+
+var typeDocs = map[string]string{
+	"AIConfig":         "AIConfig holds optional AI provider settings used by 'devx export' and automatic connection string detection via the dep connect block. Credentials are read from environment variables:\n\n\topenai:       OPENAI_API_KEY\n\tanthropic:    ANTHROPIC_API_KEY\n\tazure-openai: AZURE_OPENAI_KEY\n\tollama:       no auth required",
+	"ConnectEntry":     "ConnectEntry declares a service that a dep should inject connection\nenvironment variables into. Env values support template variables:\n  - ${host}   — the dep's service name within the compose network\n  - ${port}   — the first container-side port declared in dep.ports\n  - ${port.<name>} — the container-side port of the dep port with that name\n  - ${hostPort}, ${hostPort.<name>} — the same ports as published on the\n    host, after any move by ports.auto\n  - ${<KEY>}  — any key from the dep's own env block (e.g. ${POSTGRES_PASSWORD})\n\nIf Env is omitted and devx.yaml has an ai block, devx calls the LLM to detect appropriate env var names by scanning the service's build context.",
+	"ContainerRuntime": "ContainerRuntime selects and configures a container runtime. A plain string is shorthand for its name.",
+	"Dep":              "Dep is a third-party dependency (database, cache, broker, …) that devx runs as a container. The project fully controls which image to run via Image.\n\nWhen Kind is set, devx downloads a provider plugin that contributes behavioural logic (health checks, compose fragments, connection string templates). Source defaults to \"devx-labs/<kind>\" if omitted. Version follows the major-version convention: major = dep major version (e.g. \"16.1.0\" = provider for PostgreSQL 16, provider patch release 1.0).\n\nThe Connect block lists services that should have connection environment variables injected automatically. Each entry can supply an explicit Env mapping using ${host}, ${port}, or any dep env key as template variables. If Env is omitted and AI is configured in the manifest, devx scans the service source directory and uses the LLM to detect the correct env var names.",
+	"ExecProbe":        "ExecProbe runs a command inside the container and passes when it exits 0.",
+	"GRPCProbe":        "GRPCProbe calls the standard grpc.health.v1 Health service. Compose runs it with grpc_health_probe, which must be installed in the image.",
+	"HTTPProbe":        "HTTPProbe requests a URL and passes on a 2xx response, or on one of Status.",
+	"Health":           "Health describes how to tell that a service is ready. The same check is rendered as a compose healthcheck and as Kubernetes probes, and `devx up` waits for it to pass. Set exactly one of http, tcp, exec or grpc.",
+	"Hook":             "Hook is a single lifecycle step. Exactly one of Exec or Run must be set.\n\n\texec: runs a command inside an already-running container via `docker compose exec`.\n\t      Service is required.\n\trun:  runs a command on the host via the system shell.\n\t      Set background: true to start the process without waiting for it to exit.\n\t      devx up will stream its output (prefixed with name) and block until it stops.\n\t      Use name to label output lines; defaults to the run command.",
+	"Hooks":            "Hooks defines commands to run at lifecycle points around devx up/down.",
+	"Install":          "Install holds platform-specific install commands.",
+	"Manifest":         "Manifest is the root of devx.yaml.",
+	"Port":             "Port is a port a service or dep exposes. It is written either in the short form \"[hostIP:][hostPort:]containerPort[/protocol]\" or as a mapping.",
+	"Secret":           "Secret declares where a sensitive value comes from. Exactly one source must be set. Services and deps list the secrets they need under their own secrets key; each is mounted read-only at /run/secrets/<name> (compose secrets, or a Kubernetes Secret volume) and never rendered as an env value.\n\n\tenv:     read from an environment variable on the host\n\tfile:    read from a file, relative to devx.yaml\n\tcommand: output of a shell command, e.g. \"pass show my-app/db\" or\n\t         \"sops -d --extract '[\\\"db\\\"]' secrets.enc.yaml\"\n\tstore:   key in the local encrypted store managed by `devx secrets set`",
+	"Service":          "Service is an application container, run from an image or built from local source.",
+	"SetupStep":        "SetupStep is a host-side command run as part of `devx setup`. Steps run in declaration order. RunOnce steps are skipped if their command hash matches a previous successful run stored in .devx/setup-state.json.",
+	"TCPProbe":         "TCPProbe passes when the port accepts a connection.",
+	"Task":             "Task is a one-off command run with `devx run`: on the host through the system shell, or in a fresh container of a service or dep that is removed when the command exits.",
+	"Tool":             "Tool declares a required SDK, runtime, or CLI tool for the project. devx doctor checks each tool using its Check command and reports missing tools. devx setup (or devx doctor --fix) installs missing tools using the Install block.",
+	"WatchRule":        "WatchRule tells `devx up --watch` what to do when files under Path change. When several rules match a change, the most disruptive action wins: rebuild, then restart, then sync.",
+}
+
+var fieldDocs = map[string]string{
+	"AIConfig.BaseURL":         "override endpoint (e.g. Ollama or Azure)",
+	"AIConfig.Model":           "Model is the model identifier, e.g. gpt-4o-mini, claude-3-5-haiku-latest, llama3.2.",
+	"AIConfig.Provider":        "openai | anthropic | ollama | azure-openai",
+	"Build.Context":            "Context is the build context directory, relative to devx.yaml.",
+	"Build.Dockerfile":         "Dockerfile is relative to Context. Defaults to Dockerfile.",
+	"ConnectEntry.Env":         "Env maps env var names to templates such as \"postgres://postgres:${POSTGRES_PASSWORD}@${host}:${port}/app\".",
+	"ConnectEntry.Service":     "Service is the service to inject connection env vars into.",
+	"ContainerRuntime.Binary":  "Binary is the runtime's CLI when it is not the one on PATH.",
+	"ContainerRuntime.Context": "Context is the Docker context to use. docker only.",
+	"ContainerRuntime.Host":    "Host is the daemon's address, as in DOCKER_HOST. docker and docker-engine only.",
+	"ContainerRuntime.Name":    "Name is the runtime: docker, podman, nerdctl for containerd, or docker-engine to talk to the Docker daemon's API without the CLI.",
+	"Dep.Image":                "Image is the image to run. Optional when Kind is set, in which case the provider's default image is used.",
+	"Dep.Kind":                 "Kind is the provider type, e.g. postgres or redis.",
+	"Dep.Secrets":              "Secrets names entries of the top-level secrets block to mount at /run/secrets/<name>, e.g. for POSTGRES_PASSWORD_FILE.",
+	"Dep.Source":               "Source is the GitHub org/name of the provider. Defaults to devx-labs/<kind>.",
+	"Dep.Version":              "Version is the provider version; required when Kind is set.",
+	"Dep.Volume":               "Volume is a single named volume as \"volumeName:containerPath\".",
+	"GRPCProbe.Port":           "Port is the container port, as a number or a port name.",
+	"GRPCProbe.Service":        "Service is the service name sent in the check. Empty checks the server as a whole.",
+	"HTTPProbe.Path":           "Path is requested on Port. Defaults to /.",
+	"HTTPProbe.Port":           "Port is the container port to request, as a number or a port name.",
+	"HTTPProbe.Scheme":         "Scheme is used with Port. Defaults to http.",
+	"HTTPProbe.Status":         "Status lists the response codes that pass. Defaults to any 2xx.",
+	"HTTPProbe.URL":            "URL is requested from the host, e.g. http://localhost:8080/health. Inside the container a localhost URL on a published host port is requested on the container port instead. Set either URL or Port.",
+	"Health.Interval":          "Interval is the time between checks, e.g. 5s. Defaults to 5s.",
+	"Health.Retries":           "Retries is how many consecutive failed checks after StartPeriod mark the service unhealthy. Defaults to as many as fit in two minutes.",
+	"Health.StartPeriod":       "StartPeriod gives the service time to start: failed checks during it do not count against Retries.",
+	"Health.SuccessThreshold":  "SuccessThreshold is how many consecutive passing checks mark the service healthy. Defaults to 1.",
+	"Health.Timeout":           "Timeout bounds a single check. Defaults to 2s.",
+	"Hook.Exec":                "Exec is the command to run inside Service (e.g. \"migrate up\").",
+	"Hook.Run":                 "Run is a host-side shell command (e.g. \"./scripts/seed.sh\").",
+	"Manifest.Include":         "Include lists manifest fragments, as paths or globs relative to the including file, whose profiles, tools, setup steps and tasks are merged into this manifest. Defining the same service, dep, tool, step or task twice is an error.",
+	"Manifest.Ports":           "Ports controls what `devx up` does when a host port is already in use.",
+	"Manifest.Profiles":        "Profiles maps profile names (local, ci, k8s, …) to the environment each one describes.",
+	"Manifest.Secrets":         "Secrets declares sensitive values by name. Services and deps reference them through their own secrets list.",
+	"Manifest.Setup":           "Setup declares ordered host-side commands to run after tool installation. Use `devx setup` to execute. RunOnce steps are skipped when unchanged.",
+	"Manifest.Tasks":           "Tasks declares named one-off commands, such as migrations or test suites, run with `devx run <task>` and listed by `devx tasks`.",
+	"Manifest.Tools":           "Tools declares required SDKs, runtimes, and CLI tools for the project. Use `devx doctor` to check and `devx setup` (or `devx doctor --fix`) to install.",
+	"Manifest.Version":         "Version is the manifest format version. Version 1 files are still read; `devx migrate` rewrites them as version 2.",
+	"Port.Container":           "Container is the port the process listens on inside the container, or a range such as 8000-8010.",
+	"Port.Host":                "Host is the port published on the host. Omit to expose the port to other containers only. A range of the same size as Container maps port for port; a range with a single container port lets the runtime pick any free host port in it.",
+	"Port.HostIP":              "HostIP restricts the published port to one host address, e.g. 127.0.0.1.",
+	"Port.Name":                "Name identifies the port in connect templates (${port.<name>}) and names it in rendered Kubernetes manifests. Only available in the mapping form.",
+	"Port.Protocol":            "Protocol defaults to tcp.",
+	"PortSettings.Auto":        "Auto moves a published host port that is already in use to the next free one instead of failing. The port chosen is kept on later runs while it stays free.",
+	"Profile.ContainerRuntime": "ContainerRuntime picks the container runtime that runs a compose profile instead of the first one found. --runtime and DEVX_RUNTIME take precedence.",
+	"Profile.Extends":          "Extends names a base profile this profile inherits from. Mappings deep-merge, lists replace, and a null value removes an inherited key. Resolved at load time, so the decoded profile is already merged.",
+	"Profile.Runtime":          "Runtime selects how the profile is run: Docker Compose (default) or Kubernetes via kubectl.",
+	"Project.DefaultProfile":   "DefaultProfile is the profile used when --profile is not given.",
+	"Project.Name":             "Name is the project name, used as the Docker Compose project name.",
+	"Registry.Prefix":          "Prefix is prepended to every image, e.g. myregistry.azurecr.io. Leave empty for Docker Hub.",
+	"Service.Command":          "Command overrides the container command.",
+	"Service.DependsOn":        "DependsOn names services or deps that must start first.",
+	"Service.Image":            "Image is the image to run. Required for k8s even when Build is set.",
+	"Service.Mount":            "Mount lists bind mounts as \"hostPath:containerPath[:options]\". Not supported by the k8s runtime.",
+	"Service.Ports":            "Ports lists the ports the service listens on and, optionally, the host ports they are published as.",
+	"Service.Secrets":          "Secrets names entries of the top-level secrets block to mount at /run/secrets/<name>.",
+	"Service.Watch":            "Watch lists what `devx up --watch` does when files change. Without it, a service with build is rebuilt when its build context changes.",
+	"SetupStep.Platform":       "all | windows | linux | macos (default: all)",
+	"SetupStep.RunOnce":        "skip if hash matches last run",
+	"SetupStep.Workdir":        "working directory; defaults to cwd",
+	"TCPProbe.Port":            "Port is the container port, as a number or a port name.",
+	"Task.Command":             "Command overrides the container's command. Arguments given to `devx run` are appended to it; without it they replace the image's command. service only.",
+	"Task.DependsOn":           "DependsOn lists tasks run, in order and without arguments, before this one.",
+	"Task.Description":         "Description is shown by `devx tasks`.",
+	"Task.Env":                 "Env sets extra environment variables for the command.",
+	"Task.Run":                 "Run is a shell command run on the host, from the directory containing devx.yaml. Arguments given to `devx run` are appended to it.",
+	"Task.Service":             "Service names the service or dep whose image, environment, volumes and network the task's container gets. Set either run or service.",
+	"Tool.Check":               "shell command to verify installation",
+	"Tool.Version":             "informational, shown in doctor output",
+	"WatchRule.Action":         "Action is sync (copy changed files into the running container), restart (recreate the container) or rebuild (build the image again and recreate the container).",
+	"WatchRule.Ignore":         "Ignore lists globs, relative to Path, whose changes are ignored. The build context's .dockerignore always applies.",
+	"WatchRule.Path":           "Path is a file or directory to watch, relative to devx.yaml.",
+	"WatchRule.Target":         "Target is the container path that Path is copied to. Required for sync.",
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// resolveExtends replaces every profile that declares extends with the deep
+// merge of its base profile and its own definition:
+//
+//   - mappings (services, deps, env, build, health, …) merge key by key
+//   - lists and scalars in the child replace the inherited value
+//   - a null value (`worker: null`, `DEBUG: ~`) deletes the inherited key
+//
+// Chains are followed (staging → ci → local). Origins for inherited
+// definitions are copied from the base profile when origins is non-nil.
+func resolveExtends(root *yaml.Node, origins map[string]Origin) error {
+	profiles := mappingValue(root, "profiles")
+	if profiles == nil || profiles.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	resolved := map[string]*yaml.Node{}
+	var resolve func(name string, stack []string) (*yaml.Node, error)
+	resolve = func(name string, stack []string) (*yaml.Node, error) {
+		if n, ok := resolved[name]; ok {
+			return n, nil
+		}
+		prof := mappingValue(profiles, name)
+		ext := mappingValue(prof, "extends")
+		if ext == nil || ext.Value == "" {
+			resolved[name] = prof
+			return prof, nil
+		}
+		base := ext.Value
+		for _, s := range stack {
+			if s == base {
+				return nil, fmt.Errorf("profile extends cycle: %s -> %s", strings.Join(stack, " -> "), base)
+			}
+		}
+		if mappingValue(profiles, base) == nil {
+			return nil, fmt.Errorf("profile '%s' extends '%s' which does not exist", name, base)
+		}
+		baseNode, err := resolve(base, append(stack, base))
+		if err != nil {
+			return nil, err
+		}
+		merged := mergeNodes(cloneNode(baseNode), prof)
+		if origins != nil {
+			inheritOrigins(merged, base, name, origins)
+		}
+		resolved[name] = merged
+		return merged, nil
+	}
+
+	for i := 0; i+1 < len(profiles.Content); i += 2 {
+		name := profiles.Content[i].Value
+		merged, err := resolve(name, []string{name})
+		if err != nil {
+			return err
+		}
+		profiles.Content[i+1] = merged
+	}
+	return nil
+}
+
+// mergeNodes deep-merges src into dst and returns the result. Only mapping
+// nodes are merged; any other src value replaces dst outright.
+func mergeNodes(dst, src *yaml.Node) *yaml.Node {
+	if dst == nil || dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		return cloneNode(src)
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, val := src.Content[i].Value, src.Content[i+1]
+		if isNull(val) {
+			deleteMappingKey(dst, key)
+			continue
+		}
+		setMappingValue(dst, key, mergeNodes(mappingValue(dst, key), val))
+	}
+	return dst
+}
+
+// inheritOrigins points services and deps that a profile inherited (rather
+// than redefined) at the file that declared them in the base profile.
+func inheritOrigins(merged *yaml.Node, base, name string, origins map[string]Origin) {
+	for _, section := range []string{"services", "deps"} {
+		entries := mappingValue(merged, section)
+		if entries == nil {
+			continue
+		}
+		for i := 0; i+1 < len(entries.Content); i += 2 {
+			entry := entries.Content[i].Value
+			path := "profiles." + name + "." + section + "." + entry
+			if _, ok := origins[path]; ok {
+				continue
+			}
+			if o, ok := origins["profiles."+base+"."+section+"."+entry]; ok {
+				origins[path] = o
+			}
+		}
+	}
+}
+
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null"
+}
+
+func deleteMappingKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+func cloneNode(n *yaml.Node) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	if len(n.Content) > 0 {
+		c.Content = make([]*yaml.Node, len(n.Content))
+		for i, child := range n.Content {
+			c.Content[i] = cloneNode(child)
+		}
+	}
+	return &c
+}
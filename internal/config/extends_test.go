@@ -0,0 +1,159 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const extendsManifest = `version: 1
+project:
+  name: my-app
+  defaultProfile: local
+profiles:
+  local:
+    runtime: compose
+    services:
+      api:
+        image: api:dev
+        ports: ["8080:80", "9090:90"]
+        env:
+          APP_ENV: development
+          DEBUG: "true"
+        dependsOn: [db]
+      worker:
+        image: worker:dev
+    deps:
+      db:
+        image: postgres:16
+        env:
+          POSTGRES_PASSWORD: postgres
+  ci:
+    extends: local
+    services:
+      api:
+        image: api:latest
+        ports: ["8080:80"]
+        env:
+          APP_ENV: test
+          DEBUG: null
+      worker: null
+  staging:
+    extends: ci
+    services:
+      api:
+        env:
+          APP_ENV: staging
+`
+
+func TestExtends_DeepMerge(t *testing.T) {
+	m, err := Parse([]byte(extendsManifest))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	ci, err := ProfileByName(m, "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := ci.Services["api"]
+	if api.Image != "api:latest" {
+		t.Errorf("expected overridden image, got %q", api.Image)
+	}
+	if !reflect.DeepEqual(api.Ports, []Port{{Host: PortRange{First: 8080}, Container: PortRange{First: 80}}}) {
+		t.Errorf("expected ports list to be replaced, got %v", api.Ports)
+	}
+	if !reflect.DeepEqual(api.Env, map[string]string{"APP_ENV": "test"}) {
+		t.Errorf("expected env to merge with DEBUG removed, got %v", api.Env)
+	}
+	if !reflect.DeepEqual(api.DependsOn, []string{"db"}) {
+		t.Errorf("expected dependsOn to be inherited, got %v", api.DependsOn)
+	}
+	if _, ok := ci.Services["worker"]; ok {
+		t.Error("expected worker to be removed by null")
+	}
+	if ci.Deps["db"].Image != "postgres:16" {
+		t.Errorf("expected dep db to be inherited")
+	}
+	if ci.Runtime != "compose" {
+		t.Errorf("expected runtime to be inherited, got %q", ci.Runtime)
+	}
+
+	local, _ := ProfileByName(m, "local")
+	if local.Services["api"].Env["DEBUG"] != "true" || len(local.Services) != 2 {
+		t.Error("base profile must not be modified by children")
+	}
+}
+
+func TestExtends_Chain(t *testing.T) {
+	m, err := Parse([]byte(extendsManifest))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	staging := m.Profiles["staging"]
+	api := staging.Services["api"]
+	if api.Image != "api:latest" || api.Env["APP_ENV"] != "staging" {
+		t.Errorf("unexpected staging api: %+v", api)
+	}
+	if _, ok := staging.Services["worker"]; ok {
+		t.Error("expected worker removal to carry through the chain")
+	}
+}
+
+func TestExtends_Errors(t *testing.T) {
+	cases := map[string]string{
+		"missing base": `version: 1
+profiles:
+  ci:
+    extends: nope
+`,
+		"cycle": `version: 1
+profiles:
+  a:
+    extends: b
+  b:
+    extends: a
+`,
+	}
+	for name, data := range cases {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestExtends_InheritsOriginsAcrossIncludes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml": `version: 1
+project:
+  name: my-app
+  defaultProfile: local
+include: [teams/ci.yaml]
+profiles:
+  local:
+    services:
+      api:
+        image: api:dev
+`,
+		"teams/ci.yaml": `profiles:
+  ci:
+    extends: local
+    services:
+      job:
+        image: job:ci
+`,
+	})
+
+	m, err := Load(filepath.Join(dir, "devx.yaml"))
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if _, ok := m.Profiles["ci"].Services["api"]; !ok {
+		t.Fatal("expected ci to inherit api from local")
+	}
+	o, ok := m.OriginOf("profiles.ci.services.api")
+	if !ok || !strings.HasSuffix(o.File, "devx.yaml") {
+		t.Errorf("expected inherited api to point at devx.yaml, got %+v", o)
+	}
+}
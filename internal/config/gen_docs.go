@@ -0,0 +1,160 @@
+//go:build ignore
+
+// gen_docs extracts the doc comments of the manifest types in this package
+// into docs_gen.go, so Schema can describe each field without the sources.
+//
+// Run via `go generate ./internal/config`.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const outFile = "docs_gen.go"
+
+func main() {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	typeDocs := map[string]string{}
+	fieldDocs := map[string]string{}
+	fset := token.NewFileSet()
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") || name == outFile || name == "gen_docs.go" {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok || !ts.Name.IsExported() || !hasYAMLTags(st) {
+					continue
+				}
+				doc := ts.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				if text := clean(doc); text != "" {
+					typeDocs[ts.Name.Name] = text
+				}
+				for _, field := range st.Fields.List {
+					if field.Tag != nil && strings.Contains(field.Tag.Value, `yaml:"-"`) {
+						continue
+					}
+					text := clean(field.Doc)
+					if text == "" {
+						text = clean(field.Comment)
+					}
+					if text == "" {
+						continue
+					}
+					for _, fn := range field.Names {
+						if fn.IsExported() {
+							fieldDocs[ts.Name.Name+"."+fn.Name] = text
+						}
+					}
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gen_docs.go; DO NOT EDIT.\n\npackage config\n\n")
+	writeMap(&buf, "typeDocs", typeDocs)
+	buf.WriteString("\n")
+	writeMap(&buf, "fieldDocs", fieldDocs)
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(outFile, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// hasYAMLTags reports whether st is decoded from the manifest, as opposed to
+// a helper type such as Origin or LoadOptions.
+func hasYAMLTags(st *ast.StructType) bool {
+	for _, f := range st.Fields.List {
+		if f.Tag != nil && strings.Contains(f.Tag.Value, `yaml:"`) && !strings.Contains(f.Tag.Value, `yaml:"-"`) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeMap(buf *bytes.Buffer, name string, m map[string]string) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(buf, "var %s = map[string]string{\n", name)
+	for _, k := range keys {
+		fmt.Fprintf(buf, "\t%s: %s,\n", strconv.Quote(k), strconv.Quote(m[k]))
+	}
+	buf.WriteString("}\n")
+}
+
+// clean turns a comment group into description text: wrapped lines within a
+// paragraph are joined, while indented blocks keep their line breaks.
+func clean(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	var paras []string
+	for _, para := range strings.Split(strings.TrimSpace(cg.Text()), "\n\n") {
+		lines := strings.Split(para, "\n")
+		indented := false
+		for _, l := range lines {
+			if strings.HasPrefix(l, "\t") || strings.HasPrefix(l, "  ") || strings.HasPrefix(l, "- ") {
+				indented = true
+			}
+		}
+		if indented {
+			paras = append(paras, para)
+			continue
+		}
+		paras = append(paras, joinLines(lines))
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+// joinLines joins wrapped comment lines with spaces, except after a line
+// that breaks a hyphenated word such as "deep-" / "merge".
+func joinLines(lines []string) string {
+	var b strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			if n := len(prev); !(n > 1 && prev[n-1] == '-' && unicode.IsLetter(rune(prev[n-2]))) {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(l)
+	}
+	return b.String()
+}
@@ -0,0 +1,294 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Health describes how to tell that a service is ready. The same check is
+// rendered as a compose healthcheck and as Kubernetes probes, and `devx up`
+// waits for it to pass. Set exactly one of http, tcp, exec or grpc.
+type Health struct {
+	HTTP *HTTPProbe `yaml:"http,omitempty"`
+	TCP  *TCPProbe  `yaml:"tcp,omitempty"`
+	Exec *ExecProbe `yaml:"exec,omitempty"`
+	GRPC *GRPCProbe `yaml:"grpc,omitempty"`
+	// Interval is the time between checks, e.g. 5s. Defaults to 5s.
+	Interval string `yaml:"interval"`
+	// Timeout bounds a single check. Defaults to 2s.
+	Timeout string `yaml:"timeout,omitempty"`
+	// StartPeriod gives the service time to start: failed checks during it
+	// do not count against Retries.
+	StartPeriod string `yaml:"startPeriod,omitempty"`
+	// Retries is how many consecutive failed checks after StartPeriod mark
+	// the service unhealthy. Defaults to as many as fit in two minutes.
+	Retries int `yaml:"retries"`
+	// SuccessThreshold is how many consecutive passing checks mark the
+	// service healthy. Defaults to 1.
+	SuccessThreshold int `yaml:"successThreshold,omitempty"`
+}
+
+// HTTPProbe requests a URL and passes on a 2xx response, or on one of Status.
+type HTTPProbe struct {
+	// URL is requested from the host, e.g. http://localhost:8080/health.
+	// Inside the container a localhost URL on a published host port is
+	// requested on the container port instead. Set either URL or Port.
+	URL string `yaml:"url,omitempty"`
+	// Port is the container port to request, as a number or a port name.
+	Port PortRef `yaml:"port,omitempty"`
+	// Path is requested on Port. Defaults to /.
+	Path string `yaml:"path,omitempty"`
+	// Scheme is used with Port. Defaults to http.
+	Scheme string `yaml:"scheme,omitempty" jsonschema:"enum=http|https"`
+	// Status lists the response codes that pass. Defaults to any 2xx.
+	Status []int `yaml:"status,omitempty"`
+}
+
+// TCPProbe passes when the port accepts a connection.
+type TCPProbe struct {
+	// Port is the container port, as a number or a port name.
+	Port PortRef `yaml:"port" jsonschema:"required"`
+}
+
+// ExecProbe runs a command inside the container and passes when it exits 0.
+type ExecProbe struct {
+	Command []string `yaml:"command" jsonschema:"required"`
+}
+
+// GRPCProbe calls the standard grpc.health.v1 Health service. Compose runs
+// it with grpc_health_probe, which must be installed in the image.
+type GRPCProbe struct {
+	// Port is the container port, as a number or a port name.
+	Port PortRef `yaml:"port" jsonschema:"required"`
+	// Service is the service name sent in the check. Empty checks the
+	// server as a whole.
+	Service string `yaml:"service,omitempty"`
+}
+
+// PortRef refers to a container port by number or by port name.
+type PortRef string
+
+// HealthTiming is a health check's schedule with defaults applied.
+type HealthTiming struct {
+	Interval         time.Duration
+	Timeout          time.Duration
+	StartPeriod      time.Duration
+	Retries          int
+	SuccessThreshold int
+}
+
+const (
+	defaultHealthInterval = 5 * time.Second
+	defaultHealthTimeout  = 2 * time.Second
+	// defaultHealthWindow is how long a service may keep failing its check
+	// when Retries is not set.
+	defaultHealthWindow = 2 * time.Minute
+)
+
+// ProbeKind returns "http", "tcp", "exec" or "grpc", or "" when no probe is
+// set.
+func (h Health) ProbeKind() string {
+	switch {
+	case h.HTTP != nil:
+		return "http"
+	case h.TCP != nil:
+		return "tcp"
+	case h.Exec != nil:
+		return "exec"
+	case h.GRPC != nil:
+		return "grpc"
+	}
+	return ""
+}
+
+// Timing returns the check's schedule. Invalid durations, which
+// ValidateProfile reports, fall back to their defaults.
+func (h Health) Timing() HealthTiming {
+	t := HealthTiming{
+		Interval:         parseDurationOr(h.Interval, defaultHealthInterval),
+		Timeout:          parseDurationOr(h.Timeout, defaultHealthTimeout),
+		StartPeriod:      parseDurationOr(h.StartPeriod, 0),
+		Retries:          h.Retries,
+		SuccessThreshold: h.SuccessThreshold,
+	}
+	if t.Retries <= 0 {
+		t.Retries = max(int(defaultHealthWindow/t.Interval), 1)
+	}
+	if t.SuccessThreshold <= 0 {
+		t.SuccessThreshold = 1
+	}
+	return t
+}
+
+// Deadline is the longest a service can take to become healthy: the start
+// period followed by Retries failed checks.
+func (t HealthTiming) Deadline() time.Duration {
+	return t.StartPeriod + time.Duration(t.Retries+t.SuccessThreshold-1)*t.Interval
+}
+
+func parseDurationOr(s string, def time.Duration) time.Duration {
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return def
+	}
+	return d
+}
+
+// ResolvePort finds the declared port ref names. A number that matches no
+// declared port stands for an unpublished container port.
+func ResolvePort(ports []Port, ref PortRef) (Port, bool) {
+	n, err := strconv.Atoi(string(ref))
+	for _, p := range ports {
+		if err != nil {
+			if p.Name == string(ref) {
+				return p, true
+			}
+			continue
+		}
+		if n >= p.Container.First && n <= p.Container.end() {
+			return Port{Container: PortRange{First: n}, Host: p.hostFor(n), HostIP: p.HostIP, Protocol: p.Protocol, Name: p.Name}, true
+		}
+	}
+	if err != nil || n < 1 || n > 65535 {
+		return Port{}, false
+	}
+	return Port{Container: PortRange{First: n}}, true
+}
+
+// hostFor returns the host port container port n is published on. A host
+// range with a single container port lets the runtime choose, so it has no
+// fixed host port.
+func (p Port) hostFor(n int) PortRange {
+	if p.Host.IsZero() || p.Host.Size() != p.Container.Size() {
+		return PortRange{}
+	}
+	return PortRange{First: p.Host.First + n - p.Container.First}
+}
+
+// HostAddress returns the host:port the port is published on, or false
+// when it has no single fixed host port.
+func (p Port) HostAddress() (string, bool) {
+	if p.Host.IsZero() || p.Host.Size() != 1 {
+		return "", false
+	}
+	host := p.HostIP
+	if IsWildcardIP(host) {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(p.Host.First)), true
+}
+
+// HostURL returns the URL to request from the host, or false when the
+// probe's port is not published on a fixed host port.
+func (p HTTPProbe) HostURL(ports []Port) (string, bool) {
+	if p.URL != "" {
+		return p.URL, true
+	}
+	port, ok := ResolvePort(ports, p.Port)
+	if !ok {
+		return "", false
+	}
+	addr, ok := port.HostAddress()
+	if !ok {
+		return "", false
+	}
+	return p.scheme() + "://" + addr + p.path(), true
+}
+
+// ContainerURL returns the URL to request from inside the container.
+func (p HTTPProbe) ContainerURL(ports []Port) (string, error) {
+	if p.URL == "" {
+		port, ok := ResolvePort(ports, p.Port)
+		if !ok {
+			return "", fmt.Errorf("port '%s' is not declared", p.Port)
+		}
+		return p.scheme() + "://" + net.JoinHostPort("localhost", strconv.Itoa(port.Container.First)) + p.path(), nil
+	}
+
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return "", err
+	}
+	if !isLocalHost(u.Hostname()) || u.Port() == "" {
+		return p.URL, nil
+	}
+	hostPort, _ := strconv.Atoi(u.Port())
+	for _, port := range ports {
+		if port.Host.IsZero() || hostPort < port.Host.First || hostPort > port.Host.end() {
+			continue
+		}
+		container := port.Container.First
+		if port.Container.Size() > 1 {
+			container += hostPort - port.Host.First
+		}
+		u.Host = net.JoinHostPort("localhost", strconv.Itoa(container))
+		return u.String(), nil
+	}
+	return p.URL, nil
+}
+
+// MoveURL returns the probe's URL with a localhost port published by a
+// mapping in from replaced by the host port of the same mapping in to, for
+// when devx moves a service's host ports away from devx.yaml's.
+func (p HTTPProbe) MoveURL(from, to []Port) string {
+	u, err := url.Parse(p.URL)
+	if err != nil || !isLocalHost(u.Hostname()) || u.Port() == "" {
+		return p.URL
+	}
+	hostPort, _ := strconv.Atoi(u.Port())
+	for i, port := range from {
+		if i >= len(to) || port.Host.IsZero() || hostPort < port.Host.First || hostPort > port.Host.end() {
+			continue
+		}
+		u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(to[i].Host.First+hostPort-port.Host.First))
+		return u.String()
+	}
+	return p.URL
+}
+
+// ProbePort returns the container port of a port-based probe.
+func ProbePort(ports []Port, ref PortRef) (int, bool) {
+	port, ok := ResolvePort(ports, ref)
+	return port.Container.First, ok
+}
+
+// Accepts reports whether an HTTP response code passes the probe.
+func (p HTTPProbe) Accepts(code int) bool {
+	if len(p.Status) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, s := range p.Status {
+		if s == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (p HTTPProbe) scheme() string {
+	if p.Scheme == "" {
+		return "http"
+	}
+	return p.Scheme
+}
+
+func (p HTTPProbe) path() string {
+	if p.Path == "" {
+		return "/"
+	}
+	return p.Path
+}
+
+func isLocalHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHTTPProbe_URLs(t *testing.T) {
+	ports := []Port{
+		{Name: "http", Host: PortRange{First: 8080}, Container: PortRange{First: 80}},
+		{Name: "admin", Container: PortRange{First: 9000}},
+		{HostIP: "127.0.0.1", Host: PortRange{7000, 7001}, Container: PortRange{8000, 8001}},
+	}
+	tests := []struct {
+		probe     HTTPProbe
+		host      string
+		container string
+	}{
+		{HTTPProbe{URL: "http://localhost:8080/health"}, "http://localhost:8080/health", "http://localhost:80/health"},
+		{HTTPProbe{URL: "http://127.0.0.1:7001/"}, "http://127.0.0.1:7001/", "http://localhost:8001/"},
+		{HTTPProbe{URL: "http://example.com:8080/"}, "http://example.com:8080/", "http://example.com:8080/"},
+		{HTTPProbe{Port: "http", Path: "/ready"}, "http://localhost:8080/ready", "http://localhost:80/ready"},
+		{HTTPProbe{Port: "8001", Scheme: "https"}, "https://127.0.0.1:7001/", "https://localhost:8001/"},
+		{HTTPProbe{Port: "admin"}, "", "http://localhost:9000/"},
+		{HTTPProbe{Port: "3000"}, "", "http://localhost:3000/"},
+	}
+	for _, tt := range tests {
+		host, _ := tt.probe.HostURL(ports)
+		if host != tt.host {
+			t.Errorf("%+v: host URL %q, want %q", tt.probe, host, tt.host)
+		}
+		container, err := tt.probe.ContainerURL(ports)
+		if err != nil || container != tt.container {
+			t.Errorf("%+v: container URL %q (%v), want %q", tt.probe, container, err, tt.container)
+		}
+	}
+	if _, err := (HTTPProbe{Port: "web"}).ContainerURL(ports); err == nil {
+		t.Errorf("expected an error for an undeclared port name")
+	}
+}
+
+func TestHTTPProbe_MoveURL(t *testing.T) {
+	from := []Port{
+		{Host: PortRange{First: 8080}, Container: PortRange{First: 80}},
+		{Host: PortRange{7000, 7001}, Container: PortRange{8000, 8001}},
+	}
+	to := []Port{
+		{Host: PortRange{First: 8081}, Container: PortRange{First: 80}},
+		{Host: PortRange{7100, 7101}, Container: PortRange{8000, 8001}},
+	}
+	tests := map[string]string{
+		"http://localhost:8080/health": "http://localhost:8081/health",
+		"http://127.0.0.1:7001/":       "http://127.0.0.1:7101/",
+		"http://localhost:9000/":       "http://localhost:9000/",
+		"http://example.com:8080/":     "http://example.com:8080/",
+	}
+	for in, want := range tests {
+		if got := (HTTPProbe{URL: in}).MoveURL(from, to); got != want {
+			t.Errorf("%s: got %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestHealth_Timing(t *testing.T) {
+	got := Health{}.Timing()
+	want := HealthTiming{Interval: 5 * time.Second, Timeout: 2 * time.Second, Retries: 24, SuccessThreshold: 1}
+	if got != want {
+		t.Fatalf("defaults: got %+v, want %+v", got, want)
+	}
+	if got.Deadline() != 2*time.Minute {
+		t.Errorf("expected the default deadline to be 2m, got %s", got.Deadline())
+	}
+
+	got = Health{Interval: "10s", Timeout: "1s", StartPeriod: "30s", Retries: 3, SuccessThreshold: 2}.Timing()
+	if got.Deadline() != 30*time.Second+40*time.Second {
+		t.Errorf("unexpected deadline %s for %+v", got.Deadline(), got)
+	}
+}
@@ -0,0 +1,436 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Origin records the file and position a manifest definition was declared at.
+type Origin struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (o Origin) String() string {
+	if o.Line == 0 {
+		return o.File
+	}
+	return fmt.Sprintf("%s:%d", o.File, o.Line)
+}
+
+// rootOnlyKeys are top-level keys that fragments pulled in via include may
+// not set — they describe the project as a whole and belong in devx.yaml.
+var rootOnlyKeys = map[string]bool{"project": true, "registry": true, "ports": true, "ai": true}
+
+// readNode reads a YAML file and returns its top-level mapping node. An empty
+// file yields an empty mapping.
+func readNode(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseNode(data)
+}
+
+func parseNode(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+	return doc.Content[0], nil
+}
+
+// sourceMap collects what is known about each manifest file before the files
+// are merged and decoded: where definitions and values were declared, and
+// which keys were not recognised.
+type sourceMap struct {
+	// version is the root manifest's version, which fragments share.
+	version   int
+	origins   map[string]Origin
+	positions map[string]Origin
+	unknown   []UnknownField
+}
+
+func newSourceMap(version int) *sourceMap {
+	return &sourceMap{version: version, origins: map[string]Origin{}, positions: map[string]Origin{}}
+}
+
+// scan upgrades one file's node tree to the current manifest version and
+// records it.
+func (s *sourceMap) scan(node *yaml.Node, file string) {
+	upgradeNode(node, s.version)
+	recordOrigins(node, file, s.origins)
+	recordPositions(node, "", file, s.positions)
+	s.unknown = append(s.unknown, unknownFields(node, file, s.version)...)
+}
+
+// resolveIncludes merges every fragment listed under root's include key into
+// root. Fragments may include further fragments; stack guards against cycles.
+func resolveIncludes(root *yaml.Node, file string, src *sourceMap, stack []string) error {
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+	inc := mappingValue(root, "include")
+	if inc == nil {
+		return nil
+	}
+
+	var patterns []string
+	if err := inc.Decode(&patterns); err != nil {
+		return fmt.Errorf("%s: include must be a list of paths: %w", file, err)
+	}
+
+	baseDir := filepath.Dir(file)
+	for _, pattern := range patterns {
+		paths, err := expandInclude(baseDir, pattern)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		for _, path := range paths {
+			if containsPath(stack, path) {
+				return fmt.Errorf("include cycle detected: %s", strings.Join(append(stack, path), " -> "))
+			}
+			frag, err := readNode(path)
+			if err != nil {
+				return fmt.Errorf("%s: include %q: %w", file, pattern, err)
+			}
+			if frag.Kind != yaml.MappingNode {
+				return fmt.Errorf("%s: manifest fragment must be a mapping", path)
+			}
+			src.scan(frag, path)
+			if err := rebasePaths(frag, filepath.Dir(stack[0]), filepath.Dir(path)); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if err := resolveIncludes(frag, path, src, append(stack, path)); err != nil {
+				return err
+			}
+			if err := mergeFragment(root, frag, path, src.origins); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rebasePaths rewrites the relative paths in a fragment read from fragDir —
+// build contexts, bind mounts, watch paths and secret files — to be relative
+// to rootDir, the directory of devx.yaml, which the rest of devx resolves
+// them against.
+func rebasePaths(frag *yaml.Node, rootDir, fragDir string) error {
+	rel, err := filepath.Rel(rootDir, fragDir)
+	if err != nil {
+		return err
+	}
+	if rel == "." {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+
+	profiles := mappingValue(frag, "profiles")
+	for i := 0; profiles != nil && i+1 < len(profiles.Content); i += 2 {
+		services := mappingValue(profiles.Content[i+1], "services")
+		for j := 0; services != nil && j+1 < len(services.Content); j += 2 {
+			svc := services.Content[j+1]
+			rebaseScalar(mappingValue(mappingValue(svc, "build"), "context"), rel)
+			if mounts := mappingValue(svc, "mount"); mounts != nil && mounts.Kind == yaml.SequenceNode {
+				for _, m := range mounts.Content {
+					// Compose reads a host path without ./ or ../ as a
+					// named volume.
+					host, rest, ok := strings.Cut(m.Value, ":")
+					if ok && (host == "." || strings.HasPrefix(host, "./") || strings.HasPrefix(host, "../")) {
+						m.Value = rebase(host, rel) + ":" + rest
+					}
+				}
+			}
+			if watch := mappingValue(svc, "watch"); watch != nil && watch.Kind == yaml.SequenceNode {
+				for _, rule := range watch.Content {
+					rebaseScalar(mappingValue(rule, "path"), rel)
+				}
+			}
+		}
+	}
+	secrets := mappingValue(frag, "secrets")
+	for i := 0; secrets != nil && i+1 < len(secrets.Content); i += 2 {
+		rebaseScalar(mappingValue(secrets.Content[i+1], "file"), rel)
+	}
+	return nil
+}
+
+// rebaseScalar prefixes a relative path held in n with rel.
+func rebaseScalar(n *yaml.Node, rel string) {
+	if n != nil && n.Kind == yaml.ScalarNode && isRelativeRef(n.Value) {
+		n.Value = rebase(n.Value, rel)
+	}
+}
+
+// isRelativeRef reports whether value is a relative path. Paths that start
+// with a variable or ~ are left alone, as they are not known until later.
+func isRelativeRef(value string) bool {
+	return value != "" && !filepath.IsAbs(value) && !strings.HasPrefix(value, "/") &&
+		!strings.HasPrefix(value, "$") && !strings.HasPrefix(value, "~") && !driveLetterRe.MatchString(value)
+}
+
+// rebase joins rel and p, keeping the leading "./" that compose needs to
+// tell a bind mount from a named volume.
+func rebase(p, rel string) string {
+	joined := path.Join(rel, filepath.ToSlash(p))
+	if !strings.HasPrefix(joined, "../") && joined != ".." {
+		joined = "./" + joined
+	}
+	return joined
+}
+
+// expandInclude resolves one include entry relative to baseDir. Entries that
+// contain glob metacharacters may match zero or more files; plain paths must
+// exist.
+func expandInclude(baseDir, pattern string) ([]string, error) {
+	path := pattern
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, pattern)
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		return []string{path}, nil
+	}
+	matches, err := filepath.Glob(path)
+	if err != nil {
+		return nil, fmt.Errorf("include %q: %w", pattern, err)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func containsPath(stack []string, path string) bool {
+	abs, _ := filepath.Abs(path)
+	for _, p := range stack {
+		if pa, _ := filepath.Abs(p); pa == abs {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeFragment folds the profiles, tools and setup steps of frag into root.
+// A definition that already exists in root is a conflict; the error names
+// both files.
+func mergeFragment(root, frag *yaml.Node, file string, origins map[string]Origin) error {
+	for i := 0; i+1 < len(frag.Content); i += 2 {
+		key, val := frag.Content[i].Value, frag.Content[i+1]
+		switch {
+		case key == "include":
+			continue
+		case key == "version":
+			if rv := mappingValue(root, "version"); rv != nil && rv.Value != val.Value {
+				return fmt.Errorf("%s: version %s does not match root manifest version %s", file, val.Value, rv.Value)
+			}
+		case key == "profiles":
+			if err := mergeProfiles(root, val, file, origins); err != nil {
+				return err
+			}
+		case key == "tools" || key == "setup":
+			if err := mergeNamedList(root, key, val, file, origins); err != nil {
+				return err
+			}
+		case key == "tasks":
+			if err := mergeTasks(root, val, file, origins); err != nil {
+				return err
+			}
+		case rootOnlyKeys[key]:
+			return fmt.Errorf("%s: %s may only be set in the root manifest", file, key)
+		default:
+			if existing := mappingValue(root, key); existing != nil {
+				return conflictError(key, origins[key], file)
+			}
+			setMappingValue(root, key, val)
+		}
+	}
+	return nil
+}
+
+func mergeProfiles(root, profiles *yaml.Node, file string, origins map[string]Origin) error {
+	dst := mappingValue(root, "profiles")
+	if dst == nil {
+		setMappingValue(root, "profiles", profiles)
+		return nil
+	}
+	for i := 0; i+1 < len(profiles.Content); i += 2 {
+		name, prof := profiles.Content[i].Value, profiles.Content[i+1]
+		existing := mappingValue(dst, name)
+		if existing == nil {
+			setMappingValue(dst, name, prof)
+			continue
+		}
+		if err := mergeProfile(existing, prof, "profiles."+name, file, origins); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mergeProfile(dst, src *yaml.Node, path, file string, origins map[string]Origin) error {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, val := src.Content[i].Value, src.Content[i+1]
+		existing := mappingValue(dst, key)
+		if existing == nil {
+			setMappingValue(dst, key, val)
+			continue
+		}
+		switch key {
+		case "services", "deps":
+			for j := 0; j+1 < len(val.Content); j += 2 {
+				name := val.Content[j].Value
+				defPath := path + "." + key + "." + name
+				if mappingValue(existing, name) != nil {
+					return conflictError(defPath, origins[defPath], file)
+				}
+				setMappingValue(existing, name, val.Content[j+1])
+			}
+		case "hooks":
+			for j := 0; j+1 < len(val.Content); j += 2 {
+				point := val.Content[j].Value
+				if seq := mappingValue(existing, point); seq != nil {
+					seq.Content = append(seq.Content, val.Content[j+1].Content...)
+				} else {
+					setMappingValue(existing, point, val.Content[j+1])
+				}
+			}
+		default:
+			if existing.Value != val.Value || existing.Kind != val.Kind || existing.Kind != yaml.ScalarNode {
+				first, ok := origins[path+"."+key]
+				if !ok {
+					first = origins[path]
+				}
+				return conflictError(path+"."+key, first, file)
+			}
+		}
+	}
+	return nil
+}
+
+// mergeNamedList appends the entries of a tools or setup sequence to root,
+// rejecting entries whose name is already declared.
+func mergeNamedList(root *yaml.Node, key string, src *yaml.Node, file string, origins map[string]Origin) error {
+	dst := mappingValue(root, key)
+	if dst == nil {
+		setMappingValue(root, key, src)
+		return nil
+	}
+	for _, item := range src.Content {
+		name := ""
+		if n := mappingValue(item, "name"); n != nil {
+			name = n.Value
+		}
+		if name != "" {
+			for _, other := range dst.Content {
+				if n := mappingValue(other, "name"); n != nil && n.Value == name {
+					defPath := key + "." + name
+					return conflictError(defPath, origins[defPath], file)
+				}
+			}
+		}
+		dst.Content = append(dst.Content, item)
+	}
+	return nil
+}
+
+func mergeTasks(root, tasks *yaml.Node, file string, origins map[string]Origin) error {
+	dst := mappingValue(root, "tasks")
+	if dst == nil {
+		setMappingValue(root, "tasks", tasks)
+		return nil
+	}
+	for i := 0; i+1 < len(tasks.Content); i += 2 {
+		name := tasks.Content[i].Value
+		if mappingValue(dst, name) != nil {
+			defPath := "tasks." + name
+			return conflictError(defPath, origins[defPath], file)
+		}
+		setMappingValue(dst, name, tasks.Content[i+1])
+	}
+	return nil
+}
+
+func conflictError(path string, first Origin, second string) error {
+	return fmt.Errorf("%s is defined in both %s and %s", path, first.File, second)
+}
+
+// recordOrigins notes the declaring file of every profile, profile key,
+// service, dep, tool, setup step and task found in node.
+func recordOrigins(node *yaml.Node, file string, origins map[string]Origin) {
+	at := func(path string, n *yaml.Node) {
+		if _, ok := origins[path]; !ok {
+			origins[path] = Origin{File: file, Line: n.Line, Column: n.Column}
+		}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, val := node.Content[i], node.Content[i+1]
+		at(key.Value, key)
+		switch key.Value {
+		case "profiles":
+			for j := 0; j+1 < len(val.Content); j += 2 {
+				profPath := "profiles." + val.Content[j].Value
+				at(profPath, val.Content[j])
+				prof := val.Content[j+1]
+				for k := 0; k+1 < len(prof.Content); k += 2 {
+					section := prof.Content[k].Value
+					at(profPath+"."+section, prof.Content[k])
+					if section != "services" && section != "deps" {
+						continue
+					}
+					entries := prof.Content[k+1]
+					for l := 0; l+1 < len(entries.Content); l += 2 {
+						at(profPath+"."+section+"."+entries.Content[l].Value, entries.Content[l])
+					}
+				}
+			}
+		case "tools", "setup":
+			for _, item := range val.Content {
+				if n := mappingValue(item, "name"); n != nil && n.Value != "" {
+					at(key.Value+"."+n.Value, item)
+				}
+			}
+		case "tasks":
+			for j := 0; j+1 < len(val.Content); j += 2 {
+				at("tasks."+val.Content[j].Value, val.Content[j])
+			}
+		}
+	}
+}
+
+// mappingValue returns the value node stored under key in a mapping node, or
+// nil if the key is absent or node is not a mapping.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue replaces the value stored under key, appending the key if
+// it is not yet present.
+func setMappingValue(node *yaml.Node, key string, val *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = val
+			return
+		}
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		val,
+	)
+}
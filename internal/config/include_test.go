@@ -0,0 +1,293 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes each name → content pair under a temp dir and returns the dir.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const includeRoot = `version: 1
+project:
+  name: my-app
+  defaultProfile: local
+include:
+  - teams/*.yaml
+profiles:
+  local:
+    services:
+      api:
+        image: nginx:alpine
+tools:
+  - name: go
+    check: go version
+`
+
+func TestLoadInclude_MergesFragments(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml": includeRoot,
+		"teams/billing.yaml": `profiles:
+  local:
+    services:
+      billing:
+        image: billing:dev
+        dependsOn: [db]
+    deps:
+      db:
+        image: postgres:16
+    hooks:
+      afterUp:
+        - run: ./seed.sh
+setup:
+  - name: restore
+    run: npm install
+`,
+		"teams/search.yaml": `profiles:
+  ci:
+    services:
+      search:
+        image: search:ci
+`,
+	})
+
+	m, err := Load(filepath.Join(dir, "devx.yaml"))
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	local := m.Profiles["local"]
+	if _, ok := local.Services["api"]; !ok {
+		t.Error("expected root service api")
+	}
+	if _, ok := local.Services["billing"]; !ok {
+		t.Error("expected included service billing")
+	}
+	if _, ok := local.Deps["db"]; !ok {
+		t.Error("expected included dep db")
+	}
+	if len(local.Hooks.AfterUp) != 1 {
+		t.Errorf("expected 1 afterUp hook, got %d", len(local.Hooks.AfterUp))
+	}
+	if _, ok := m.Profiles["ci"]; !ok {
+		t.Error("expected included profile ci")
+	}
+	if len(m.Setup) != 1 || len(m.Tools) != 1 {
+		t.Errorf("expected 1 tool and 1 setup step, got %d and %d", len(m.Tools), len(m.Setup))
+	}
+
+	o, ok := m.OriginOf("profiles.local.services.billing")
+	if !ok || !strings.HasSuffix(o.File, filepath.Join("teams", "billing.yaml")) {
+		t.Errorf("unexpected origin for billing: %+v", o)
+	}
+	o, ok = m.OriginOf("profiles.local.services.api")
+	if !ok || !strings.HasSuffix(o.File, "devx.yaml") || o.Line == 0 {
+		t.Errorf("unexpected origin for api: %+v", o)
+	}
+	if !m.MultiFile() {
+		t.Error("expected MultiFile to be true")
+	}
+}
+
+func TestLoadInclude_ConflictNamesBothFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml": includeRoot,
+		"teams/dup.yaml": `profiles:
+  local:
+    services:
+      api:
+        image: other:latest
+`,
+	})
+
+	_, err := Load(filepath.Join(dir, "devx.yaml"))
+	if err == nil {
+		t.Fatal("expected conflict error")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "profiles.local.services.api") || !strings.Contains(msg, "devx.yaml") || !strings.Contains(msg, "dup.yaml") {
+		t.Errorf("conflict error should name the definition and both files, got: %v", err)
+	}
+}
+
+func TestLoadInclude_ScalarConflictNamesDeclaringFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml": includeRoot,
+		"teams/a.yaml": `profiles:
+  local:
+    runtime: compose
+`,
+		"teams/b.yaml": `profiles:
+  local:
+    runtime: k8s
+`,
+	})
+
+	_, err := Load(filepath.Join(dir, "devx.yaml"))
+	if err == nil {
+		t.Fatal("expected conflict error")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "profiles.local.runtime") || !strings.Contains(msg, "a.yaml and") || !strings.Contains(msg, "b.yaml") {
+		t.Errorf("conflict error should name the files declaring runtime, got: %v", err)
+	}
+}
+
+func TestLoadInclude_RebasesFragmentPaths(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml": `version: 1
+project:
+  name: my-app
+  defaultProfile: local
+include:
+  - services/api/devx.yaml
+profiles:
+  local:
+    services:
+      web:
+        image: nginx
+        build:
+          context: ./web
+`,
+		"services/api/devx.yaml": `secrets:
+  api_key:
+    file: ./api.key
+profiles:
+  local:
+    services:
+      api:
+        image: api:dev
+        build:
+          context: .
+        mount:
+          - ./src:/app/src
+          - ../shared:/shared:ro
+          - cache:/cache
+          - /abs:/abs
+        watch:
+          - path: src
+            action: sync
+            target: /app/src
+        secrets: [api_key]
+`,
+	})
+
+	m, err := Load(filepath.Join(dir, "devx.yaml"))
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	local := m.Profiles["local"]
+	api := local.Services["api"]
+	if got := api.Build.Context; got != "./services/api" {
+		t.Errorf("build context: got %q", got)
+	}
+	if got, want := strings.Join(api.Mount, " "), "./services/api/src:/app/src ./services/shared:/shared:ro cache:/cache /abs:/abs"; got != want {
+		t.Errorf("mounts: got %q, want %q", got, want)
+	}
+	if got := api.Watch[0].Path; got != "./services/api/src" {
+		t.Errorf("watch path: got %q", got)
+	}
+	if got := m.Secrets["api_key"].File; got != "./services/api/api.key" {
+		t.Errorf("secret file: got %q", got)
+	}
+	if got := local.Services["web"].Build.Context; got != "./web" {
+		t.Errorf("root paths should be left alone, got %q", got)
+	}
+}
+
+func TestLoadInclude_DuplicateTool(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml": includeRoot,
+		"teams/tools.yaml": `tools:
+  - name: go
+    check: go version
+`,
+	})
+
+	if _, err := Load(filepath.Join(dir, "devx.yaml")); err == nil || !strings.Contains(err.Error(), "tools.go") {
+		t.Fatalf("expected duplicate tool error, got: %v", err)
+	}
+}
+
+func TestLoadInclude_FragmentCannotSetProject(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml": includeRoot,
+		"teams/bad.yaml": `project:
+  name: hijack
+`,
+	})
+
+	if _, err := Load(filepath.Join(dir, "devx.yaml")); err == nil || !strings.Contains(err.Error(), "root manifest") {
+		t.Fatalf("expected root-only key error, got: %v", err)
+	}
+}
+
+func TestLoadInclude_Cycle(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml": includeRoot,
+		"teams/a.yaml": `include: [b.yaml]
+`,
+		"teams/b.yaml": `include: [a.yaml]
+`,
+	})
+
+	if _, err := Load(filepath.Join(dir, "devx.yaml")); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected include cycle error, got: %v", err)
+	}
+}
+
+func TestLoadInclude_MissingFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml": `version: 1
+project:
+  name: my-app
+  defaultProfile: local
+include:
+  - missing.yaml
+profiles:
+  local:
+    services:
+      api:
+        image: nginx:alpine
+`,
+	})
+
+	if _, err := Load(filepath.Join(dir, "devx.yaml")); err == nil {
+		t.Fatal("expected error for missing include")
+	}
+}
+
+func TestValidateProfile_ReportsSourceFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml": includeRoot,
+		"teams/broken.yaml": `profiles:
+  local:
+    services:
+      broken: {}
+`,
+	})
+
+	m, err := Load(filepath.Join(dir, "devx.yaml"))
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	err = ValidateProfile(m, "local")
+	if err == nil || !strings.Contains(err.Error(), "broken.yaml") {
+		t.Fatalf("expected issue to name broken.yaml, got: %v", err)
+	}
+}
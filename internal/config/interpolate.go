@@ -0,0 +1,312 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Variable records how a ${VAR} reference in the manifest was resolved.
+type Variable struct {
+	Name  string
+	Value string
+	// Source is where the value came from: "environment", ".env",
+	// ".env.<profile>", "default", or "" when the variable is unset.
+	Source string
+	// Paths lists every manifest location that references the variable,
+	// e.g. "profiles.staging.deps.db.env.POSTGRES_PASSWORD".
+	Paths []string
+	// Missing lists the references that got no value because the variable
+	// was unset and no :- default was given. They interpolate to "".
+	Missing []MissingRef
+}
+
+// MissingRef is a reference to an unset variable.
+type MissingRef struct {
+	Path string
+	// Required is set for ${VAR:?message} references, which fail
+	// validation; a plain ${VAR} becomes empty.
+	Required bool
+	// Message is the text of a ${VAR:?message} reference, if any.
+	Message string
+}
+
+// Resolved reports whether every reference to the variable got a value.
+func (v Variable) Resolved() bool {
+	return len(v.Missing) == 0
+}
+
+// LoadOptions controls how Load reads a manifest.
+type LoadOptions struct {
+	// Profile selects which .env.<profile> file is read alongside .env.
+	// Defaults to project.defaultProfile.
+	Profile string
+	// LookupEnv resolves variables from the process environment.
+	// Defaults to os.LookupEnv.
+	LookupEnv func(string) (string, bool)
+}
+
+// envSource is one layer of variable values, in precedence order.
+type envSource struct {
+	name   string
+	lookup func(string) (string, bool)
+}
+
+// interpolate expands ${VAR}, ${VAR:-default} and ${VAR:?message} in every
+// scalar value of root. $$ produces a literal $. Dep connect env templates
+// (${host}, ${port}, …) are left untouched; devx fills those in later.
+//
+// Unset variables are not an error here: a manifest may reference variables
+// that only one profile needs. They are recorded in Variable.Missing and
+// reported by Validate and ValidateProfile for the parts that use them.
+func interpolate(root *yaml.Node, sources []envSource) (map[string]Variable, error) {
+	vars := map[string]Variable{}
+	lookup := func(name string) (string, string, bool) {
+		for _, src := range sources {
+			if v, ok := src.lookup(name); ok {
+				return v, src.name, true
+			}
+		}
+		return "", "", false
+	}
+
+	var walk func(n *yaml.Node, path string) error
+	walk = func(n *yaml.Node, path string) error {
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				child := joinPath(path, n.Content[i].Value)
+				if isConnectEnvPath(child) {
+					continue
+				}
+				if err := walk(n.Content[i+1], child); err != nil {
+					return err
+				}
+			}
+		case yaml.SequenceNode:
+			for i, item := range n.Content {
+				if err := walk(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		case yaml.ScalarNode:
+			if !strings.Contains(n.Value, "$") {
+				return nil
+			}
+			out, err := expandVars(n.Value, func(name, op, arg string) (string, error) {
+				value, source, ok := lookup(name)
+				v := vars[name]
+				v.Name = name
+				v.Paths = append(v.Paths, path)
+				defer func() { vars[name] = v }()
+				if ok && (value != "" || op == "") {
+					v.Value, v.Source = value, source
+					return value, nil
+				}
+				switch op {
+				case ":-":
+					if v.Source == "" {
+						v.Value, v.Source = arg, "default"
+					}
+					return arg, nil
+				case ":?":
+					v.Missing = append(v.Missing, MissingRef{Path: path, Required: true, Message: arg})
+					return "", nil
+				}
+				v.Missing = append(v.Missing, MissingRef{Path: path})
+				return "", nil
+			})
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			n.Value = out
+			if n.Style == 0 {
+				// Let plain scalars re-resolve their type, so "${REPLICAS}" can
+				// still decode into an int field.
+				n.Tag = ""
+			}
+		}
+		return nil
+	}
+
+	if err := walk(root, ""); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// expandVars performs the substitution for a single string. resolve is
+// called for each ${name}, ${name:-arg} or ${name:?arg} reference with op set
+// to "", ":-" or ":?" respectively.
+func expandVars(s string, resolve func(name, op, arg string) (string, error)) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+		if i+1 >= len(s) || s[i+1] != '{' {
+			b.WriteByte('$')
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable reference in %q", s)
+		}
+		expr := s[i+2 : i+end]
+		i += end
+
+		name, op, arg := expr, "", ""
+		for _, candidate := range []string{":-", ":?"} {
+			if idx := strings.Index(expr, candidate); idx >= 0 {
+				name, op, arg = expr[:idx], candidate, expr[idx+2:]
+				break
+			}
+		}
+		if !validVarName(name) {
+			return "", fmt.Errorf("invalid variable name %q", name)
+		}
+
+		value, err := resolve(name, op, arg)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+	}
+	return b.String(), nil
+}
+
+func validVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		isLetter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		isDigit := r >= '0' && r <= '9'
+		if !isLetter && !(isDigit && i > 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// isConnectEnvPath reports whether path is a dep connect env block, whose
+// values are connection templates rather than environment references.
+func isConnectEnvPath(path string) bool {
+	parts := strings.Split(path, ".")
+	return len(parts) == 6 && parts[0] == "profiles" && parts[2] == "deps" &&
+		strings.HasPrefix(parts[4], "connect[") && parts[5] == "env"
+}
+
+func joinPath(base, key string) string {
+	if base == "" {
+		return key
+	}
+	return base + "." + key
+}
+
+// envSources returns the variable layers for a manifest in dir: the process
+// environment first, then .env.<profile>, then .env.
+func envSources(dir, profile string, lookupEnv func(string) (string, bool)) ([]envSource, error) {
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	sources := []envSource{{name: "environment", lookup: lookupEnv}}
+
+	files := []string{".env"}
+	if profile != "" {
+		files = []string{".env." + profile, ".env"}
+	}
+	for _, name := range files {
+		values, err := ReadEnvFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, envSource{name: name, lookup: mapLookup(values)})
+	}
+	return sources, nil
+}
+
+func mapLookup(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := values[key]
+		return v, ok
+	}
+}
+
+// ReadEnvFile parses a dotenv file: KEY=VALUE lines, optional `export`
+// prefix, # comments, and single- or double-quoted values.
+func ReadEnvFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNo)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			value = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = strings.TrimSpace(value[:idx])
+			}
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+// VariablesFor returns the variables referenced by the named profile (and by
+// any non-profile part of the manifest), sorted by name.
+func (m *Manifest) VariablesFor(profile string) []Variable {
+	prefix := "profiles." + profile + "."
+	var out []Variable
+	for _, v := range m.Variables {
+		for _, p := range v.Paths {
+			if strings.HasPrefix(p, prefix) || !strings.HasPrefix(p, "profiles.") {
+				out = append(out, v)
+				break
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// defaultProfileOf reads project.defaultProfile from an undecoded manifest.
+func defaultProfileOf(root *yaml.Node) string {
+	if n := mappingValue(mappingValue(root, "project"), "defaultProfile"); n != nil {
+		return n.Value
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const interpolateManifest = `version: 1
+project:
+  name: my-app
+  defaultProfile: local
+profiles:
+  local:
+    services:
+      api:
+        image: "api:${API_TAG:-dev}"
+        env:
+          DB_PASSWORD: ${DB_PASSWORD}
+          GREETING: "cost: $$5"
+          REGION: ${REGION}
+        health:
+          httpGet: http://localhost:8080/health
+          retries: ${RETRIES}
+    deps:
+      db:
+        image: postgres:16
+        env:
+          POSTGRES_PASSWORD: ${DB_PASSWORD}
+        connect:
+          - service: api
+            env:
+              DATABASE_URL: "postgres://${host}:${port}"
+  staging:
+    services:
+      api:
+        image: api:stable
+        env:
+          TOKEN: ${STAGING_TOKEN:?set STAGING_TOKEN for staging}
+          SIGNING_KEY: ${SIGNING_KEY:?}
+`
+
+func lookupFrom(values map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := values[k]
+		return v, ok
+	}
+}
+
+func TestLoad_InterpolatesVariables(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml":  interpolateManifest,
+		".env":       "DB_PASSWORD=from-dotenv\nRETRIES=7\n# comment\nexport REGION='eu west'\n",
+		".env.local": "DB_PASSWORD=\"from-local\"\n",
+	})
+
+	m, err := LoadWithOptions(filepath.Join(dir, "devx.yaml"), LoadOptions{
+		LookupEnv: lookupFrom(map[string]string{"REGION": "us-east"}),
+	})
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	api := m.Profiles["local"].Services["api"]
+	if api.Image != "api:dev" {
+		t.Errorf("expected default to apply, got %q", api.Image)
+	}
+	if api.Env["DB_PASSWORD"] != "from-local" {
+		t.Errorf("expected .env.<profile> to win over .env, got %q", api.Env["DB_PASSWORD"])
+	}
+	if api.Env["REGION"] != "us-east" {
+		t.Errorf("expected environment to win over .env, got %q", api.Env["REGION"])
+	}
+	if api.Env["GREETING"] != "cost: $5" {
+		t.Errorf("expected $$ to produce a literal $, got %q", api.Env["GREETING"])
+	}
+	if api.Health.Retries != 7 {
+		t.Errorf("expected interpolated int field, got %d", api.Health.Retries)
+	}
+
+	connect := m.Profiles["local"].Deps["db"].Connect[0].Env["DATABASE_URL"]
+	if connect != "postgres://${host}:${port}" {
+		t.Errorf("connect templates must not be interpolated, got %q", connect)
+	}
+
+	v := m.Variables["DB_PASSWORD"]
+	if v.Source != ".env.local" || len(v.Paths) != 2 {
+		t.Errorf("unexpected DB_PASSWORD record: %+v", v)
+	}
+	if m.Variables["API_TAG"].Source != "default" {
+		t.Errorf("expected API_TAG source 'default', got %q", m.Variables["API_TAG"].Source)
+	}
+
+	if err := ValidateProfile(m, "local"); err != nil {
+		t.Errorf("local should be valid, got: %v", err)
+	}
+	err = ValidateProfile(m, "staging")
+	if err == nil || !strings.Contains(err.Error(), "set STAGING_TOKEN for staging") {
+		t.Errorf("expected :? message for staging, got: %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "variable SIGNING_KEY is required") {
+		t.Errorf("expected a default message for ${SIGNING_KEY:?}, got: %v", err)
+	}
+}
+
+func TestLoad_ProfileEnvFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml":    interpolateManifest,
+		".env.staging": "STAGING_TOKEN=abc\nSIGNING_KEY=key\n",
+	})
+
+	m, err := LoadWithOptions(filepath.Join(dir, "devx.yaml"), LoadOptions{
+		Profile:   "staging",
+		LookupEnv: lookupFrom(nil),
+	})
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if got := m.Profiles["staging"].Services["api"].Env["TOKEN"]; got != "abc" {
+		t.Errorf("expected TOKEN from .env.staging, got %q", got)
+	}
+	if err := ValidateProfile(m, "staging"); err != nil {
+		t.Errorf("staging should be valid, got: %v", err)
+	}
+
+	// A plain ${VAR} left unset is a warning, not an error.
+	if err := ValidateProfile(m, "local"); err != nil && strings.Contains(err.Error(), "DB_PASSWORD") {
+		t.Errorf("unset DB_PASSWORD should not fail local, got: %v", err)
+	}
+	var warned bool
+	for _, issue := range ProfileIssues(m, "local") {
+		if issue.Rule == "unset-variable" && issue.Path == "profiles.local.services.api.env.DB_PASSWORD" {
+			warned = issue.Severity == SeverityWarning && issue.Message == "variable DB_PASSWORD is not set, using an empty value"
+		}
+	}
+	if !warned {
+		t.Errorf("expected an unset-variable warning for DB_PASSWORD, got %+v", ProfileIssues(m, "local"))
+	}
+}
+
+func TestExpandVars_Errors(t *testing.T) {
+	resolve := func(name, op, arg string) (string, error) { return "", nil }
+	for _, in := range []string{"${UNTERMINATED", "${1BAD}", "${}"} {
+		if _, err := expandVars(in, resolve); err == nil {
+			t.Errorf("expandVars(%q): expected error", in)
+		}
+	}
+	out, err := expandVars("$HOME and $", resolve)
+	if err != nil || out != "$HOME and $" {
+		t.Errorf("bare $ references should pass through, got %q, %v", out, err)
+	}
+}
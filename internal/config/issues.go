@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity ranks a validation issue.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a single validation finding. Path is the YAML path of the
+// offending value (e.g. "profiles.local.services.api.dependsOn[1]"); File,
+// Line and Column locate it in the manifest when known.
+type Issue struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Path     string   `json:"path,omitempty"`
+	Profile  string   `json:"profile,omitempty"`
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+}
+
+func (i Issue) String() string {
+	if i.Line == 0 {
+		return i.Message
+	}
+	return fmt.Sprintf("%s (%s)", i.Message, Origin{File: i.File, Line: i.Line, Column: i.Column}.Position())
+}
+
+// Rules describes every rule code an Issue can carry. Codes are stable, so
+// CI configuration and code-scanning dashboards can refer to them.
+var Rules = map[string]string{
+	"parse-error":         "Manifest could not be read or parsed",
+	"unknown-field":       "Key does not match any manifest field",
+	"unsupported-version": "Manifest version is not supported",
+	"required":            "A required field is missing",
+	"unknown-profile":     "Referenced profile does not exist",
+	"invalid-value":       "Field value is not one of the allowed values",
+	"secret-source":       "Secret must declare exactly one source",
+	"invalid-name":        "Name is not usable as a file name and Kubernetes key",
+	"undeclared-secret":   "Service or dep references an undeclared secret",
+	"missing-dependency":  "dependsOn names a service or dep that does not exist",
+	"connect-service":     "Dep connect entry references a missing service",
+	"dep-source":          "Dep provider source is not in org/name format",
+	"hook":                "Hook definition is invalid",
+	"unset-variable":      "${VAR} reference has no value",
+	"invalid-port":        "Port spec does not parse as [hostIP:][hostPort:]containerPort[/protocol]",
+	"port-conflict":       "Host port is published more than once in a profile",
+	"invalid-volume":      "Volume or mount spec is malformed",
+	"invalid-duration":    "Value does not parse as a positive duration",
+	"missing-path":        "Build context directory or Dockerfile does not exist",
+	"dependency-cycle":    "dependsOn forms a cycle",
+	"duplicate-name":      "Name is used by both a service and a dep",
+	"unsupported":         "Field is not supported by the profile's runtime",
+	"unused-field":        "Field has no effect given the rest of the definition",
+	"unused-secret":       "Declared secret is not used by any service or dep",
+	"deprecated":          "Field or value is deprecated",
+}
+
+// newIssue returns an error-severity issue.
+func newIssue(rule, path, format string, args ...any) Issue {
+	return Issue{Rule: rule, Severity: SeverityError, Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+// newWarning returns a warning-severity issue.
+func newWarning(rule, path, format string, args ...any) Issue {
+	issue := newIssue(rule, path, format, args...)
+	issue.Severity = SeverityWarning
+	return issue
+}
+
+// locate fills in the file and position of each issue from the manifest's
+// recorded node positions, falling back to the closest enclosing path that
+// has one (inherited or defaulted values have no node of their own).
+func (m *Manifest) locate(issues []Issue) []Issue {
+	for i := range issues {
+		if issues[i].Line != 0 {
+			continue
+		}
+		for path := issues[i].Path; path != ""; path = parentPath(path) {
+			o, ok := m.Positions[path]
+			if !ok {
+				o, ok = m.Origins[path]
+			}
+			if ok {
+				issues[i].File, issues[i].Line, issues[i].Column = o.File, o.Line, o.Column
+				break
+			}
+		}
+	}
+	return issues
+}
+
+// parentPath strips the last element from a YAML path: a.b[2] → a.b → a.
+func parentPath(path string) string {
+	if strings.HasSuffix(path, "]") {
+		if idx := strings.LastIndexByte(path, '['); idx >= 0 {
+			return path[:idx]
+		}
+	}
+	if idx := strings.LastIndexByte(path, '.'); idx >= 0 {
+		return path[:idx]
+	}
+	return ""
+}
+
+// recordPositions notes the position of every key and sequence item under
+// node. Sequence items of tools and setup are also keyed by name, matching
+// Origins, since their indices change when fragments are merged.
+func recordPositions(node *yaml.Node, path, file string, positions map[string]Origin) {
+	at := func(p string, n *yaml.Node) {
+		if _, ok := positions[p]; !ok {
+			positions[p] = Origin{File: file, Line: n.Line, Column: n.Column}
+		}
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			child := joinPath(path, node.Content[i].Value)
+			at(child, node.Content[i])
+			recordPositions(node.Content[i+1], child, file, positions)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			if path == "tools" || path == "setup" {
+				if n := mappingValue(item, "name"); n != nil && n.Value != "" {
+					child = path + "." + n.Value
+				}
+			}
+			at(child, item)
+			recordPositions(item, child, file, positions)
+		}
+	}
+}
+
+// ValidationError carries every error-severity issue found by a Validate
+// function.
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		lines[i] = issue.String()
+	}
+	sort.Strings(lines)
+	return "manifest validation failed:\n- " + strings.Join(lines, "\n- ")
+}
+
+// validationError drops warnings from issues and returns nil when nothing
+// else is left.
+func validationError(m *Manifest, issues []Issue) error {
+	var errs []Issue
+	for _, issue := range issues {
+		if issue.Severity != SeverityWarning {
+			errs = append(errs, issue)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Issues: m.locate(errs)}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateProfile_IssuePositions(t *testing.T) {
+	data := []byte(`version: 1
+project:
+  name: my-app
+  defaultProfile: local
+profiles:
+  local:
+    services:
+      api:
+        image: nginx:alpine
+        dependsOn:
+          - db
+          - nope
+`)
+
+	m, err := Parse(data)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	var ve *ValidationError
+	if err := ValidateProfile(m, "local"); !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(ve.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %v", ve.Issues)
+	}
+	for _, issue := range ve.Issues {
+		if _, ok := Rules[issue.Rule]; !ok {
+			t.Errorf("rule %q is not listed in Rules", issue.Rule)
+		}
+		if issue.Severity != SeverityError || issue.Profile != "local" {
+			t.Errorf("unexpected severity/profile: %+v", issue)
+		}
+	}
+	got := ve.Issues[1]
+	if got.Path != "profiles.local.services.api.dependsOn[1]" || got.Line != 12 || got.Column != 13 {
+		t.Fatalf("unexpected issue location: %+v", got)
+	}
+}
+
+func TestValidateTools_IssueLocatedInFragment(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml":        includeRoot,
+		"teams/tools.yaml": "tools:\n  - name: node\n",
+	})
+
+	m, err := Load(filepath.Join(dir, "devx.yaml"))
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	var ve *ValidationError
+	if err := ValidateTools(m); !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	issue := ve.Issues[0]
+	if issue.File != filepath.Join(dir, "teams", "tools.yaml") || issue.Line != 2 || issue.Rule != "required" {
+		t.Fatalf("unexpected issue: %+v", issue)
+	}
+}
+
+func TestParentPath(t *testing.T) {
+	cases := map[string]string{
+		"profiles.local.ports[0]": "profiles.local.ports",
+		"profiles.local":          "profiles",
+		"profiles":                "",
+	}
+	for in, want := range cases {
+		if got := parentPath(in); got != want {
+			t.Errorf("parentPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
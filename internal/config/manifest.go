@@ -3,22 +3,57 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"gopkg.in/yaml.v3"
 )
 
+// Manifest is the root of devx.yaml.
 type Manifest struct {
-	Version  int                `yaml:"version"`
-	Project  Project            `yaml:"project"`
-	Registry Registry           `yaml:"registry"`
-	AI       *AIConfig          `yaml:"ai,omitempty"`
-	Profiles map[string]Profile `yaml:"profiles"`
+	// Version is the manifest format version. Version 1 files are still
+	// read; `devx migrate` rewrites them as version 2.
+	Version int `yaml:"version" jsonschema:"required,enum=1|2"`
+	// Include lists manifest fragments, as paths or globs relative to the
+	// including file, whose profiles, tools, setup steps and tasks are merged
+	// into this manifest. Defining the same service, dep, tool, step or task
+	// twice is an error.
+	Include  []string `yaml:"include,omitempty"`
+	Project  Project  `yaml:"project" jsonschema:"required"`
+	Registry Registry `yaml:"registry"`
+	// Ports controls what `devx up` does when a host port is already in use.
+	Ports PortSettings `yaml:"ports,omitempty"`
+	AI    *AIConfig    `yaml:"ai,omitempty"`
+	// Profiles maps profile names (local, ci, k8s, …) to the environment
+	// each one describes.
+	Profiles map[string]Profile `yaml:"profiles" jsonschema:"required"`
 	// Tools declares required SDKs, runtimes, and CLI tools for the project.
 	// Use `devx doctor` to check and `devx setup` (or `devx doctor --fix`) to install.
 	Tools []Tool `yaml:"tools,omitempty"`
 	// Setup declares ordered host-side commands to run after tool installation.
 	// Use `devx setup` to execute. RunOnce steps are skipped when unchanged.
 	Setup []SetupStep `yaml:"setup,omitempty"`
+	// Tasks declares named one-off commands, such as migrations or test
+	// suites, run with `devx run <task>` and listed by `devx tasks`.
+	Tasks map[string]Task `yaml:"tasks,omitempty"`
+	// Secrets declares sensitive values by name. Services and deps reference
+	// them through their own secrets list.
+	Secrets map[string]Secret `yaml:"secrets,omitempty"`
+
+	// Origins maps definition paths such as "profiles.local.services.api" or
+	// "tools.node" to the file they were declared in. Populated by Load.
+	Origins map[string]Origin `yaml:"-"`
+	// Positions maps the YAML path of every key and list item, such as
+	// "profiles.local.services.api.ports[0]", to where it was written.
+	Positions map[string]Origin `yaml:"-"`
+	// Variables records every ${VAR} reference that was interpolated, keyed
+	// by variable name.
+	Variables map[string]Variable `yaml:"-"`
+	// Unknown lists keys that match no manifest field, reported by Validate.
+	Unknown []UnknownField `yaml:"-"`
+	// Dir is the directory containing devx.yaml, against which build
+	// contexts are resolved. Populated by Load; empty for Parse, in which
+	// case ValidateProfile skips filesystem checks.
+	Dir string `yaml:"-"`
 }
 
 // AIConfig holds optional AI provider settings used by 'devx export' and
@@ -30,25 +65,81 @@ type Manifest struct {
 //	azure-openai: AZURE_OPENAI_KEY
 //	ollama:       no auth required
 type AIConfig struct {
-	Provider string `yaml:"provider"` // openai | anthropic | ollama | azure-openai
-	Model    string `yaml:"model"`
-	BaseURL  string `yaml:"baseURL,omitempty"` // override endpoint (e.g. Ollama or Azure)
+	Provider string `yaml:"provider" jsonschema:"required,enum=openai|anthropic|ollama|azure-openai"` // openai | anthropic | ollama | azure-openai
+	// Model is the model identifier, e.g. gpt-4o-mini, claude-3-5-haiku-latest, llama3.2.
+	Model   string `yaml:"model" jsonschema:"required"`
+	BaseURL string `yaml:"baseURL,omitempty"` // override endpoint (e.g. Ollama or Azure)
 }
 
 type Project struct {
-	Name           string `yaml:"name"`
-	DefaultProfile string `yaml:"defaultProfile"`
+	// Name is the project name, used as the Docker Compose project name.
+	Name string `yaml:"name" jsonschema:"required"`
+	// DefaultProfile is the profile used when --profile is not given.
+	DefaultProfile string `yaml:"defaultProfile" jsonschema:"required"`
+}
+
+type PortSettings struct {
+	// Auto moves a published host port that is already in use to the next
+	// free one instead of failing. The port chosen is kept on later runs
+	// while it stays free.
+	Auto bool `yaml:"auto,omitempty"`
 }
 
 type Registry struct {
+	// Prefix is prepended to every image, e.g. myregistry.azurecr.io. Leave
+	// empty for Docker Hub.
 	Prefix string `yaml:"prefix"`
 }
 
 type Profile struct {
+	// Extends names a base profile this profile inherits from. Mappings deep-
+	// merge, lists replace, and a null value removes an inherited key.
+	// Resolved at load time, so the decoded profile is already merged.
+	Extends  string             `yaml:"extends,omitempty"`
 	Services map[string]Service `yaml:"services"`
 	Deps     map[string]Dep     `yaml:"deps"`
-	Runtime  string             `yaml:"runtime"`
-	Hooks    Hooks              `yaml:"hooks"`
+	// Runtime selects how the profile is run: Docker Compose (default) or
+	// Kubernetes via kubectl.
+	Runtime string `yaml:"runtime" jsonschema:"enum=compose|k8s"`
+	// ContainerRuntime picks the container runtime that runs a compose
+	// profile instead of the first one found. --runtime and DEVX_RUNTIME
+	// take precedence.
+	ContainerRuntime ContainerRuntime `yaml:"containerRuntime,omitempty"`
+	Hooks            Hooks            `yaml:"hooks"`
+}
+
+// ContainerRuntime selects and configures a container runtime. A plain
+// string is shorthand for its name.
+type ContainerRuntime struct {
+	// Name is the runtime: docker, podman, nerdctl for containerd, or
+	// docker-engine to talk to the Docker daemon's API without the CLI.
+	Name string `yaml:"name" jsonschema:"required,enum=docker|podman|nerdctl|docker-engine"`
+	// Binary is the runtime's CLI when it is not the one on PATH.
+	Binary string `yaml:"binary,omitempty"`
+	// Context is the Docker context to use. docker only.
+	Context string `yaml:"context,omitempty"`
+	// Host is the daemon's address, as in DOCKER_HOST. docker and
+	// docker-engine only.
+	Host string `yaml:"host,omitempty"`
+}
+
+// UnmarshalYAML accepts the runtime's name or the mapping form.
+func (c *ContainerRuntime) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*c = ContainerRuntime{Name: node.Value}
+		return nil
+	}
+	type plain ContainerRuntime
+	return node.Decode((*plain)(c))
+}
+
+// MarshalYAML writes the name alone when nothing else is set.
+func (c ContainerRuntime) MarshalYAML() (any, error) {
+	if c.Binary == "" && c.Context == "" && c.Host == "" {
+		return c.Name, nil
+	}
+	type plain ContainerRuntime
+	return plain(c), nil
 }
 
 // Hooks defines commands to run at lifecycle points around devx up/down.
@@ -75,29 +166,40 @@ type Hook struct {
 	Name       string `yaml:"name,omitempty"`
 }
 
+// Service is an application container, run from an image or built from
+// local source.
 type Service struct {
-	Image     string            `yaml:"image"`
-	Build     *Build            `yaml:"build"`
-	Ports     []string          `yaml:"ports"`
-	Env       map[string]string `yaml:"env"`
-	Command   []string          `yaml:"command"`
-	Workdir   string            `yaml:"workdir"`
-	Mount     []string          `yaml:"mount"`
-	DependsOn []string          `yaml:"dependsOn"`
-	Health    *Health           `yaml:"health"`
+	// Image is the image to run. Required for k8s even when Build is set.
+	Image string `yaml:"image"`
+	Build *Build `yaml:"build"`
+	// Ports lists the ports the service listens on and, optionally, the
+	// host ports they are published as.
+	Ports []Port            `yaml:"ports"`
+	Env   map[string]string `yaml:"env"`
+	// Command overrides the container command.
+	Command []string `yaml:"command"`
+	Workdir string   `yaml:"workdir"`
+	// Mount lists bind mounts as "hostPath:containerPath[:options]". Not
+	// supported by the k8s runtime.
+	Mount []string `yaml:"mount"`
+	// DependsOn names services or deps that must start first.
+	DependsOn []string `yaml:"dependsOn"`
+	Health    *Health  `yaml:"health"`
+	// Secrets names entries of the top-level secrets block to mount at
+	// /run/secrets/<name>.
+	Secrets []string `yaml:"secrets,omitempty"`
+	// Watch lists what `devx up --watch` does when files change. Without
+	// it, a service with build is rebuilt when its build context changes.
+	Watch []WatchRule `yaml:"watch,omitempty"`
 }
 
 type Build struct {
-	Context    string `yaml:"context"`
+	// Context is the build context directory, relative to devx.yaml.
+	Context string `yaml:"context"`
+	// Dockerfile is relative to Context. Defaults to Dockerfile.
 	Dockerfile string `yaml:"dockerfile"`
 }
 
-type Health struct {
-	HttpGet  string `yaml:"httpGet"`
-	Interval string `yaml:"interval"`
-	Retries  int    `yaml:"retries"`
-}
-
 // Dep is a third-party dependency (database, cache, broker, …) that devx
 // runs as a container. The project fully controls which image to run via
 // Image.
@@ -114,46 +216,154 @@ type Health struct {
 // If Env is omitted and AI is configured in the manifest, devx scans the
 // service source directory and uses the LLM to detect the correct env var names.
 type Dep struct {
-	Kind    string `yaml:"kind,omitempty"`
-	Source  string `yaml:"source,omitempty"`
+	// Kind is the provider type, e.g. postgres or redis.
+	Kind string `yaml:"kind,omitempty"`
+	// Source is the GitHub org/name of the provider. Defaults to devx-labs/<kind>.
+	Source string `yaml:"source,omitempty"`
+	// Version is the provider version; required when Kind is set.
 	Version string `yaml:"version,omitempty"`
-	Image   string `yaml:"image,omitempty"`
+	// Image is the image to run. Optional when Kind is set, in which case
+	// the provider's default image is used.
+	Image string `yaml:"image,omitempty"`
 
-	Env     map[string]string `yaml:"env"`
-	Ports   []string          `yaml:"ports"`
-	Volume  string            `yaml:"volume"`
-	Connect []ConnectEntry    `yaml:"connect,omitempty"`
+	Env   map[string]string `yaml:"env"`
+	Ports []Port            `yaml:"ports"`
+	// Volume is a single named volume as "volumeName:containerPath".
+	Volume  string         `yaml:"volume"`
+	Connect []ConnectEntry `yaml:"connect,omitempty"`
+	// Secrets names entries of the top-level secrets block to mount at
+	// /run/secrets/<name>, e.g. for POSTGRES_PASSWORD_FILE.
+	Secrets []string `yaml:"secrets,omitempty"`
 }
 
 // ConnectEntry declares a service that a dep should inject connection
 // environment variables into. Env values support template variables:
 //   - ${host}   — the dep's service name within the compose network
 //   - ${port}   — the first container-side port declared in dep.ports
+//   - ${port.<name>} — the container-side port of the dep port with that name
+//   - ${hostPort}, ${hostPort.<name>} — the same ports as published on the
+//     host, after any move by ports.auto
 //   - ${<KEY>}  — any key from the dep's own env block (e.g. ${POSTGRES_PASSWORD})
 //
 // If Env is omitted and devx.yaml has an ai block, devx calls the LLM to
 // detect appropriate env var names by scanning the service's build context.
 type ConnectEntry struct {
-	Service string            `yaml:"service"`
-	Env     map[string]string `yaml:"env,omitempty"`
+	// Service is the service to inject connection env vars into.
+	Service string `yaml:"service" jsonschema:"required"`
+	// Env maps env var names to templates such as
+	// "postgres://postgres:${POSTGRES_PASSWORD}@${host}:${port}/app".
+	Env map[string]string `yaml:"env,omitempty"`
 }
 
+// Load reads the manifest at path, merges any fragments it includes and
+// interpolates ${VAR} references from the environment, .env and
+// .env.<defaultProfile>.
 func Load(path string) (*Manifest, error) {
-	data, err := os.ReadFile(path)
+	return LoadWithOptions(path, LoadOptions{})
+}
+
+// LoadWithOptions is Load with control over which profile's .env file is
+// read and how environment variables are looked up. Manifests written for
+// an older version are upgraded to CurrentVersion as they are read.
+func LoadWithOptions(path string, opts LoadOptions) (*Manifest, error) {
+	root, err := readNode(path)
+	if err != nil {
+		return nil, err
+	}
+
+	src := newSourceMap(manifestVersion(root))
+	src.scan(root, path)
+	if err := resolveIncludes(root, path, src, []string{path}); err != nil {
+		return nil, err
+	}
+	if err := resolveExtends(root, src.origins); err != nil {
+		return nil, err
+	}
+
+	profile := opts.Profile
+	if profile == "" {
+		profile = defaultProfileOf(root)
+	}
+	sources, err := envSources(filepath.Dir(path), profile, opts.LookupEnv)
 	if err != nil {
 		return nil, err
 	}
-	return Parse(data)
+	vars, err := interpolate(root, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := decode(root)
+	if err != nil {
+		return nil, err
+	}
+	m.Origins = src.origins
+	m.Positions = src.positions
+	m.Variables = vars
+	m.Unknown = src.unknown
+	m.Dir = filepath.Dir(path)
+	return m, nil
 }
 
+// Parse decodes a single manifest document, interpolating ${VAR} references
+// from the process environment. Include directives and .env files are not
+// read; use Load for that.
 func Parse(data []byte) (*Manifest, error) {
+	root, err := parseNode(data)
+	if err != nil {
+		return nil, err
+	}
+	version := manifestVersion(root)
+	upgradeNode(root, version)
+	positions := map[string]Origin{}
+	recordPositions(root, "", "", positions)
+	unknown := unknownFields(root, "", version)
+	if err := resolveExtends(root, nil); err != nil {
+		return nil, err
+	}
+	vars, err := interpolate(root, []envSource{{name: "environment", lookup: os.LookupEnv}})
+	if err != nil {
+		return nil, err
+	}
+	m, err := decode(root)
+	if err != nil {
+		return nil, err
+	}
+	m.Positions = positions
+	m.Variables = vars
+	m.Unknown = unknown
+	return m, nil
+}
+
+func decode(root *yaml.Node) (*Manifest, error) {
 	var m Manifest
-	if err := yaml.Unmarshal(data, &m); err != nil {
+	if err := root.Decode(&m); err != nil {
 		return nil, fmt.Errorf("failed to parse manifest: %w", err)
 	}
 	return &m, nil
 }
 
+// OriginOf returns where the definition at path was declared, if known.
+func (m *Manifest) OriginOf(path string) (Origin, bool) {
+	o, ok := m.Origins[path]
+	return o, ok
+}
+
+// MultiFile reports whether the manifest was assembled from more than one file.
+func (m *Manifest) MultiFile() bool {
+	var first string
+	for _, o := range m.Origins {
+		if first == "" {
+			first = o.File
+		} else if o.File != first {
+			return true
+		}
+	}
+	return false
+}
+
+// ProfileByName returns the named profile with any extends chain already
+// merged in.
 func ProfileByName(m *Manifest, name string) (*Profile, error) {
 	prof, ok := m.Profiles[name]
 	if !ok {
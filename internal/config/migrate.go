@@ -0,0 +1,343 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MigratedFile is one manifest file as Migrate would rewrite it. Migrated
+// equals Original when the file needs no change.
+type MigratedFile struct {
+	Path     string
+	Original []byte
+	Migrated []byte
+}
+
+// migrations compute the text edits that take a file from version N to
+// N+1, keyed by N. Each one has a matching in-memory upgrader.
+var migrations = map[int]func(root *yaml.Node, src []byte) ([]textEdit, error){
+	1: migrateV1,
+}
+
+// Migrate rewrites the manifest at path, and every fragment it includes,
+// for CurrentVersion. Edits are made to the original text, so comments,
+// blank lines and key order are kept. Nothing is written to disk.
+func Migrate(path string) ([]MigratedFile, error) {
+	root, err := readNode(path)
+	if err != nil {
+		return nil, err
+	}
+	version := manifestVersion(root)
+	if version < 1 || version > CurrentVersion {
+		return nil, fmt.Errorf("%s: cannot migrate from version %s", path, scalarOr(mappingValue(root, "version"), "(missing)"))
+	}
+
+	paths, err := manifestFiles(path, root, []string{path})
+	if err != nil {
+		return nil, err
+	}
+	var files []MigratedFile
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		migrated, err := migrateFile(data, version)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		files = append(files, MigratedFile{Path: p, Original: data, Migrated: migrated})
+	}
+	return files, nil
+}
+
+// manifestFiles returns file followed by every fragment it includes,
+// depth first.
+func manifestFiles(file string, node *yaml.Node, stack []string) ([]string, error) {
+	files := []string{file}
+	inc := mappingValue(node, "include")
+	if inc == nil {
+		return files, nil
+	}
+	var patterns []string
+	if err := inc.Decode(&patterns); err != nil {
+		return nil, fmt.Errorf("%s: include must be a list of paths: %w", file, err)
+	}
+	for _, pattern := range patterns {
+		paths, err := expandInclude(filepath.Dir(file), pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for _, path := range paths {
+			if containsPath(stack, path) {
+				return nil, fmt.Errorf("include cycle detected: %s", strings.Join(append(stack, path), " -> "))
+			}
+			frag, err := readNode(path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: include %q: %w", file, pattern, err)
+			}
+			sub, err := manifestFiles(path, frag, append(stack, path))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, sub...)
+		}
+	}
+	return files, nil
+}
+
+// migrateFile applies each migration from version onwards to one file, then
+// checks that the result decodes to the same manifest the in-memory upgrade
+// produces.
+func migrateFile(data []byte, version int) ([]byte, error) {
+	out := data
+	for v := version; v < CurrentVersion; v++ {
+		node, err := parseNode(out)
+		if err != nil {
+			return nil, err
+		}
+		edits, err := migrations[v](node, out)
+		if err != nil {
+			return nil, err
+		}
+		out = applyEdits(out, edits)
+	}
+	if bytes.Equal(out, data) {
+		return out, nil
+	}
+
+	before, err := parseNode(data)
+	if err != nil {
+		return nil, err
+	}
+	upgradeNode(before, version)
+	after, err := parseNode(out)
+	if err != nil {
+		return nil, fmt.Errorf("migrated file does not parse: %w", err)
+	}
+	want, err := decode(before)
+	if err != nil {
+		return nil, err
+	}
+	got, err := decode(after)
+	if err != nil {
+		return nil, fmt.Errorf("migrated file does not decode: %w", err)
+	}
+	got.Version = want.Version
+	if !reflect.DeepEqual(want, got) {
+		return nil, fmt.Errorf("migration would change the manifest's meaning; please report this with the file attached")
+	}
+	return out, nil
+}
+
+// migrateV1 bumps version to 2, rewrites health.httpGet as health.http.url
+// and writes ports that have a single host and container port as mappings.
+// Port ranges and ports built from ${VAR} references keep the short form,
+// which version 2 still accepts.
+func migrateV1(root *yaml.Node, src []byte) ([]textEdit, error) {
+	lines := newLineIndex(src)
+	var edits []textEdit
+
+	if v := mappingValue(root, "version"); v != nil && v.Kind == yaml.ScalarNode {
+		start := lines.offset(v.Line, v.Column)
+		end, err := scalarEnd(src, start, v, false)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, textEdit{start, end, "2"})
+	}
+
+	var portErr error
+	rewritePorts := func(entry *yaml.Node) {
+		ports := mappingValue(entry, "ports")
+		if ports == nil || ports.Kind != yaml.SequenceNode {
+			return
+		}
+		flow := ports.Style&yaml.FlowStyle != 0
+		for _, item := range ports.Content {
+			if item.Kind != yaml.ScalarNode || item.Anchor != "" {
+				continue
+			}
+			// Ranges and invalid specs stay in short form.
+			p, err := ParsePort(item.Value)
+			if err != nil || p.Container.Size() > 1 || p.Host.Size() > 1 {
+				continue
+			}
+			start := lines.offset(item.Line, item.Column)
+			end, err := scalarEnd(src, start, item, flow)
+			if err != nil {
+				portErr = err
+				return
+			}
+			edits = append(edits, textEdit{start, end, portMapping(p)})
+		}
+	}
+	forEachEntry(root, "services", rewritePorts)
+	forEachEntry(root, "deps", rewritePorts)
+	if portErr != nil {
+		return nil, portErr
+	}
+
+	var healthErr error
+	forEachEntry(root, "services", func(svc *yaml.Node) {
+		health := mappingValue(svc, "health")
+		if health == nil || health.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(health.Content); i += 2 {
+			key, val := health.Content[i], health.Content[i+1]
+			if key.Value != "httpGet" || val.Kind != yaml.ScalarNode {
+				continue
+			}
+			keyStart := lines.offset(key.Line, key.Column)
+			valStart := lines.offset(val.Line, val.Column)
+			if health.Style&yaml.FlowStyle != 0 {
+				valEnd, err := scalarEnd(src, valStart, val, true)
+				if err != nil {
+					healthErr = err
+					return
+				}
+				edits = append(edits, textEdit{keyStart, valStart, "http: {url: "}, textEdit{valEnd, valEnd, "}"})
+				continue
+			}
+			indent := strings.Repeat(" ", key.Column-1)
+			edits = append(edits, textEdit{keyStart, valStart, "http:\n" + indent + "  url: "})
+		}
+	})
+	if healthErr != nil {
+		return nil, healthErr
+	}
+	return edits, nil
+}
+
+// portMapping formats a port as a flow mapping, valid both as a block
+// sequence item and inside a flow sequence.
+func portMapping(p Port) string {
+	var fields []string
+	if p.HostIP != "" {
+		fields = append(fields, "hostIP: "+quoteIfNeeded(p.HostIP))
+	}
+	if !p.Host.IsZero() {
+		fields = append(fields, "host: "+p.Host.String())
+	}
+	fields = append(fields, "container: "+p.Container.String())
+	if p.Protocol != "" {
+		fields = append(fields, "protocol: "+p.Protocol)
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+// quoteIfNeeded double-quotes IPv6 addresses, whose colons would otherwise
+// be read as mapping separators inside a flow mapping.
+func quoteIfNeeded(s string) string {
+	if strings.Contains(s, ":") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+func scalarOr(n *yaml.Node, fallback string) string {
+	if n == nil {
+		return fallback
+	}
+	return n.Value
+}
+
+// textEdit replaces src[start:end] with text.
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+func applyEdits(src []byte, edits []textEdit) []byte {
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := append([]byte{}, src...)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+	return out
+}
+
+// lineIndex converts the 1-based line and column yaml reports, which count
+// characters, into byte offsets.
+type lineIndex struct {
+	src    []byte
+	starts []int
+}
+
+func newLineIndex(src []byte) lineIndex {
+	starts := []int{0}
+	for i, b := range src {
+		if b == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return lineIndex{src: src, starts: starts}
+}
+
+func (l lineIndex) offset(line, column int) int {
+	off := l.starts[line-1]
+	for c := 1; c < column && off < len(l.src); c++ {
+		_, size := utf8.DecodeRune(l.src[off:])
+		off += size
+	}
+	return off
+}
+
+// scalarEnd returns the offset just past the scalar starting at start.
+// Only single-line scalars are supported; anything else is reported rather
+// than risk a wrong edit.
+func scalarEnd(src []byte, start int, n *yaml.Node, inFlow bool) (int, error) {
+	unsupported := fmt.Errorf("line %d: cannot rewrite value %q in place; edit it by hand and run migrate again", n.Line, n.Value)
+	switch {
+	case n.Style&yaml.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(src) && src[i] != '\n'; i++ {
+			switch src[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, nil
+			}
+		}
+		return 0, unsupported
+	case n.Style&yaml.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(src) && src[i] != '\n'; i++ {
+			if src[i] == '\'' {
+				if i+1 < len(src) && src[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1, nil
+			}
+		}
+		return 0, unsupported
+	case n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+		return 0, unsupported
+	}
+	end := start
+	for end < len(src) {
+		c := src[end]
+		if c == '\n' || c == '\r' || (inFlow && (c == ',' || c == ']' || c == '}')) {
+			break
+		}
+		if c == '#' && end > start && (src[end-1] == ' ' || src[end-1] == '\t') {
+			break
+		}
+		end++
+	}
+	for end > start && (src[end-1] == ' ' || src[end-1] == '\t') {
+		end--
+	}
+	if string(src[start:end]) != n.Value {
+		return 0, unsupported
+	}
+	return end, nil
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const migrateRootV1 = `# Project manifest
+version: 1 # bump with devx migrate
+
+project:
+  name: my-app
+  defaultProfile: local
+
+include:
+  - jobs.yaml
+
+profiles:
+  local:
+    services:
+      api:
+        image: nginx:alpine
+        ports:
+          - "8080:80"   # public
+          - '127.0.0.1:9090:9090/udp'
+          - 7000-7001:7000-7001
+        health:
+          httpGet: "http://localhost:8080/health"
+          interval: 5s
+
+      web:
+        image: nginx:alpine
+        ports: ["3000:3000", "${WEB_PORT:-3001}:80"]
+        health: {httpGet: http://localhost:3000/, retries: 3}
+`
+
+const migrateRootV2 = `# Project manifest
+version: 2 # bump with devx migrate
+
+project:
+  name: my-app
+  defaultProfile: local
+
+include:
+  - jobs.yaml
+
+profiles:
+  local:
+    services:
+      api:
+        image: nginx:alpine
+        ports:
+          - {host: 8080, container: 80}   # public
+          - {hostIP: 127.0.0.1, host: 9090, container: 9090, protocol: udp}
+          - 7000-7001:7000-7001
+        health:
+          http:
+            url: "http://localhost:8080/health"
+          interval: 5s
+
+      web:
+        image: nginx:alpine
+        ports: [{host: 3000, container: 3000}, "${WEB_PORT:-3001}:80"]
+        health: {http: {url: http://localhost:3000/}, retries: 3}
+`
+
+func TestMigrate_RewritesInPlace(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml": migrateRootV1,
+		"jobs.yaml": "profiles:\n  local:\n    deps:\n      db:\n        image: postgres:16\n        ports:\n          - 5432 # container only\n",
+	})
+
+	files, err := Migrate(filepath.Join(dir, "devx.yaml"))
+	if err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected root and fragment, got %d files", len(files))
+	}
+	if got := string(files[0].Migrated); got != migrateRootV2 {
+		t.Errorf("unexpected root:\n%s", got)
+	}
+	if got, want := string(files[1].Migrated), "profiles:\n  local:\n    deps:\n      db:\n        image: postgres:16\n        ports:\n          - {container: 5432} # container only\n"; got != want {
+		t.Errorf("unexpected fragment:\n%s", got)
+	}
+}
+
+func TestMigrate_CurrentVersionUnchanged(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"devx.yaml": migrateRootV2, "jobs.yaml": "profiles: {}\n"})
+	files, err := Migrate(filepath.Join(dir, "devx.yaml"))
+	if err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	for _, f := range files {
+		if string(f.Original) != string(f.Migrated) {
+			t.Errorf("%s changed:\n%s", f.Path, f.Migrated)
+		}
+	}
+}
+
+func TestLoad_V1AndV2Equivalent(t *testing.T) {
+	v1 := writeFiles(t, map[string]string{"devx.yaml": migrateRootV1, "jobs.yaml": "profiles: {}\n"})
+	v2 := writeFiles(t, map[string]string{"devx.yaml": migrateRootV2, "jobs.yaml": "profiles: {}\n"})
+
+	m1, err := Load(filepath.Join(v1, "devx.yaml"))
+	if err != nil {
+		t.Fatalf("load v1 failed: %v", err)
+	}
+	m2, err := Load(filepath.Join(v2, "devx.yaml"))
+	if err != nil {
+		t.Fatalf("load v2 failed: %v", err)
+	}
+	if len(m1.Unknown) != 0 || len(m2.Unknown) != 0 {
+		t.Fatalf("unexpected unknown fields: %v %v", m1.Unknown, m2.Unknown)
+	}
+	if !reflect.DeepEqual(m1.Profiles, m2.Profiles) {
+		t.Fatalf("profiles differ:\n%+v\n%+v", m1.Profiles, m2.Profiles)
+	}
+	api := m2.Profiles["local"].Services["api"]
+	if api.Health.HTTP.URL != "http://localhost:8080/health" {
+		t.Errorf("unexpected health URL %q", api.Health.HTTP.URL)
+	}
+	if got := PortSpecs(api.Ports); strings.Join(got, " ") != "8080:80 127.0.0.1:9090:9090/udp 7000-7001:7000-7001" {
+		t.Errorf("unexpected ports %v", got)
+	}
+
+	// The v1 health key is reported against its v1 position.
+	if o := m1.Positions["profiles.local.services.api.health.http.url"]; o.Line != 21 {
+		t.Errorf("expected health url at line 21, got %+v", o)
+	}
+}
+
+func TestValidate_Version(t *testing.T) {
+	for version, want := range map[string]string{"1": "deprecated", "2": "", "3": "unsupported-version"} {
+		m, err := Parse([]byte("version: " + version + "\nproject:\n  name: a\n  defaultProfile: local\nprofiles:\n  local: {}\n"))
+		if err != nil {
+			t.Fatalf("parse failed: %v", err)
+		}
+		var rules []string
+		for _, issue := range ManifestIssues(m) {
+			rules = append(rules, issue.Rule)
+		}
+		if strings.Join(rules, ",") != want {
+			t.Errorf("version %s: got rules %v, want %q", version, rules, want)
+		}
+	}
+}
+
+func TestUnknownFields_RenamedKey(t *testing.T) {
+	m, err := Parse([]byte(`version: 2
+project:
+  name: my-app
+  defaultProfile: local
+profiles:
+  local:
+    services:
+      api:
+        image: nginx
+        health:
+          httpGet: http://localhost/health
+`))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(m.Unknown) != 1 || m.Unknown[0].Suggestion != "http.url" {
+		t.Fatalf("expected httpGet to point at http.url, got %+v", m.Unknown)
+	}
+}
@@ -0,0 +1,364 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Port is a port a service or dep exposes. It is written either in the
+// short form "[hostIP:][hostPort:]containerPort[/protocol]" or as a mapping.
+type Port struct {
+	// Name identifies the port in connect templates (${port.<name>}) and
+	// names it in rendered Kubernetes manifests. Only available in the
+	// mapping form.
+	Name string `yaml:"name,omitempty"`
+	// Container is the port the process listens on inside the container, or
+	// a range such as 8000-8010.
+	Container PortRange `yaml:"container" jsonschema:"required"`
+	// Host is the port published on the host. Omit to expose the port to
+	// other containers only. A range of the same size as Container maps
+	// port for port; a range with a single container port lets the runtime
+	// pick any free host port in it.
+	Host PortRange `yaml:"host,omitempty"`
+	// HostIP restricts the published port to one host address, e.g. 127.0.0.1.
+	HostIP string `yaml:"hostIP,omitempty"`
+	// Protocol defaults to tcp.
+	Protocol string `yaml:"protocol,omitempty" jsonschema:"enum=tcp|udp|sctp"`
+
+	// raw holds a short-form spec that does not parse, so ValidateProfile
+	// can report it.
+	raw string
+}
+
+// PortRange is a single port or an inclusive range of ports. It is written
+// as a number or as a "first-last" string.
+type PortRange struct {
+	First int
+	// Last is the end of a range, or zero for a single port.
+	Last int
+}
+
+// IsZero reports whether the range is unset.
+func (r PortRange) IsZero() bool { return r.First == 0 }
+
+// Size returns the number of ports in the range.
+func (r PortRange) Size() int {
+	if r.IsZero() {
+		return 0
+	}
+	return r.end() - r.First + 1
+}
+
+func (r PortRange) end() int {
+	if r.Last == 0 {
+		return r.First
+	}
+	return r.Last
+}
+
+func (r PortRange) overlaps(o PortRange) bool {
+	return r.First <= o.end() && o.First <= r.end()
+}
+
+func (r PortRange) String() string {
+	if r.Size() <= 1 {
+		return strconv.Itoa(r.First)
+	}
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// Ports returns every port in the range.
+func (r PortRange) Ports() []int {
+	out := make([]int, 0, r.Size())
+	for p := r.First; p <= r.end(); p++ {
+		out = append(out, p)
+	}
+	return out
+}
+
+// UnmarshalYAML accepts a number or a "first-last" string.
+func (r *PortRange) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: port must be a number or a range such as 8000-8010", node.Line)
+	}
+	first, last, isRange := strings.Cut(node.Value, "-")
+	var err error
+	if r.First, err = strconv.Atoi(first); err != nil {
+		return fmt.Errorf("line %d: port '%s' is not a number", node.Line, node.Value)
+	}
+	if isRange {
+		if r.Last, err = strconv.Atoi(last); err != nil {
+			return fmt.Errorf("line %d: port range '%s' is not valid", node.Line, node.Value)
+		}
+	}
+	if r.Last == r.First {
+		r.Last = 0
+	}
+	return nil
+}
+
+// MarshalYAML writes single ports as numbers and ranges as strings.
+func (r PortRange) MarshalYAML() (any, error) {
+	if r.Size() <= 1 {
+		return r.First, nil
+	}
+	return r.String(), nil
+}
+
+// UnmarshalYAML accepts both the short string form and the mapping form.
+func (p *Port) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		parsed, err := ParsePort(node.Value)
+		if err != nil {
+			parsed = Port{raw: node.Value}
+		}
+		*p = parsed
+		return nil
+	}
+	type plain Port
+	return node.Decode((*plain)(p))
+}
+
+// MarshalYAML writes the port in short form unless it is named.
+func (p Port) MarshalYAML() (any, error) {
+	if p.Name != "" {
+		type plain Port
+		return plain(p), nil
+	}
+	return p.String(), nil
+}
+
+// ParsePort parses a port in the short syntax accepted by Docker Compose.
+// IPv6 host addresses must be bracketed: "[::1]:8080:80".
+func ParsePort(spec string) (Port, error) {
+	var p Port
+	rest := spec
+	if idx := strings.LastIndexByte(rest, '/'); idx >= 0 {
+		p.Protocol = rest[idx+1:]
+		rest = rest[:idx]
+		if err := checkProtocol(p.Protocol); err != nil {
+			return p, err
+		}
+		if p.Protocol == "tcp" {
+			p.Protocol = ""
+		}
+	}
+
+	if strings.HasPrefix(rest, "[") {
+		end := strings.IndexByte(rest, ']')
+		if end < 0 || !strings.HasPrefix(rest[end+1:], ":") {
+			return p, fmt.Errorf("bracketed host IP must be followed by ':'")
+		}
+		p.HostIP = rest[1:end]
+		rest = rest[end+2:]
+		if net.ParseIP(p.HostIP) == nil {
+			return p, fmt.Errorf("host IP '%s' is not a valid IP address", p.HostIP)
+		}
+	}
+
+	parts := strings.Split(rest, ":")
+	var hostPart, containerPart string
+	switch {
+	case len(parts) == 1:
+		containerPart = parts[0]
+	case len(parts) == 2:
+		hostPart, containerPart = parts[0], parts[1]
+	case len(parts) == 3 && p.HostIP == "":
+		p.HostIP, hostPart, containerPart = parts[0], parts[1], parts[2]
+		if net.ParseIP(p.HostIP) == nil {
+			return p, fmt.Errorf("host IP '%s' is not a valid IP address", p.HostIP)
+		}
+	default:
+		return p, fmt.Errorf("expected [hostIP:][hostPort:]containerPort[/protocol] (bracket IPv6 addresses)")
+	}
+
+	var err error
+	if p.Container, err = parsePortRange(containerPart, "container"); err != nil {
+		return p, err
+	}
+	if hostPart == "" {
+		if len(parts) == 2 && p.HostIP == "" {
+			return p, fmt.Errorf("host port is empty")
+		}
+		return p, nil
+	}
+	if p.Host, err = parsePortRange(hostPart, "host"); err != nil {
+		return p, err
+	}
+	return p, checkRangeSizes(p)
+}
+
+func parsePortRange(s, side string) (PortRange, error) {
+	if s == "" {
+		return PortRange{}, fmt.Errorf("%s port is empty", side)
+	}
+	first, last, isRange := strings.Cut(s, "-")
+	var r PortRange
+	var err error
+	if r.First, err = parsePort(first, side); err != nil {
+		return r, err
+	}
+	if isRange {
+		if r.Last, err = parsePort(last, side); err != nil {
+			return r, err
+		}
+		if r.Last < r.First {
+			return r, fmt.Errorf("%s port range '%s' ends before it starts", side, s)
+		}
+		if r.Last == r.First {
+			r.Last = 0
+		}
+	}
+	return r, nil
+}
+
+func parsePort(s, side string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s port '%s' is not a number", side, s)
+	}
+	if err := checkPortNumber(n, side); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func checkPortNumber(n int, side string) error {
+	if n < 1 || n > 65535 {
+		return fmt.Errorf("%s port %d is out of range 1-65535", side, n)
+	}
+	return nil
+}
+
+func checkPortRange(r PortRange, side string) error {
+	if err := checkPortNumber(r.First, side); err != nil {
+		return err
+	}
+	if r.Last != 0 {
+		if err := checkPortNumber(r.Last, side); err != nil {
+			return err
+		}
+		if r.Last < r.First {
+			return fmt.Errorf("%s port range '%d-%d' ends before it starts", side, r.First, r.Last)
+		}
+	}
+	return nil
+}
+
+func checkRangeSizes(p Port) error {
+	if p.Container.Size() > 1 && p.Host.Size() != p.Container.Size() {
+		return fmt.Errorf("host range %s and container range %s differ in size", p.Host, p.Container)
+	}
+	return nil
+}
+
+func checkProtocol(protocol string) error {
+	switch protocol {
+	case "", "tcp", "udp", "sctp":
+		return nil
+	}
+	return fmt.Errorf("protocol '%s' must be tcp, udp or sctp", protocol)
+}
+
+// portNamePattern matches an IANA service name, which is what Kubernetes
+// accepts as a port name.
+var portNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// check reports why a port is invalid, or nil.
+func (p Port) check() error {
+	if p.raw != "" {
+		_, err := ParsePort(p.raw)
+		return err
+	}
+	if err := checkProtocol(p.Protocol); err != nil {
+		return err
+	}
+	if p.HostIP != "" && net.ParseIP(p.HostIP) == nil {
+		return fmt.Errorf("host IP '%s' is not a valid IP address", p.HostIP)
+	}
+	if err := checkPortRange(p.Container, "container"); err != nil {
+		return err
+	}
+	if !p.Host.IsZero() {
+		if err := checkPortRange(p.Host, "host"); err != nil {
+			return err
+		}
+		if err := checkRangeSizes(p); err != nil {
+			return err
+		}
+	}
+	if p.Name != "" {
+		if len(p.Name) > 15 || !portNamePattern.MatchString(p.Name) || strings.Contains(p.Name, "--") || !strings.ContainsAny(p.Name, "abcdefghijklmnopqrstuvwxyz") {
+			return fmt.Errorf("name '%s' must be at most 15 lowercase letters, digits and single hyphens, with at least one letter", p.Name)
+		}
+		if p.Container.Size() > 1 {
+			return fmt.Errorf("a named port cannot be a range")
+		}
+	}
+	return nil
+}
+
+// Proto returns the port's protocol, defaulting to tcp.
+func (p Port) Proto() string {
+	if p.Protocol == "" {
+		return "tcp"
+	}
+	return p.Protocol
+}
+
+// String returns the port in short form, as Docker Compose accepts it. The
+// name is not part of the short form.
+func (p Port) String() string {
+	if p.raw != "" {
+		return p.raw
+	}
+	var b strings.Builder
+	if p.HostIP != "" {
+		if strings.Contains(p.HostIP, ":") {
+			b.WriteString("[" + p.HostIP + "]:")
+		} else {
+			b.WriteString(p.HostIP + ":")
+		}
+	}
+	if !p.Host.IsZero() {
+		b.WriteString(p.Host.String() + ":")
+	} else if p.HostIP != "" {
+		b.WriteString(":")
+	}
+	b.WriteString(p.Container.String())
+	if p.Proto() != "tcp" {
+		b.WriteString("/" + p.Protocol)
+	}
+	return b.String()
+}
+
+// PortSpecs returns ports in short form.
+func PortSpecs(ports []Port) []string {
+	if ports == nil {
+		return nil
+	}
+	out := make([]string, len(ports))
+	for i, p := range ports {
+		out[i] = p.String()
+	}
+	return out
+}
+
+// sameHostAddress reports whether two host IPs can collide. An empty IP
+// binds every interface, so it collides with any address.
+func sameHostAddress(a, b string) bool {
+	if IsWildcardIP(a) || IsWildcardIP(b) {
+		return true
+	}
+	return net.ParseIP(a).Equal(net.ParseIP(b))
+}
+
+// IsWildcardIP reports whether ip binds every interface.
+func IsWildcardIP(ip string) bool {
+	return ip == "" || ip == "0.0.0.0" || ip == "::"
+}
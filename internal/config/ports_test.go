@@ -0,0 +1,118 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Port
+	}{
+		{"80", Port{Container: PortRange{First: 80}}},
+		{"8080:80", Port{Host: PortRange{First: 8080}, Container: PortRange{First: 80}}},
+		{"127.0.0.1:8080:80/udp", Port{HostIP: "127.0.0.1", Host: PortRange{First: 8080}, Container: PortRange{First: 80}, Protocol: "udp"}},
+		{"127.0.0.1::80", Port{HostIP: "127.0.0.1", Container: PortRange{First: 80}}},
+		{"[::1]:8080:80/tcp", Port{HostIP: "::1", Host: PortRange{First: 8080}, Container: PortRange{First: 80}}},
+		{"9000-9002:8000-8002", Port{Host: PortRange{9000, 9002}, Container: PortRange{8000, 8002}}},
+		{"9000-9010:80", Port{Host: PortRange{9000, 9010}, Container: PortRange{First: 80}}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePort(tt.spec)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParsePort_Errors(t *testing.T) {
+	tests := map[string]string{
+		"http":            "container port 'http' is not a number",
+		"8080:":           "container port is empty",
+		":80":             "host port is empty",
+		"70000:80":        "host port 70000 is out of range 1-65535",
+		"8080:80/icmp":    "protocol 'icmp' must be tcp, udp or sctp",
+		"localhost:80:80": "host IP 'localhost' is not a valid IP address",
+		"9000-9001:80-82": "host range 9000-9001 and container range 80-82 differ in size",
+		"9002-9000:80":    "host port range '9002-9000' ends before it starts",
+		"::1:8080:80":     "expected [hostIP:][hostPort:]containerPort[/protocol] (bracket IPv6 addresses)",
+	}
+	for spec, want := range tests {
+		_, err := ParsePort(spec)
+		if err == nil || err.Error() != want {
+			t.Errorf("%s: got error %v, want %q", spec, err, want)
+		}
+	}
+}
+
+func TestPort_ShortAndMappingForms(t *testing.T) {
+	tests := map[string]string{
+		"8080:80":             "8080:80",
+		"80":                  "80",
+		"127.0.0.1::53/udp":   "127.0.0.1::53/udp",
+		"[::1]:8080:80/tcp":   "[::1]:8080:80",
+		"9000-9001:9000-9001": "9000-9001:9000-9001",
+		"8080:http":           "8080:http",
+	}
+	for spec, want := range tests {
+		var p Port
+		if err := yaml.Unmarshal([]byte(`"`+spec+`"`), &p); err != nil {
+			t.Fatalf("%s: unmarshal failed: %v", spec, err)
+		}
+		if got := p.String(); got != want {
+			t.Errorf("%s: got %q, want %q", spec, got, want)
+		}
+	}
+
+	var ports []Port
+	src := `["8080:80", {container: 53, host: 5353, protocol: udp}, {name: metrics, container: 9000-9001, host: "9100-9101"}]`
+	if err := yaml.Unmarshal([]byte(src), &ports); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	want := []Port{
+		{Host: PortRange{First: 8080}, Container: PortRange{First: 80}},
+		{Host: PortRange{First: 5353}, Container: PortRange{First: 53}, Protocol: "udp"},
+		{Name: "metrics", Host: PortRange{9100, 9101}, Container: PortRange{9000, 9001}},
+	}
+	if !reflect.DeepEqual(ports, want) {
+		t.Fatalf("got %+v, want %+v", ports, want)
+	}
+
+	out, err := yaml.Marshal(ports)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if got := string(out); got != "- 8080:80\n- 5353:53/udp\n- name: metrics\n  container: 9000-9001\n  host: 9100-9101\n" {
+		t.Errorf("unexpected marshalled ports:\n%s", got)
+	}
+}
+
+func TestPortIssues_Names(t *testing.T) {
+	ports := []Port{
+		{Name: "http", Container: PortRange{First: 80}},
+		{Name: "http", Container: PortRange{First: 81}},
+		{Name: "Admin_UI", Container: PortRange{First: 82}},
+		{Name: "range", Container: PortRange{8000, 8001}},
+		{Name: "8080", Container: PortRange{First: 83}},
+	}
+	var got []string
+	for _, issue := range portIssues("service", "api", "services.api", ports) {
+		got = append(got, issue.Rule+" "+issue.Path)
+	}
+	want := []string{
+		"duplicate-name services.api.ports[1].name",
+		"invalid-port services.api.ports[2]",
+		"invalid-port services.api.ports[3]",
+		"invalid-port services.api.ports[4]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//go:generate go run gen_docs.go
+//go:generate go run ../../cmd/devx schema --out ../../schemas/devx.schema.json
+
+// SchemaID is the canonical location of the published schema, referenced by
+// the yaml-language-server modeline that `devx init` writes.
+const SchemaID = "https://raw.githubusercontent.com/dever-labs/devx/main/schemas/devx.schema.json"
+
+// Schema returns a JSON Schema (draft 2020-12) for devx.yaml derived from the
+// Manifest type. Field names come from yaml tags, descriptions from the doc
+// comments of each type and field (see docs_gen.go), and enums and required
+// fields from `jsonschema:"required,enum=a|b"` tags.
+func Schema() map[string]any {
+	defs := map[string]any{}
+	root := structSchema(reflect.TypeOf(Manifest{}), defs)
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = SchemaID
+	root["title"] = "devx manifest"
+	root["$defs"] = defs
+	return root
+}
+
+// SchemaJSON returns Schema as indented JSON with a trailing newline.
+func SchemaJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(Schema()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// typeSchema describes t, registering named struct types in defs and
+// returning a $ref to them.
+func typeSchema(t reflect.Type, defs map[string]any) map[string]any {
+	if scalar, ok := scalarForms[t.Name()]; ok {
+		return scalar()
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), defs)
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		// Map entries may be null so an extending profile can delete an
+		// inherited service, dep or env key.
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": map[string]any{"anyOf": []any{typeSchema(t.Elem(), defs), map[string]any{"type": "null"}}},
+		}
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			defs[t.Name()] = true // placeholder guards against recursive types
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		ref := map[string]any{"$ref": "#/$defs/" + t.Name()}
+		if short, ok := shortForms[t.Name()]; ok {
+			return map[string]any{"anyOf": []any{map[string]any{"type": "string", "description": short}, ref}}
+		}
+		return ref
+	}
+	return map[string]any{}
+}
+
+// shortForms describes the string form of struct types that accept one in
+// place of a mapping.
+var shortForms = map[string]string{
+	"Port":             "Short form: [hostIP:][hostPort:]containerPort[/protocol], e.g. \"8080:80\"",
+	"ContainerRuntime": "Short form: the runtime's name, e.g. \"podman\"",
+}
+
+// scalarForms describes named types that are written as scalars but whose
+// Go type would describe them wrongly.
+var scalarForms = map[string]func() map[string]any{
+	"PortRange": func() map[string]any {
+		return map[string]any{"anyOf": []any{
+			map[string]any{"type": "integer", "minimum": 1, "maximum": 65535},
+			map[string]any{"type": "string", "pattern": `^[0-9]+(-[0-9]+)?$`, "description": "A range such as 8000-8010"},
+		}}
+	},
+	"PortRef": func() map[string]any {
+		return map[string]any{"anyOf": []any{
+			map[string]any{"type": "integer", "minimum": 1, "maximum": 65535},
+			map[string]any{"type": "string", "description": "A port name"},
+		}}
+	},
+}
+
+func structSchema(t reflect.Type, defs map[string]any) map[string]any {
+	props := map[string]any{}
+	var required []string
+	for _, f := range schemaFields(t) {
+		s := typeSchema(f.Type, defs)
+		if doc := fieldDocs[t.Name()+"."+f.Name]; doc != "" {
+			s["description"] = doc
+		}
+		opts := strings.Split(f.Tag.Get("jsonschema"), ",")
+		for _, opt := range opts {
+			switch {
+			case opt == "required":
+				required = append(required, yamlName(f))
+			case strings.HasPrefix(opt, "enum="):
+				s["enum"] = enumValues(f.Type, strings.Split(strings.TrimPrefix(opt, "enum="), "|"))
+			}
+		}
+		props[yamlName(f)] = s
+	}
+
+	s := map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	if doc := typeDocs[t.Name()]; doc != "" {
+		s["description"] = doc
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// schemaFields returns the fields of t that appear in devx.yaml.
+func schemaFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || yamlName(f) == "-" {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// yamlName returns the key a struct field is decoded from.
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
+
+func enumValues(t reflect.Type, values []string) []any {
+	out := make([]any, 0, len(values))
+	for _, v := range values {
+		if t.Kind() == reflect.Int {
+			if n, err := strconv.Atoi(v); err == nil {
+				out = append(out, n)
+				continue
+			}
+		}
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchema_DescribesFields(t *testing.T) {
+	s := Schema()
+	defs := s["$defs"].(map[string]any)
+
+	profile := defs["Profile"].(map[string]any)["properties"].(map[string]any)
+	runtime := profile["runtime"].(map[string]any)
+	if got := runtime["enum"].([]any); len(got) != 2 || got[0] != "compose" || got[1] != "k8s" {
+		t.Fatalf("runtime enum = %v", got)
+	}
+	if desc, _ := profile["extends"].(map[string]any)["description"].(string); !strings.Contains(desc, "base profile") {
+		t.Fatalf("expected extends doc comment as description, got %q", desc)
+	}
+
+	step := defs["SetupStep"].(map[string]any)
+	if got := step["properties"].(map[string]any)["platform"].(map[string]any)["enum"].([]any); len(got) != 4 {
+		t.Fatalf("platform enum = %v", got)
+	}
+	if got := step["required"].([]string); len(got) != 2 || got[0] != "name" || got[1] != "run" {
+		t.Fatalf("setup step required = %v", got)
+	}
+	if _, ok := s["properties"].(map[string]any)["origins"]; ok {
+		t.Fatalf("yaml:\"-\" fields must not appear in the schema")
+	}
+}
+
+// The committed schema is what editors download; regenerate it with
+// `go generate ./internal/config`.
+func TestSchema_CommittedFileUpToDate(t *testing.T) {
+	want, err := SchemaJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join("..", "..", "schemas", "devx.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("schemas/devx.schema.json is stale — run 'go generate ./internal/config'")
+	}
+}
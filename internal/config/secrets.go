@@ -0,0 +1,66 @@
+package config
+
+import "github.com/dever-labs/devx/internal/util"
+
+// Secret declares where a sensitive value comes from. Exactly one source
+// must be set. Services and deps list the secrets they need under their own
+// secrets key; each is mounted read-only at /run/secrets/<name> (compose
+// secrets, or a Kubernetes Secret volume) and never rendered as an env value.
+//
+//	env:     read from an environment variable on the host
+//	file:    read from a file, relative to devx.yaml
+//	command: output of a shell command, e.g. "pass show my-app/db" or
+//	         "sops -d --extract '[\"db\"]' secrets.enc.yaml"
+//	store:   key in the local encrypted store managed by `devx secrets set`
+type Secret struct {
+	Env     string `yaml:"env,omitempty"`
+	File    string `yaml:"file,omitempty"`
+	Command string `yaml:"command,omitempty"`
+	Store   string `yaml:"store,omitempty"`
+}
+
+// Source returns the kind of source the secret uses (env, file, command or
+// store) and its reference. kind is "" when no source is set.
+func (s Secret) Source() (kind, ref string) {
+	switch {
+	case s.Env != "":
+		return "env", s.Env
+	case s.File != "":
+		return "file", s.File
+	case s.Command != "":
+		return "command", s.Command
+	case s.Store != "":
+		return "store", s.Store
+	}
+	return "", ""
+}
+
+func (s Secret) sourceCount() int {
+	n := 0
+	for _, v := range []string{s.Env, s.File, s.Command, s.Store} {
+		if v != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// SecretMountPath is the directory secrets are mounted under in containers.
+const SecretMountPath = "/run/secrets"
+
+// ProfileSecrets returns the sorted, de-duplicated names of every secret
+// referenced by the profile's services and deps.
+func ProfileSecrets(prof *Profile) []string {
+	seen := map[string]bool{}
+	for _, svc := range prof.Services {
+		for _, name := range svc.Secrets {
+			seen[name] = true
+		}
+	}
+	for _, dep := range prof.Deps {
+		for _, name := range dep.Secrets {
+			seen[name] = true
+		}
+	}
+	return util.SortedKeys(seen)
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateSecrets(t *testing.T) {
+	data := []byte(`version: 1
+project:
+  name: my-app
+  defaultProfile: local
+secrets:
+  db_password:
+    env: DB_PASSWORD
+  api_key:
+    env: API_KEY
+    file: ./api.key
+profiles:
+  local:
+    services:
+      api:
+        image: nginx:alpine
+        secrets: [api_key, missing]
+    deps:
+      db:
+        image: postgres:16
+        secrets: [db_password]
+`)
+
+	m, err := Parse(data)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	err = Validate(m)
+	if err == nil || !strings.Contains(err.Error(), "secret 'api_key' must set exactly one of") {
+		t.Fatalf("expected source error for api_key, got %v", err)
+	}
+
+	err = ValidateProfile(m, "local")
+	if err == nil || !strings.Contains(err.Error(), "service 'api' references secret 'missing' which is not declared") {
+		t.Fatalf("expected undeclared secret error, got %v", err)
+	}
+
+	prof := m.Profiles["local"]
+	got := ProfileSecrets(&prof)
+	want := []string{"api_key", "db_password", "missing"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ProfileSecrets = %v, want %v", got, want)
+	}
+}
+
+func TestSecretSource(t *testing.T) {
+	kind, ref := Secret{Command: "pass show db"}.Source()
+	if kind != "command" || ref != "pass show db" {
+		t.Fatalf("got %s %q", kind, ref)
+	}
+	if kind, _ := (Secret{}).Source(); kind != "" {
+		t.Fatalf("expected no source, got %s", kind)
+	}
+}
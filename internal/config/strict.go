@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownField is a manifest key that does not correspond to any field of
+// the manifest types — usually a typo, which yaml decoding silently ignores.
+type UnknownField struct {
+	// Key is the unrecognised key and Path the mapping it appears in, e.g.
+	// "profiles.local.services.api" ("" for the top level).
+	Key  string
+	Path string
+	At   Origin
+	// Suggestion is the closest known key, if one is similar enough.
+	Suggestion string
+}
+
+// Issue converts the unknown field to a validation issue.
+func (u UnknownField) Issue() Issue {
+	where := "at the top level"
+	if u.Path != "" {
+		where = "in " + u.Path
+	}
+	msg := fmt.Sprintf("unknown field '%s' %s", u.Key, where)
+	if u.Suggestion != "" {
+		msg += fmt.Sprintf(" — did you mean '%s'?", u.Suggestion)
+	}
+	return Issue{
+		Rule:     "unknown-field",
+		Severity: SeverityError,
+		Message:  msg,
+		Path:     joinPath(u.Path, u.Key),
+		File:     u.At.File,
+		Line:     u.At.Line,
+		Column:   u.At.Column,
+	}
+}
+
+func (u UnknownField) String() string {
+	return u.Issue().String()
+}
+
+// Position formats the origin as file:line:column, or "line L, column C"
+// when the file is unknown.
+func (o Origin) Position() string {
+	if o.File == "" {
+		return fmt.Sprintf("line %d, column %d", o.Line, o.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", o.File, o.Line, o.Column)
+}
+
+// renamedFields maps keys that an earlier manifest version used, as
+// "Type.key", to their replacement in the current version.
+var renamedFields = map[string]string{
+	"Health.httpGet": "http.url",
+}
+
+// unknownFields walks one manifest file's node tree against the Manifest
+// type and returns every key that would be ignored on decode. version is
+// the version the file was written for: older files are suggested the keys
+// they used, newer ones are pointed from an old key to its replacement.
+func unknownFields(root *yaml.Node, file string, version int) []UnknownField {
+	var out []UnknownField
+	checkFields(root, reflect.TypeOf(Manifest{}), "", file, version, &out)
+	return out
+}
+
+func checkFields(n *yaml.Node, t reflect.Type, path, file string, version int, out *[]UnknownField) {
+	if n == nil {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			return
+		}
+		fields := map[string]reflect.Type{}
+		var known []string
+		for _, f := range schemaFields(t) {
+			fields[yamlName(f)] = f.Type
+			known = append(known, yamlName(f))
+		}
+		renamed := map[string]string{}
+		for old, replacement := range renamedFields {
+			if typeName, key, _ := strings.Cut(old, "."); typeName == t.Name() {
+				renamed[key] = replacement
+				if version < CurrentVersion {
+					known = append(known, key)
+				}
+			}
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			ft, ok := fields[key.Value]
+			if !ok {
+				suggestion := suggest(key.Value, known)
+				if replacement, ok := renamed[key.Value]; ok && version >= CurrentVersion {
+					suggestion = replacement
+				}
+				*out = append(*out, UnknownField{
+					Key:        key.Value,
+					Path:       path,
+					At:         Origin{File: file, Line: key.Line, Column: key.Column},
+					Suggestion: suggestion,
+				})
+				continue
+			}
+			checkFields(n.Content[i+1], ft, joinPath(path, key.Value), file, version, out)
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			checkFields(n.Content[i+1], t.Elem(), joinPath(path, n.Content[i].Value), file, version, out)
+		}
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range n.Content {
+			checkFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), file, version, out)
+		}
+	}
+}
+
+// suggest returns the candidate closest to key, provided it is a plausible
+// typo: a case-only difference or an edit distance of at most a third of
+// the key's length.
+func suggest(key string, candidates []string) string {
+	best, bestDist := "", len(key)/3+1
+	for _, c := range candidates {
+		if strings.EqualFold(c, key) {
+			return c
+		}
+		if d := editDistance(strings.ToLower(key), strings.ToLower(c)); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Damerau–Levenshtein (optimal string alignment)
+// distance between a and b, so swapped letters count as one edit.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate_UnknownFields(t *testing.T) {
+	data := []byte(`version: 1
+project:
+  name: my-app
+  defaultProfile: local
+profiles:
+  local:
+    runtme: compose
+    services:
+      api:
+        imgae: nginx:alpine
+        health:
+          httpget: http://localhost/health
+`)
+
+	m, err := Parse(data)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	err = Validate(m)
+	if err == nil {
+		t.Fatalf("expected unknown field errors")
+	}
+	for _, want := range []string{
+		"unknown field 'runtme' in profiles.local — did you mean 'runtime'? (line 7, column 5)",
+		"unknown field 'imgae' in profiles.local.services.api — did you mean 'image'? (line 10, column 9)",
+		"unknown field 'httpget' in profiles.local.services.api.health — did you mean 'httpGet'? (line 12, column 11)",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoad_UnknownFieldInInclude(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml":       includeRoot,
+		"teams/jobs.yaml": "profiles:\n  local:\n    services:\n      job:\n        image: busybox\n        dependson: [api]\n",
+	})
+
+	m, err := Load(filepath.Join(dir, "devx.yaml"))
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(m.Unknown) != 1 {
+		t.Fatalf("expected 1 unknown field, got %v", m.Unknown)
+	}
+	u := m.Unknown[0]
+	if !strings.HasSuffix(u.At.File, filepath.Join("teams", "jobs.yaml")) || u.At.Line != 6 || u.Suggestion != "dependsOn" {
+		t.Fatalf("unexpected unknown field %+v", u)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	known := []string{"image", "build", "ports", "env", "dependsOn"}
+	cases := map[string]string{
+		"imgae":     "image",
+		"port":      "ports",
+		"DependsOn": "dependsOn",
+		"volumes":   "",
+		"x":         "",
+	}
+	for key, want := range cases {
+		if got := suggest(key, known); got != want {
+			t.Errorf("suggest(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
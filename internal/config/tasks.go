@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Task is a one-off command run with `devx run`: on the host through the
+// system shell, or in a fresh container of a service or dep that is removed
+// when the command exits.
+type Task struct {
+	// Description is shown by `devx tasks`.
+	Description string `yaml:"description,omitempty"`
+	// Run is a shell command run on the host, from the directory containing
+	// devx.yaml. Arguments given to `devx run` are appended to it.
+	Run string `yaml:"run,omitempty"`
+	// Service names the service or dep whose image, environment, volumes and
+	// network the task's container gets. Set either run or service.
+	Service string `yaml:"service,omitempty"`
+	// Command overrides the container's command. Arguments given to
+	// `devx run` are appended to it; without it they replace the image's
+	// command. service only.
+	Command []string `yaml:"command,omitempty"`
+	// DependsOn lists tasks run, in order and without arguments, before this
+	// one.
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+	// Env sets extra environment variables for the command.
+	Env map[string]string `yaml:"env,omitempty"`
+}
+
+// TaskOrder returns name and every task it depends on, directly or through
+// other tasks, each after its own dependencies. It fails on unknown tasks
+// and dependency cycles, which TaskIssues also reports.
+func TaskOrder(tasks map[string]Task, name string) ([]string, error) {
+	if _, ok := tasks[name]; !ok {
+		return nil, fmt.Errorf("unknown task '%s'", name)
+	}
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var stack, order []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			start := 0
+			for stack[start] != name {
+				start++
+			}
+			cycle := append(append([]string{}, stack[start:]...), name)
+			return fmt.Errorf("task dependency cycle: %s", strings.Join(cycle, " → "))
+		case done:
+			return nil
+		}
+		task, ok := tasks[name]
+		if !ok {
+			return fmt.Errorf("task '%s' depends on unknown task '%s'", stack[len(stack)-1], name)
+		}
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, dep := range task.DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+		order = append(order, name)
+		return nil
+	}
+	if err := visit(name); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
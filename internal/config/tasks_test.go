@@ -0,0 +1,163 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const tasksManifest = `version: 2
+project:
+  name: my-app
+  defaultProfile: local
+profiles:
+  local:
+    services:
+      api:
+        image: my-app:dev
+    deps:
+      db:
+        image: postgres:16
+tasks:
+  migrate:
+    service: api
+    command: ["./migrate", "up"]
+    dependsOn: [generate]
+  generate:
+    run: go generate ./...
+  seed:
+    service: db
+    command: ["psql", "-f", "/seed.sql"]
+    dependsOn: [migrate]
+    env:
+      PGUSER: postgres
+`
+
+func TestTaskIssues_Valid(t *testing.T) {
+	m, err := Parse([]byte(tasksManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues := TaskIssues(m); len(issues) != 0 {
+		t.Fatalf("expected no issues, got %+v", issues)
+	}
+	if got := m.Tasks["seed"].Env["PGUSER"]; got != "postgres" {
+		t.Errorf("seed env = %q", got)
+	}
+}
+
+func TestTaskIssues(t *testing.T) {
+	m, err := Parse([]byte(`version: 2
+project:
+  name: my-app
+  defaultProfile: local
+profiles:
+  local:
+    services:
+      api:
+        image: my-app:dev
+tasks:
+  empty:
+    description: does nothing
+  both:
+    run: make
+    service: api
+  ghost:
+    service: worker
+  hostcmd:
+    run: make test
+    command: [go, test]
+  a:
+    run: echo a
+    dependsOn: [b, missing]
+  b:
+    run: echo b
+    dependsOn: [a]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, issue := range TaskIssues(m) {
+		got = append(got, issue.Rule+" "+issue.Path)
+	}
+	want := []string{
+		"missing-dependency tasks.a.dependsOn[1]",
+		"task-target tasks.both",
+		"task-target tasks.empty",
+		"unknown-service tasks.ghost.service",
+		"invalid-value tasks.hostcmd.command",
+		"dependency-cycle tasks.b.dependsOn[0]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestTaskOrder(t *testing.T) {
+	m, err := Parse([]byte(tasksManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := TaskOrder(m.Tasks, "seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"generate", "migrate", "seed"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+
+	if _, err := TaskOrder(m.Tasks, "deploy"); err == nil || !strings.Contains(err.Error(), "unknown task 'deploy'") {
+		t.Errorf("expected unknown task error, got %v", err)
+	}
+
+	cyclic := map[string]Task{
+		"a": {Run: "a", DependsOn: []string{"b"}},
+		"b": {Run: "b", DependsOn: []string{"c"}},
+		"c": {Run: "c", DependsOn: []string{"b"}},
+	}
+	if _, err := TaskOrder(cyclic, "a"); err == nil || err.Error() != "task dependency cycle: b → c → b" {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+	if _, err := TaskOrder(map[string]Task{"a": {Run: "a", DependsOn: []string{"x"}}}, "a"); err == nil || !strings.Contains(err.Error(), "unknown task 'x'") {
+		t.Errorf("expected unknown dependency error, got %v", err)
+	}
+}
+
+func TestLoadInclude_Tasks(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"devx.yaml": includeRoot + `tasks:
+  test:
+    run: go test ./...
+`,
+		"teams/tasks.yaml": `tasks:
+  lint:
+    run: golangci-lint run
+`,
+	})
+	m, err := Load(filepath.Join(dir, "devx.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Tasks) != 2 || m.Tasks["lint"].Run != "golangci-lint run" {
+		t.Fatalf("expected both files' tasks, got %+v", m.Tasks)
+	}
+	if origin := m.Origins["tasks.lint"]; filepath.Base(origin.File) != "tasks.yaml" {
+		t.Errorf("tasks.lint origin = %+v", origin)
+	}
+
+	dir = writeFiles(t, map[string]string{
+		"devx.yaml": includeRoot + `tasks:
+  test:
+    run: go test ./...
+`,
+		"teams/tasks.yaml": `tasks:
+  test:
+    run: make test
+`,
+	})
+	if _, err := Load(filepath.Join(dir, "devx.yaml")); err == nil || !strings.Contains(err.Error(), "tasks.test") {
+		t.Fatalf("expected duplicate task error, got %v", err)
+	}
+}
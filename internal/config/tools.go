@@ -4,9 +4,9 @@ package config
 // devx doctor checks each tool using its Check command and reports missing tools.
 // devx setup (or devx doctor --fix) installs missing tools using the Install block.
 type Tool struct {
-	Name    string  `yaml:"name"`
-	Version string  `yaml:"version,omitempty"` // informational, shown in doctor output
-	Check   string  `yaml:"check"`             // shell command to verify installation
+	Name    string  `yaml:"name" jsonschema:"required"`
+	Version string  `yaml:"version,omitempty"`           // informational, shown in doctor output
+	Check   string  `yaml:"check" jsonschema:"required"` // shell command to verify installation
 	Install Install `yaml:"install,omitempty"`
 }
 
@@ -21,9 +21,9 @@ type Install struct {
 // Steps run in declaration order. RunOnce steps are skipped if their
 // command hash matches a previous successful run stored in .devx/setup-state.json.
 type SetupStep struct {
-	Name     string `yaml:"name"`
-	Run      string `yaml:"run"`
-	Workdir  string `yaml:"workdir,omitempty"`  // working directory; defaults to cwd
-	RunOnce  bool   `yaml:"runOnce,omitempty"`  // skip if hash matches last run
-	Platform string `yaml:"platform,omitempty"` // all | windows | linux | macos (default: all)
+	Name     string `yaml:"name" jsonschema:"required"`
+	Run      string `yaml:"run" jsonschema:"required"`
+	Workdir  string `yaml:"workdir,omitempty"`                                            // working directory; defaults to cwd
+	RunOnce  bool   `yaml:"runOnce,omitempty"`                                            // skip if hash matches last run
+	Platform string `yaml:"platform,omitempty" jsonschema:"enum=all|windows|linux|macos"` // all | windows | linux | macos (default: all)
 }
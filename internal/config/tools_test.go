@@ -196,3 +196,31 @@ profiles:
 		t.Fatalf("expected no error for empty setup: %v", err)
 	}
 }
+
+func TestSetupIssues_DarwinDeprecated(t *testing.T) {
+	data := []byte(`version: 1
+project:
+  name: my-app
+  defaultProfile: local
+profiles:
+  local:
+    services:
+      api:
+        image: nginx:alpine
+setup:
+  - name: certs
+    run: "mkcert -install"
+    platform: darwin
+`)
+	m, err := Parse(data)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if err := ValidateSetup(m); err != nil {
+		t.Fatalf("deprecated platform should only warn: %v", err)
+	}
+	issues := SetupIssues(m)
+	if len(issues) != 1 || issues[0].Rule != "deprecated" || issues[0].Severity != SeverityWarning {
+		t.Fatalf("expected deprecated warning, got %+v", issues)
+	}
+}
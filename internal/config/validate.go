@@ -2,127 +2,573 @@ package config
 
 import (
 	"fmt"
-	"sort"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
-)
+	"time"
 
-type ValidationError struct {
-	Issues []string
-}
+	"github.com/dever-labs/devx/internal/util"
+)
 
-func (e *ValidationError) Error() string {
-	return "manifest validation failed:\n- " + joinIssues(e.Issues)
+// Validate checks the manifest as a whole. It returns a *ValidationError
+// when any error-severity issue is found; use ManifestIssues to include
+// warnings.
+func Validate(m *Manifest) error {
+	return validationError(m, ManifestIssues(m))
 }
 
-func joinIssues(issues []string) string {
-	if len(issues) == 0 {
-		return ""
+// ManifestIssues returns every issue, including warnings, found outside
+// profiles.
+func ManifestIssues(m *Manifest) []Issue {
+	var issues []Issue
+	for _, u := range m.Unknown {
+		issues = append(issues, u.Issue())
 	}
-	sort.Strings(issues)
-	return strings.Join(issues, "\n- ")
-}
-
-func Validate(m *Manifest) error {
-	var issues []string
-	if m.Version != 1 {
-		issues = append(issues, "version must be 1")
+	switch {
+	case m.Version == 1:
+		issues = append(issues, newWarning("deprecated", "version", "manifest version 1 is deprecated — run `devx migrate` to upgrade to version %d", CurrentVersion))
+	case m.Version != CurrentVersion:
+		issues = append(issues, newIssue("unsupported-version", "version", "version must be 1 or %d", CurrentVersion))
 	}
 	if m.Project.Name == "" {
-		issues = append(issues, "project.name is required")
+		issues = append(issues, newIssue("required", "project.name", "project.name is required"))
 	}
 	if m.Project.DefaultProfile == "" {
-		issues = append(issues, "project.defaultProfile is required")
+		issues = append(issues, newIssue("required", "project.defaultProfile", "project.defaultProfile is required"))
 	}
 	if len(m.Profiles) == 0 {
-		issues = append(issues, "profiles are required")
+		issues = append(issues, newIssue("required", "profiles", "profiles are required"))
 	}
 	if m.AI != nil {
 		if m.AI.Provider == "" {
-			issues = append(issues, "ai.provider is required when ai block is present")
+			issues = append(issues, newIssue("required", "ai.provider", "ai.provider is required when ai block is present"))
 		}
 		if m.AI.Model == "" {
-			issues = append(issues, "ai.model is required when ai block is present")
+			issues = append(issues, newIssue("required", "ai.model", "ai.model is required when ai block is present"))
 		}
 	}
-	if len(issues) > 0 {
-		return &ValidationError{Issues: issues}
+	for _, name := range util.SortedKeys(m.Secrets) {
+		if !secretNameRe.MatchString(name) || name == "." || name == ".." {
+			issues = append(issues, newIssue("invalid-name", "secrets."+name, "secret name '%s' may contain only letters, digits, '_', '.' or '-'", name))
+		}
+		if m.Secrets[name].sourceCount() != 1 {
+			issues = append(issues, newIssue("secret-source", "secrets."+name, "secret '%s' must set exactly one of env, file, command or store", name))
+		}
 	}
-	if _, ok := m.Profiles[m.Project.DefaultProfile]; !ok {
-		return &ValidationError{Issues: []string{"project.defaultProfile does not exist"}}
+	issues = append(issues, unusedSecrets(m)...)
+	issues = append(issues, missingVariables(m, func(path string) bool {
+		return !strings.HasPrefix(path, "profiles.")
+	})...)
+	if m.Project.DefaultProfile != "" && len(m.Profiles) > 0 {
+		if _, ok := m.Profiles[m.Project.DefaultProfile]; !ok {
+			issues = append(issues, newIssue("unknown-profile", "project.defaultProfile", "project.defaultProfile does not exist"))
+		}
 	}
-	return nil
+	return m.locate(issues)
 }
 
+// secretNameRe matches names usable both as a file under .devx/secrets and
+// as a Kubernetes Secret key.
+var secretNameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// unusedSecrets warns about declared secrets no profile references.
+func unusedSecrets(m *Manifest) []Issue {
+	used := map[string]bool{}
+	for _, prof := range m.Profiles {
+		for _, name := range ProfileSecrets(&prof) {
+			used[name] = true
+		}
+	}
+	var issues []Issue
+	for _, name := range util.SortedKeys(m.Secrets) {
+		if !used[name] {
+			issues = append(issues, newWarning("unused-secret", "secrets."+name, "secret '%s' is declared but no service or dep uses it", name))
+		}
+	}
+	return issues
+}
+
+// ValidateProfile checks a single profile. It returns a *ValidationError
+// when any error-severity issue is found; use ProfileIssues to include
+// warnings.
 func ValidateProfile(m *Manifest, profile string) error {
+	return validationError(m, ProfileIssues(m, profile))
+}
+
+// ProfileIssues returns every issue, including warnings, found in the
+// named profile.
+func ProfileIssues(m *Manifest, profile string) []Issue {
 	prof, ok := m.Profiles[profile]
 	if !ok {
-		return &ValidationError{Issues: []string{"profile does not exist"}}
+		return m.locate([]Issue{newIssue("unknown-profile", "profiles", "profile does not exist")})
 	}
 
-	var issues []string
+	base := "profiles." + profile
+	var issues []Issue
 	if prof.Runtime != "" && prof.Runtime != "compose" && prof.Runtime != "k8s" {
-		issues = append(issues, fmt.Sprintf("profile '%s' runtime must be compose or k8s", profile))
+		issues = append(issues, newIssue("invalid-value", base+".runtime", "profile '%s' runtime must be compose or k8s", profile))
 	}
-	for name, svc := range prof.Services {
+	isK8s := prof.Runtime == "k8s"
+	issues = append(issues, containerRuntimeIssues(prof.ContainerRuntime, base+".containerRuntime", profile, isK8s)...)
+	for _, name := range util.SortedKeys(prof.Services) {
+		svc := prof.Services[name]
+		path := base + ".services." + name
 		if svc.Image == "" && svc.Build == nil {
-			issues = append(issues, fmt.Sprintf("service '%s' must define image or build", name))
+			issues = append(issues, newIssue("required", path, "service '%s' must define image or build", name))
 		}
-		for _, dep := range svc.DependsOn {
+		if _, ok := prof.Deps[name]; ok {
+			issues = append(issues, newIssue("duplicate-name", path, "'%s' is defined as both a service and a dep", name))
+		}
+		for i, dep := range svc.DependsOn {
 			if !existsServiceOrDep(prof, dep) {
-				issues = append(issues, fmt.Sprintf("service '%s' dependsOn '%s' which does not exist", name, dep))
+				issues = append(issues, newIssue("missing-dependency", fmt.Sprintf("%s.dependsOn[%d]", path, i), "service '%s' dependsOn '%s' which does not exist", name, dep))
+			}
+		}
+		for i, secret := range svc.Secrets {
+			if _, ok := m.Secrets[secret]; !ok {
+				issues = append(issues, newIssue("undeclared-secret", fmt.Sprintf("%s.secrets[%d]", path, i), "service '%s' references secret '%s' which is not declared", name, secret))
+			}
+		}
+		issues = append(issues, portIssues("service", name, path, svc.Ports)...)
+		for i, mount := range svc.Mount {
+			mountPath := fmt.Sprintf("%s.mount[%d]", path, i)
+			if isK8s {
+				issues = append(issues, newIssue("unsupported", mountPath, "service '%s' mount is not supported by the k8s runtime", name))
+			} else if err := checkMount(mount); err != nil {
+				issues = append(issues, newIssue("invalid-volume", mountPath, "service '%s' mount '%s': %v", name, mount, err))
+			}
+		}
+		if svc.Build != nil {
+			switch {
+			case isK8s:
+				issues = append(issues, newWarning("unused-field", path+".build", "service '%s' build is ignored by the k8s runtime, which runs image", name))
+			case svc.Image != "":
+				issues = append(issues, newWarning("unused-field", path+".image", "service '%s' image is ignored by compose when build is set", name))
+			}
+			if !isK8s {
+				issues = append(issues, buildIssues(m.Dir, name, path+".build", svc.Build)...)
 			}
 		}
+		if isK8s && svc.Image == "" && svc.Build != nil {
+			issues = append(issues, newIssue("required", path+".image", "service '%s' requires image for the k8s runtime", name))
+		}
+		if svc.Health != nil {
+			issues = append(issues, healthIssues(name, path+".health", svc.Health, svc.Ports, isK8s)...)
+		}
+		if len(svc.Watch) > 0 {
+			issues = append(issues, watchIssues(m.Dir, name, path+".watch", svc, isK8s)...)
+		}
 	}
 
-	for name, dep := range prof.Deps {
+	for _, name := range util.SortedKeys(prof.Deps) {
+		dep := prof.Deps[name]
+		path := base + ".deps." + name
 		if dep.Kind == "" && dep.Image == "" {
-			issues = append(issues, fmt.Sprintf("dep '%s' must define image (or set kind to use a provider's default image)", name))
+			issues = append(issues, newIssue("required", path, "dep '%s' must define image (or set kind to use a provider's default image)", name))
 		}
 		if dep.Kind != "" && dep.Version == "" {
-			issues = append(issues, fmt.Sprintf("dep '%s' has kind '%s' but is missing version — version is required when kind is set", name, dep.Kind))
+			issues = append(issues, newIssue("required", path+".kind", "dep '%s' has kind '%s' but is missing version — version is required when kind is set", name, dep.Kind))
+		}
+		if dep.Kind == "" {
+			if dep.Source != "" {
+				issues = append(issues, newWarning("unused-field", path+".source", "dep '%s' source is ignored because kind is not set", name))
+			}
+			if dep.Version != "" {
+				issues = append(issues, newWarning("unused-field", path+".version", "dep '%s' version is ignored because kind is not set", name))
+			}
+		}
+		for i, secret := range dep.Secrets {
+			if _, ok := m.Secrets[secret]; !ok {
+				issues = append(issues, newIssue("undeclared-secret", fmt.Sprintf("%s.secrets[%d]", path, i), "dep '%s' references secret '%s' which is not declared", name, secret))
+			}
 		}
 		if dep.Source != "" && !strings.Contains(dep.Source, "/") {
-			issues = append(issues, fmt.Sprintf("dep '%s' source must be in org/name format (e.g. devx-labs/postgres)", name))
+			issues = append(issues, newIssue("dep-source", path+".source", "dep '%s' source must be in org/name format (e.g. devx-labs/postgres)", name))
+		}
+		issues = append(issues, portIssues("dep", name, path, dep.Ports)...)
+		if dep.Volume != "" {
+			if err := checkNamedVolume(dep.Volume); err != nil {
+				issues = append(issues, newIssue("invalid-volume", path+".volume", "dep '%s' volume '%s': %v", name, dep.Volume, err))
+			}
 		}
 		for i, c := range dep.Connect {
+			connPath := fmt.Sprintf("%s.connect[%d]", path, i)
 			if c.Service == "" {
-				issues = append(issues, fmt.Sprintf("dep '%s' connect[%d] must specify a service", name, i))
+				issues = append(issues, newIssue("required", connPath, "dep '%s' connect[%d] must specify a service", name, i))
 			} else if _, ok := prof.Services[c.Service]; !ok {
-				issues = append(issues, fmt.Sprintf("dep '%s' connect[%d] references service '%s' which does not exist", name, i, c.Service))
+				issues = append(issues, newIssue("connect-service", connPath+".service", "dep '%s' connect[%d] references service '%s' which does not exist", name, i, c.Service))
 			}
 		}
 	}
 
-	allHooks := append(prof.Hooks.AfterUp, prof.Hooks.BeforeDown...)
-	for i, h := range allHooks {
+	issues = append(issues, portConflicts(base, prof)...)
+	issues = append(issues, dependencyCycles(base, prof)...)
+
+	type indexedHook struct {
+		path string
+		hook Hook
+	}
+	var allHooks []indexedHook
+	for i, h := range prof.Hooks.AfterUp {
+		allHooks = append(allHooks, indexedHook{fmt.Sprintf("%s.hooks.afterUp[%d]", base, i), h})
+	}
+	for i, h := range prof.Hooks.BeforeDown {
+		allHooks = append(allHooks, indexedHook{fmt.Sprintf("%s.hooks.beforeDown[%d]", base, i), h})
+	}
+	for i, ih := range allHooks {
+		h, path := ih.hook, ih.path
 		hasExec := h.Exec != ""
 		hasRun := h.Run != ""
 		if !hasExec && !hasRun {
-			issues = append(issues, fmt.Sprintf("hook[%d] must set either exec or run", i))
+			issues = append(issues, newIssue("hook", path, "hook[%d] must set either exec or run", i))
 		}
 		if hasExec && hasRun {
-			issues = append(issues, fmt.Sprintf("hook[%d] cannot set both exec and run", i))
+			issues = append(issues, newIssue("hook", path, "hook[%d] cannot set both exec and run", i))
 		}
 		if hasExec && h.Service == "" {
-			issues = append(issues, fmt.Sprintf("hook[%d] exec requires service to be set", i))
+			issues = append(issues, newIssue("hook", path, "hook[%d] exec requires service to be set", i))
 		}
 		if hasRun && h.Service != "" {
-			issues = append(issues, fmt.Sprintf("hook[%d] run does not use service", i))
+			issues = append(issues, newIssue("hook", path+".service", "hook[%d] run does not use service", i))
 		}
 		if h.Background && hasExec {
-			issues = append(issues, fmt.Sprintf("hook[%d] background is only supported for run hooks", i))
+			issues = append(issues, newIssue("hook", path+".background", "hook[%d] background is only supported for run hooks", i))
 		}
 		if h.Name != "" && hasExec {
-			issues = append(issues, fmt.Sprintf("hook[%d] name is only supported for run hooks", i))
+			issues = append(issues, newIssue("hook", path+".name", "hook[%d] name is only supported for run hooks", i))
+		}
+	}
+
+	prefix := base + "."
+	issues = append(issues, missingVariables(m, func(path string) bool {
+		return strings.HasPrefix(path, prefix)
+	})...)
+
+	for i := range issues {
+		issues[i].Profile = profile
+	}
+	return m.locate(issues)
+}
+
+// portIssues reports ports that do not parse or are out of range, and port
+// names used twice.
+func portIssues(kind, name, path string, ports []Port) []Issue {
+	var issues []Issue
+	named := map[string]bool{}
+	for i, p := range ports {
+		portPath := fmt.Sprintf("%s.ports[%d]", path, i)
+		if err := p.check(); err != nil {
+			issues = append(issues, newIssue("invalid-port", portPath, "%s '%s' port '%s': %v", kind, name, p, err))
+			continue
+		}
+		if p.Name != "" {
+			if named[p.Name] {
+				issues = append(issues, newIssue("duplicate-name", portPath+".name", "%s '%s' has more than one port named '%s'", kind, name, p.Name))
+			}
+			named[p.Name] = true
+		}
+	}
+	return issues
+}
+
+// portConflicts reports host ports published more than once in a profile.
+// Each clash is reported at the later definition, in service-then-dep
+// name order.
+func portConflicts(base string, prof Profile) []Issue {
+	type published struct {
+		owner string
+		path  string
+		port  Port
+	}
+	var seen []published
+	var issues []Issue
+	check := func(kind, name, path string, ports []Port) {
+		for i, port := range ports {
+			if port.check() != nil || port.Host.IsZero() {
+				continue
+			}
+			p := published{owner: fmt.Sprintf("%s '%s'", kind, name), path: fmt.Sprintf("%s.ports[%d]", path, i), port: port}
+			for _, prev := range seen {
+				if prev.port.Proto() == port.Proto() && sameHostAddress(prev.port.HostIP, port.HostIP) && prev.port.Host.overlaps(port.Host) {
+					issues = append(issues, newIssue("port-conflict", p.path, "%s publishes host port %s/%s, already published by %s (%s)", p.owner, port.Host, port.Proto(), prev.owner, prev.path))
+					break
+				}
+			}
+			seen = append(seen, p)
+		}
+	}
+	for _, name := range util.SortedKeys(prof.Services) {
+		check("service", name, base+".services."+name, prof.Services[name].Ports)
+	}
+	for _, name := range util.SortedKeys(prof.Deps) {
+		check("dep", name, base+".deps."+name, prof.Deps[name].Ports)
+	}
+	return issues
+}
+
+// dependencyCycles reports each dependsOn cycle once, at the edge that
+// closes it.
+func dependencyCycles(base string, prof Profile) []Issue {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	reported := map[string]bool{}
+	var stack []string
+	var issues []Issue
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		stack = append(stack, name)
+		svc := prof.Services[name]
+		for i, dep := range svc.DependsOn {
+			if _, ok := prof.Services[dep]; !ok {
+				continue // deps have no dependencies of their own
+			}
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				start := 0
+				for stack[start] != dep {
+					start++
+				}
+				cycle := append(append([]string{}, stack[start:]...), dep)
+				if key := cycleKey(cycle[:len(cycle)-1]); !reported[key] {
+					reported[key] = true
+					issues = append(issues, newIssue("dependency-cycle", fmt.Sprintf("%s.services.%s.dependsOn[%d]", base, name, i), "dependency cycle: %s", strings.Join(cycle, " → ")))
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+	}
+	for _, name := range util.SortedKeys(prof.Services) {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+	return issues
+}
+
+// cycleKey identifies a cycle regardless of which member it starts from.
+func cycleKey(members []string) string {
+	least := 0
+	for i, name := range members {
+		if name < members[least] {
+			least = i
+		}
+	}
+	return strings.Join(append(append([]string{}, members[least:]...), members[:least]...), "→")
+}
+
+// buildIssues checks that a local build context and its Dockerfile exist.
+// Skipped when dir is empty (the manifest was not loaded from disk) or the
+// context is a remote URL.
+func buildIssues(dir, name, path string, b *Build) []Issue {
+	if b.Context == "" {
+		return []Issue{newIssue("required", path+".context", "service '%s' build.context is required", name)}
+	}
+	if dir == "" || strings.Contains(b.Context, "://") || strings.HasPrefix(b.Context, "git@") {
+		return nil
+	}
+	context := b.Context
+	if !filepath.IsAbs(context) {
+		context = filepath.Join(dir, context)
+	}
+	info, err := os.Stat(context)
+	if err != nil {
+		return []Issue{newIssue("missing-path", path+".context", "service '%s' build context '%s' does not exist", name, b.Context)}
+	}
+	if !info.IsDir() {
+		return []Issue{newIssue("missing-path", path+".context", "service '%s' build context '%s' is not a directory", name, b.Context)}
+	}
+	dockerfile := b.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(context, dockerfile)
+	}
+	if info, err := os.Stat(dockerfile); err != nil || info.IsDir() {
+		at := path + ".dockerfile"
+		if b.Dockerfile == "" {
+			at = path
+		}
+		return []Issue{newIssue("missing-path", at, "service '%s' Dockerfile '%s' not found in build context '%s'", name, filepath.Base(dockerfile), b.Context)}
+	}
+	return nil
+}
+
+// healthIssues checks that exactly one probe is set and well formed, and
+// that the timing settings parse.
+func healthIssues(name, path string, h *Health, ports []Port, isK8s bool) []Issue {
+	var issues []Issue
+	var kinds []string
+	for _, k := range []struct {
+		name string
+		set  bool
+	}{{"http", h.HTTP != nil}, {"tcp", h.TCP != nil}, {"exec", h.Exec != nil}, {"grpc", h.GRPC != nil}} {
+		if k.set {
+			kinds = append(kinds, k.name)
+		}
+	}
+	switch {
+	case len(kinds) == 0:
+		if h.Interval != "" || h.Timeout != "" || h.StartPeriod != "" || h.Retries != 0 || h.SuccessThreshold != 0 {
+			issues = append(issues, newWarning("unused-field", path, "service '%s' health settings are ignored without a probe (http, tcp, exec or grpc)", name))
+		}
+	case len(kinds) > 1:
+		issues = append(issues, newIssue("invalid-value", path, "service '%s' health sets %s; set exactly one probe", name, strings.Join(kinds, " and ")))
+	}
+
+	checkPort := func(field string, ref PortRef) {
+		if ref == "" {
+			issues = append(issues, newIssue("required", path+"."+field, "service '%s' health %s is required", name, field))
+			return
+		}
+		if _, ok := ResolvePort(ports, ref); !ok {
+			issues = append(issues, newIssue("invalid-value", path+"."+field, "service '%s' health %s '%s' is neither a port number nor the name of one of its ports", name, field, ref))
+		}
+	}
+
+	if p := h.HTTP; p != nil {
+		switch {
+		case p.URL != "" && p.Port != "":
+			issues = append(issues, newIssue("invalid-value", path+".http", "service '%s' health http sets both url and port; set one", name))
+		case p.URL != "":
+			if u, err := url.Parse(p.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				issues = append(issues, newIssue("invalid-value", path+".http.url", "service '%s' health url '%s' must be an http:// or https:// URL", name, p.URL))
+			}
+			if p.Path != "" || p.Scheme != "" {
+				issues = append(issues, newWarning("unused-field", path+".http", "service '%s' health http path and scheme are ignored when url is set", name))
+			}
+		default:
+			checkPort("http.port", p.Port)
+		}
+		if p.Path != "" && !strings.HasPrefix(p.Path, "/") {
+			issues = append(issues, newIssue("invalid-value", path+".http.path", "service '%s' health path '%s' must start with /", name, p.Path))
+		}
+		for i, code := range p.Status {
+			if code < 100 || code > 599 {
+				issues = append(issues, newIssue("invalid-value", fmt.Sprintf("%s.http.status[%d]", path, i), "service '%s' health status %d is not an HTTP status code", name, code))
+			}
+		}
+		if isK8s && len(p.Status) > 0 {
+			issues = append(issues, newWarning("unsupported", path+".http.status", "service '%s' health status is ignored by the k8s runtime, which accepts 200-399", name))
+		}
+	}
+	if h.TCP != nil {
+		checkPort("tcp.port", h.TCP.Port)
+	}
+	if h.Exec != nil && len(h.Exec.Command) == 0 {
+		issues = append(issues, newIssue("required", path+".exec.command", "service '%s' health exec command is required", name))
+	}
+	if h.GRPC != nil {
+		checkPort("grpc.port", h.GRPC.Port)
+	}
+
+	for _, d := range []struct {
+		field, value string
+		zeroOK       bool
+	}{{"interval", h.Interval, false}, {"timeout", h.Timeout, false}, {"startPeriod", h.StartPeriod, true}} {
+		if d.value == "" {
+			continue
 		}
+		if v, err := time.ParseDuration(d.value); err != nil {
+			issues = append(issues, newIssue("invalid-duration", path+"."+d.field, "service '%s' health %s '%s' is not a duration (e.g. 5s, 1m30s)", name, d.field, d.value))
+		} else if v < 0 || (v == 0 && !d.zeroOK) {
+			issues = append(issues, newIssue("invalid-duration", path+"."+d.field, "service '%s' health %s '%s' must be positive", name, d.field, d.value))
+		}
+	}
+	if h.Retries < 0 {
+		issues = append(issues, newIssue("invalid-value", path+".retries", "service '%s' health retries must not be negative", name))
+	}
+	if h.SuccessThreshold < 0 {
+		issues = append(issues, newIssue("invalid-value", path+".successThreshold", "service '%s' health successThreshold must not be negative", name))
 	}
+	return issues
+}
+
+var volumeNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// checkNamedVolume validates a dep volume in "volumeName:containerPath" form.
+func checkNamedVolume(spec string) error {
+	name, target, ok := strings.Cut(spec, ":")
+	if !ok {
+		return fmt.Errorf("expected volumeName:containerPath")
+	}
+	if !volumeNameRe.MatchString(name) {
+		return fmt.Errorf("volume name '%s' must start with a letter or digit and contain only letters, digits, '_', '.' or '-'", name)
+	}
+	if !strings.HasPrefix(target, "/") {
+		return fmt.Errorf("container path '%s' must be absolute", target)
+	}
+	return nil
+}
+
+var driveLetterRe = regexp.MustCompile(`^[A-Za-z]:[\\/]`)
+
+var mountOptions = map[string]bool{
+	"ro": true, "rw": true, "z": true, "Z": true,
+	"cached": true, "delegated": true, "consistent": true, "nocopy": true,
+}
 
-	if len(issues) > 0 {
-		return &ValidationError{Issues: issues}
+// checkMount validates a bind mount in "hostPath:containerPath[:options]"
+// form. Windows host paths such as C:\src keep their drive letter.
+func checkMount(spec string) error {
+	drive := ""
+	if driveLetterRe.MatchString(spec) {
+		drive, spec = spec[:2], spec[2:]
+	}
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return fmt.Errorf("expected hostPath:containerPath[:options]")
+	}
+	if drive+parts[0] == "" {
+		return fmt.Errorf("host path is empty")
+	}
+	if !strings.HasPrefix(parts[1], "/") {
+		return fmt.Errorf("container path '%s' must be absolute", parts[1])
+	}
+	if len(parts) == 3 {
+		for _, opt := range strings.Split(parts[2], ",") {
+			if !mountOptions[opt] {
+				return fmt.Errorf("unknown mount option '%s'", opt)
+			}
+		}
 	}
 	return nil
 }
 
+// missingVariables reports unset ${VAR} references at paths accepted by include.
+func missingVariables(m *Manifest, include func(path string) bool) []Issue {
+	var issues []Issue
+	for _, name := range util.SortedKeys(m.Variables) {
+		for _, ref := range m.Variables[name].Missing {
+			if !include(ref.Path) {
+				continue
+			}
+			// Like compose, a plain ${VAR} that is unset becomes empty;
+			// only ${VAR:?message} makes it an error.
+			switch {
+			case ref.Required && ref.Message != "":
+				issues = append(issues, newIssue("unset-variable", ref.Path, "%s: %s", name, ref.Message))
+			case ref.Required:
+				issues = append(issues, newIssue("unset-variable", ref.Path, "variable %s is required", name))
+			default:
+				issues = append(issues, newWarning("unset-variable", ref.Path, "variable %s is not set, using an empty value", name))
+			}
+		}
+	}
+	return issues
+}
+
 func existsServiceOrDep(prof Profile, name string) bool {
 	if _, ok := prof.Services[name]; ok {
 		return true
@@ -135,42 +581,176 @@ func existsServiceOrDep(prof Profile, name string) bool {
 
 // ValidateTools checks that all tool declarations are well-formed.
 func ValidateTools(m *Manifest) error {
-	var issues []string
+	return validationError(m, ToolIssues(m))
+}
+
+// ToolIssues returns every issue found in the tools block.
+func ToolIssues(m *Manifest) []Issue {
+	var issues []Issue
 	for i, t := range m.Tools {
+		path := fmt.Sprintf("tools[%d]", i)
+		if t.Name != "" {
+			path = "tools." + t.Name
+		}
 		if t.Name == "" {
-			issues = append(issues, fmt.Sprintf("tools[%d]: name is required", i))
+			issues = append(issues, newIssue("required", path, "tools[%d]: name is required", i))
 		}
 		if t.Check == "" {
 			label := t.Name
 			if label == "" {
 				label = fmt.Sprintf("[%d]", i)
 			}
-			issues = append(issues, fmt.Sprintf("tool '%s': check is required", label))
+			issues = append(issues, newIssue("required", path, "tool '%s': check is required", label))
 		}
 	}
-	if len(issues) > 0 {
-		return &ValidationError{Issues: issues}
-	}
-	return nil
+	return m.locate(issues)
 }
 
 // ValidateSetup checks that all setup step declarations are well-formed.
 func ValidateSetup(m *Manifest) error {
-	var issues []string
-	validPlatforms := map[string]bool{"": true, "all": true, "windows": true, "linux": true, "macos": true, "darwin": true}
+	return validationError(m, SetupIssues(m))
+}
+
+// SetupIssues returns every issue, including warnings, found in the setup
+// block.
+func SetupIssues(m *Manifest) []Issue {
+	var issues []Issue
+	validPlatforms := map[string]bool{"": true, "all": true, "windows": true, "linux": true, "macos": true}
 	for i, s := range m.Setup {
+		path := fmt.Sprintf("setup[%d]", i)
+		if s.Name != "" {
+			path = "setup." + s.Name
+		}
 		if s.Name == "" {
-			issues = append(issues, fmt.Sprintf("setup[%d]: name is required", i))
+			issues = append(issues, newIssue("required", path, "setup[%d]: name is required", i))
 		}
 		if s.Run == "" {
-			issues = append(issues, fmt.Sprintf("setup step '%s': run is required", s.Name))
+			issues = append(issues, newIssue("required", path, "setup step '%s': run is required", s.Name))
 		}
-		if !validPlatforms[s.Platform] {
-			issues = append(issues, fmt.Sprintf("setup step '%s': platform must be all, windows, linux, or macos", s.Name))
+		switch {
+		case s.Platform == "darwin":
+			issues = append(issues, newWarning("deprecated", path+".platform", "setup step '%s': platform 'darwin' is deprecated — use macos", s.Name))
+		case !validPlatforms[s.Platform]:
+			issues = append(issues, newIssue("invalid-value", path+".platform", "setup step '%s': platform must be all, windows, linux, or macos", s.Name))
 		}
 	}
-	if len(issues) > 0 {
-		return &ValidationError{Issues: issues}
+	return m.locate(issues)
+}
+
+// ValidateTasks checks that all task declarations are well-formed.
+func ValidateTasks(m *Manifest) error {
+	return validationError(m, TaskIssues(m))
+}
+
+// TaskIssues returns every issue found in the tasks block.
+func TaskIssues(m *Manifest) []Issue {
+	var issues []Issue
+	for _, name := range util.SortedKeys(m.Tasks) {
+		t := m.Tasks[name]
+		path := "tasks." + name
+		switch {
+		case t.Run == "" && t.Service == "":
+			issues = append(issues, newIssue("task-target", path, "task '%s' must set run or service", name))
+		case t.Run != "" && t.Service != "":
+			issues = append(issues, newIssue("task-target", path, "task '%s' must set only one of run or service", name))
+		case t.Service != "" && !taskServiceExists(m, t.Service):
+			issues = append(issues, newIssue("unknown-service", path+".service", "task '%s' runs in '%s', which no profile defines", name, t.Service))
+		}
+		if len(t.Command) > 0 && t.Service == "" {
+			issues = append(issues, newIssue("invalid-value", path+".command", "task '%s': command requires service — use run for host commands", name))
+		}
+		for i, dep := range t.DependsOn {
+			if _, ok := m.Tasks[dep]; !ok {
+				issues = append(issues, newIssue("missing-dependency", fmt.Sprintf("%s.dependsOn[%d]", path, i), "task '%s' dependsOn '%s' which does not exist", name, dep))
+			}
+		}
 	}
-	return nil
+	issues = append(issues, taskCycles(m.Tasks)...)
+	return m.locate(issues)
+}
+
+// taskServiceExists reports whether any profile defines name as a service
+// or dep.
+func taskServiceExists(m *Manifest, name string) bool {
+	for _, prof := range m.Profiles {
+		if existsServiceOrDep(prof, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// taskCycles reports each task dependsOn cycle once, at the edge that
+// closes it.
+func taskCycles(tasks map[string]Task) []Issue {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	reported := map[string]bool{}
+	var stack []string
+	var issues []Issue
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		stack = append(stack, name)
+		for i, dep := range tasks[name].DependsOn {
+			if _, ok := tasks[dep]; !ok {
+				continue
+			}
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				start := 0
+				for stack[start] != dep {
+					start++
+				}
+				cycle := append(append([]string{}, stack[start:]...), dep)
+				if key := cycleKey(cycle[:len(cycle)-1]); !reported[key] {
+					reported[key] = true
+					issues = append(issues, newIssue("dependency-cycle", fmt.Sprintf("tasks.%s.dependsOn[%d]", name, i), "task dependency cycle: %s", strings.Join(cycle, " → ")))
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+	}
+	for _, name := range util.SortedKeys(tasks) {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+	return issues
+}
+
+// containerRuntimeIssues checks a profile's containerRuntime setting.
+func containerRuntimeIssues(cr ContainerRuntime, path, profile string, isK8s bool) []Issue {
+	if cr == (ContainerRuntime{}) {
+		return nil
+	}
+	if isK8s {
+		return []Issue{newWarning("unused-field", path, "profile '%s' containerRuntime is ignored by the k8s runtime", profile)}
+	}
+	var issues []Issue
+	switch cr.Name {
+	case "docker", "podman", "nerdctl", "docker-engine":
+	case "":
+		issues = append(issues, newIssue("required", path+".name", "profile '%s' containerRuntime requires name", profile))
+	default:
+		issues = append(issues, newIssue("invalid-value", path+".name", "profile '%s' containerRuntime must be docker, podman, nerdctl or docker-engine", profile))
+	}
+	if cr.Context != "" && cr.Name != "docker" {
+		issues = append(issues, newIssue("invalid-value", path+".context", "profile '%s' containerRuntime context is only supported by docker", profile))
+	}
+	if cr.Host != "" && cr.Name != "docker" && cr.Name != "docker-engine" {
+		issues = append(issues, newIssue("invalid-value", path+".host", "profile '%s' containerRuntime host is only supported by docker and docker-engine", profile))
+	}
+	if cr.Binary != "" && cr.Name == "docker-engine" {
+		issues = append(issues, newWarning("unused-field", path+".binary", "profile '%s' containerRuntime binary is ignored by docker-engine, which uses no CLI", profile))
+	}
+	return issues
 }
@@ -0,0 +1,437 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// profileIssues parses a manifest whose only profile is local and returns
+// the issues ValidateProfile-level checks find in it.
+func profileIssues(t *testing.T, profile string) []Issue {
+	t.Helper()
+	m, err := Parse([]byte("version: 1\nproject:\n  name: my-app\n  defaultProfile: local\nprofiles:\n  local:\n" + profile))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	return ProfileIssues(m, "local")
+}
+
+func findIssue(issues []Issue, rule string) *Issue {
+	for i := range issues {
+		if issues[i].Rule == rule {
+			return &issues[i]
+		}
+	}
+	return nil
+}
+
+func TestProfileIssues_Semantic(t *testing.T) {
+	tests := []struct {
+		name     string
+		profile  string
+		rule     string
+		path     string
+		message  string
+		severity Severity
+	}{
+		{
+			name: "malformed port",
+			profile: `    services:
+      api:
+        image: nginx
+        ports: ["8080:http"]
+`,
+			rule:    "invalid-port",
+			path:    "profiles.local.services.api.ports[0]",
+			message: "service 'api' port '8080:http': container port 'http' is not a number",
+		},
+		{
+			name: "watch sync without target",
+			profile: `    services:
+      api:
+        image: nginx
+        watch:
+          - path: src
+            action: sync
+`,
+			rule:    "required",
+			path:    "profiles.local.services.api.watch[0].target",
+			message: "service 'api' watch[0] sync requires target",
+		},
+		{
+			name: "watch rebuild without build",
+			profile: `    services:
+      api:
+        image: nginx
+        watch:
+          - path: src
+            action: rebuild
+`,
+			rule:    "invalid-value",
+			path:    "profiles.local.services.api.watch[0].action",
+			message: "service 'api' watch[0] rebuild requires build",
+		},
+		{
+			name: "watch target on restart",
+			profile: `    services:
+      api:
+        image: nginx
+        watch:
+          - path: package.json
+            action: restart
+            target: /app
+`,
+			rule:     "unused-field",
+			path:     "profiles.local.services.api.watch[0].target",
+			message:  "service 'api' watch[0] target is only used by sync",
+			severity: SeverityWarning,
+		},
+		{
+			name: "dep volume without container path",
+			profile: `    deps:
+      db:
+        image: postgres
+        volume: db-data
+`,
+			rule:    "invalid-volume",
+			path:    "profiles.local.deps.db.volume",
+			message: "dep 'db' volume 'db-data': expected volumeName:containerPath",
+		},
+		{
+			name: "relative mount target",
+			profile: `    services:
+      api:
+        image: nginx
+        mount: ["./src:app"]
+`,
+			rule:    "invalid-volume",
+			path:    "profiles.local.services.api.mount[0]",
+			message: "service 'api' mount './src:app': container path 'app' must be absolute",
+		},
+		{
+			name: "bad health interval",
+			profile: `    services:
+      api:
+        image: nginx
+        health:
+          httpGet: http://localhost:8080/health
+          interval: 5
+`,
+			rule:    "invalid-duration",
+			path:    "profiles.local.services.api.health.interval",
+			message: "service 'api' health interval '5' is not a duration (e.g. 5s, 1m30s)",
+		},
+		{
+			name: "host port collision",
+			profile: `    services:
+      api:
+        image: nginx
+        ports: ["8080:80"]
+    deps:
+      admin:
+        image: adminer
+        ports: ["127.0.0.1:8080:8080"]
+`,
+			rule:    "port-conflict",
+			path:    "profiles.local.deps.admin.ports[0]",
+			message: "dep 'admin' publishes host port 8080/tcp, already published by service 'api' (profiles.local.services.api.ports[0])",
+		},
+		{
+			name: "dependency cycle",
+			profile: `    services:
+      api:
+        image: nginx
+        dependsOn: [worker]
+      worker:
+        image: nginx
+        dependsOn: [api]
+`,
+			rule:    "dependency-cycle",
+			path:    "profiles.local.services.worker.dependsOn[0]",
+			message: "dependency cycle: api → worker → api",
+		},
+		{
+			name: "health settings without a probe",
+			profile: `    services:
+      api:
+        image: nginx
+        health:
+          retries: 3
+`,
+			rule:     "unused-field",
+			path:     "profiles.local.services.api.health",
+			message:  "service 'api' health settings are ignored without a probe (http, tcp, exec or grpc)",
+			severity: SeverityWarning,
+		},
+		{
+			name: "two health probes",
+			profile: `    services:
+      api:
+        image: nginx
+        health:
+          tcp: {port: 80}
+          exec: {command: [true]}
+`,
+			rule:    "invalid-value",
+			path:    "profiles.local.services.api.health",
+			message: "service 'api' health sets tcp and exec; set exactly one probe",
+		},
+		{
+			name: "health probe on an unknown port name",
+			profile: `    services:
+      api:
+        image: nginx
+        ports: [{name: http, container: 80}]
+        health:
+          http: {port: web, path: /health}
+`,
+			rule:    "invalid-value",
+			path:    "profiles.local.services.api.health.http.port",
+			message: "service 'api' health http.port 'web' is neither a port number nor the name of one of its ports",
+		},
+		{
+			name: "bad health timeout",
+			profile: `    services:
+      api:
+        image: nginx
+        health:
+          tcp: {port: 80}
+          timeout: 0s
+`,
+			rule:    "invalid-duration",
+			path:    "profiles.local.services.api.health.timeout",
+			message: "service 'api' health timeout '0s' must be positive",
+		},
+		{
+			name: "dep version without kind",
+			profile: `    deps:
+      db:
+        image: postgres
+        version: "16"
+`,
+			rule:     "unused-field",
+			path:     "profiles.local.deps.db.version",
+			message:  "dep 'db' version is ignored because kind is not set",
+			severity: SeverityWarning,
+		},
+		{
+			name: "unknown container runtime",
+			profile: `    containerRuntime: rkt
+    services:
+      api:
+        image: nginx
+`,
+			rule:    "invalid-value",
+			path:    "profiles.local.containerRuntime.name",
+			message: "profile 'local' containerRuntime must be docker, podman, nerdctl or docker-engine",
+		},
+		{
+			name: "docker context on podman",
+			profile: `    containerRuntime:
+      name: podman
+      context: colima
+    services:
+      api:
+        image: nginx
+`,
+			rule:    "invalid-value",
+			path:    "profiles.local.containerRuntime.context",
+			message: "profile 'local' containerRuntime context is only supported by docker",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := profileIssues(t, tt.profile)
+			got := findIssue(issues, tt.rule)
+			if got == nil {
+				t.Fatalf("expected %s issue, got %v", tt.rule, issues)
+			}
+			want := tt.severity
+			if want == "" {
+				want = SeverityError
+			}
+			if got.Path != tt.path || got.Message != tt.message || got.Severity != want {
+				t.Fatalf("unexpected issue: %+v", *got)
+			}
+			if got.Line == 0 {
+				t.Errorf("issue has no position: %+v", *got)
+			}
+		})
+	}
+}
+
+func TestProfileIssues_PortsOnDifferentProtocolsDoNotConflict(t *testing.T) {
+	issues := profileIssues(t, `    services:
+      dns:
+        image: coredns
+        ports: ["5353:53/udp", "5353:53/tcp", "127.0.0.1:9000:9000", "127.0.0.2:9000:9000"]
+`)
+	if got := findIssue(issues, "port-conflict"); got != nil {
+		t.Fatalf("unexpected conflict: %+v", *got)
+	}
+}
+
+func TestValidateProfile_WarningsDoNotFail(t *testing.T) {
+	m, err := Parse([]byte(`version: 1
+project:
+  name: my-app
+  defaultProfile: local
+profiles:
+  local:
+    deps:
+      db:
+        image: postgres
+        source: acme/postgres
+`))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if err := ValidateProfile(m, "local"); err != nil {
+		t.Fatalf("warnings should not fail validation: %v", err)
+	}
+	if got := findIssue(ProfileIssues(m, "local"), "unused-field"); got == nil || got.Severity != SeverityWarning {
+		t.Fatalf("expected unused-field warning, got %+v", got)
+	}
+}
+
+func TestProfileIssues_BuildContext(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "api"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "worker"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "worker", "Dockerfile"), []byte("FROM scratch\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "devx.yaml")
+	manifest := `version: 1
+project:
+  name: my-app
+  defaultProfile: local
+profiles:
+  local:
+    services:
+      api:
+        build:
+          context: ./api
+      web:
+        build:
+          context: ./web
+      worker:
+        build:
+          context: ./worker
+`
+	if err := os.WriteFile(path, []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	var msgs []string
+	for _, issue := range ProfileIssues(m, "local") {
+		if issue.Rule == "missing-path" {
+			msgs = append(msgs, issue.Message)
+		}
+	}
+	want := []string{
+		"service 'api' Dockerfile 'Dockerfile' not found in build context './api'",
+		"service 'web' build context './web' does not exist",
+	}
+	if strings.Join(msgs, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got %q, want %q", msgs, want)
+	}
+}
+
+func TestManifestIssues_UnusedSecret(t *testing.T) {
+	m, err := Parse([]byte(`version: 1
+project:
+  name: my-app
+  defaultProfile: local
+secrets:
+  stripe_key:
+    env: STRIPE_KEY
+profiles:
+  local:
+    services:
+      api:
+        image: nginx
+`))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if err := Validate(m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := findIssue(ManifestIssues(m), "unused-secret")
+	if got == nil || got.Severity != SeverityWarning || got.Line != 6 {
+		t.Fatalf("expected positioned unused-secret warning, got %+v", got)
+	}
+}
+
+func TestManifestIssues_SecretName(t *testing.T) {
+	m, err := Parse([]byte(`version: 1
+project:
+  name: my-app
+  defaultProfile: local
+secrets:
+  ../../.ssh/x:
+    env: X
+  db_password.v2:
+    env: DB_PASSWORD
+profiles:
+  local:
+    services:
+      api:
+        image: nginx
+        secrets: [../../.ssh/x, db_password.v2]
+`))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	var got []string
+	for _, issue := range ManifestIssues(m) {
+		if issue.Rule == "invalid-name" {
+			got = append(got, issue.Path)
+		}
+	}
+	if len(got) != 1 || got[0] != "secrets.../../.ssh/x" {
+		t.Fatalf("expected one invalid-name issue for the path-like secret, got %v", got)
+	}
+}
+
+func TestContainerRuntime_ShortAndLongForms(t *testing.T) {
+	m, err := Parse([]byte(`version: 1
+project:
+  name: my-app
+  defaultProfile: local
+profiles:
+  local:
+    containerRuntime: podman
+  remote:
+    containerRuntime:
+      name: docker
+      binary: /opt/docker/bin/docker
+      context: colima
+`))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if got := m.Profiles["local"].ContainerRuntime; got != (ContainerRuntime{Name: "podman"}) {
+		t.Errorf("short form: %+v", got)
+	}
+	want := ContainerRuntime{Name: "docker", Binary: "/opt/docker/bin/docker", Context: "colima"}
+	if got := m.Profiles["remote"].ContainerRuntime; got != want {
+		t.Errorf("long form: %+v", got)
+	}
+	for _, name := range []string{"local", "remote"} {
+		if issues := ProfileIssues(m, name); len(issues) != 0 {
+			t.Errorf("profile %s: unexpected issues %v", name, issues)
+		}
+	}
+}
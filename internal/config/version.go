@@ -0,0 +1,86 @@
+package config
+
+import (
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the manifest version devx writes. Older versions are
+// upgraded in memory when loaded, and on disk by `devx migrate`.
+const CurrentVersion = 2
+
+// upgraders rewrite a manifest node tree from version N to N+1 in place,
+// keyed by N. Keys and values are moved rather than copied, so positions
+// recorded afterwards still point at the original text.
+var upgraders = map[int]func(root *yaml.Node){
+	1: upgradeV1,
+}
+
+// manifestVersion returns the version a manifest node declares, or 0 when
+// it declares none (fragments usually don't).
+func manifestVersion(root *yaml.Node) int {
+	v := mappingValue(root, "version")
+	if v == nil {
+		return 0
+	}
+	n, err := strconv.Atoi(v.Value)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// upgradeNode brings a node tree declared at version from up to
+// CurrentVersion. Unknown versions are left alone for Validate to report.
+func upgradeNode(root *yaml.Node, from int) {
+	for v := from; v < CurrentVersion; v++ {
+		up, ok := upgraders[v]
+		if !ok {
+			return
+		}
+		up(root)
+	}
+}
+
+// upgradeV1 turns health.httpGet into health.http.url. Ports need no
+// change: version 2 still accepts the short string form.
+func upgradeV1(root *yaml.Node) {
+	forEachEntry(root, "services", func(svc *yaml.Node) {
+		health := mappingValue(svc, "health")
+		if health == nil || health.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(health.Content); i += 2 {
+			key, val := health.Content[i], health.Content[i+1]
+			if key.Value != "httpGet" {
+				continue
+			}
+			key.Value = "http"
+			urlKey := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "url", Line: val.Line, Column: val.Column}
+			health.Content[i+1] = &yaml.Node{
+				Kind: yaml.MappingNode, Tag: "!!map", Line: val.Line, Column: val.Column,
+				Content: []*yaml.Node{urlKey, val},
+			}
+		}
+	})
+}
+
+// forEachEntry calls fn with every service or dep (per kind) in every profile.
+func forEachEntry(root *yaml.Node, kind string, fn func(entry *yaml.Node)) {
+	profiles := mappingValue(root, "profiles")
+	if profiles == nil || profiles.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 1; i < len(profiles.Content); i += 2 {
+		entries := mappingValue(profiles.Content[i], kind)
+		if entries == nil || entries.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 1; j < len(entries.Content); j += 2 {
+			if entry := entries.Content[j]; entry.Kind == yaml.MappingNode {
+				fn(entry)
+			}
+		}
+	}
+}
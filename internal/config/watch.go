@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Watch actions, from least to most disruptive.
+const (
+	WatchSync    = "sync"
+	WatchRestart = "restart"
+	WatchRebuild = "rebuild"
+)
+
+// WatchRule tells `devx up --watch` what to do when files under Path
+// change. When several rules match a change, the most disruptive action
+// wins: rebuild, then restart, then sync.
+type WatchRule struct {
+	// Path is a file or directory to watch, relative to devx.yaml.
+	Path string `yaml:"path" jsonschema:"required"`
+	// Action is sync (copy changed files into the running container),
+	// restart (recreate the container) or rebuild (build the image again
+	// and recreate the container).
+	Action string `yaml:"action" jsonschema:"required,enum=sync|restart|rebuild"`
+	// Target is the container path that Path is copied to. Required for sync.
+	Target string `yaml:"target,omitempty"`
+	// Ignore lists globs, relative to Path, whose changes are ignored. The
+	// build context's .dockerignore always applies.
+	Ignore []string `yaml:"ignore,omitempty"`
+}
+
+// WatchRank orders actions by how disruptive they are; unknown actions
+// rank zero.
+func WatchRank(action string) int {
+	switch action {
+	case WatchSync:
+		return 1
+	case WatchRestart:
+		return 2
+	case WatchRebuild:
+		return 3
+	}
+	return 0
+}
+
+// watchIssues checks a service's watch rules. dir is the manifest's
+// directory, or empty to skip filesystem checks.
+func watchIssues(dir, name, path string, svc Service, isK8s bool) []Issue {
+	if isK8s {
+		return []Issue{newWarning("unused-field", path, "service '%s' watch is ignored by the k8s runtime", name)}
+	}
+	var issues []Issue
+	for i, rule := range svc.Watch {
+		rulePath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case rule.Path == "":
+			issues = append(issues, newIssue("required", rulePath+".path", "service '%s' watch[%d] path is required", name, i))
+		case dir != "":
+			if _, err := os.Stat(filepath.Join(dir, rule.Path)); err != nil {
+				issues = append(issues, newIssue("missing-path", rulePath+".path", "service '%s' watch path '%s' does not exist", name, rule.Path))
+			}
+		}
+		switch rule.Action {
+		case WatchSync:
+			if rule.Target == "" {
+				issues = append(issues, newIssue("required", rulePath+".target", "service '%s' watch[%d] sync requires target", name, i))
+			} else if !strings.HasPrefix(rule.Target, "/") {
+				issues = append(issues, newIssue("invalid-value", rulePath+".target", "service '%s' watch target '%s' must be an absolute container path", name, rule.Target))
+			}
+		case WatchRestart, WatchRebuild:
+			if rule.Target != "" {
+				issues = append(issues, newWarning("unused-field", rulePath+".target", "service '%s' watch[%d] target is only used by sync", name, i))
+			}
+			if rule.Action == WatchRebuild && svc.Build == nil {
+				issues = append(issues, newIssue("invalid-value", rulePath+".action", "service '%s' watch[%d] rebuild requires build", name, i))
+			}
+		case "":
+			issues = append(issues, newIssue("required", rulePath+".action", "service '%s' watch[%d] action is required", name, i))
+		default:
+			issues = append(issues, newIssue("invalid-value", rulePath+".action", "service '%s' watch[%d] action '%s' must be sync, restart or rebuild", name, i, rule.Action))
+		}
+		for j, glob := range rule.Ignore {
+			if _, err := filepath.Match(glob, ""); err != nil {
+				issues = append(issues, newIssue("invalid-value", fmt.Sprintf("%s.ignore[%d]", rulePath, j), "service '%s' watch ignore '%s' is not a valid glob", name, glob))
+			}
+		}
+	}
+	return issues
+}
@@ -0,0 +1,174 @@
+// Package diagnostics collects what is known about services that failed to
+// start into a report that can be read in the terminal or attached to a
+// ticket as a .tar.gz bundle.
+package diagnostics
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dever-labs/devx/internal/runtime"
+	"github.com/dever-labs/devx/internal/util"
+)
+
+// Report describes one failed `devx up`.
+type Report struct {
+	Time     time.Time `json:"time"`
+	Project  string    `json:"project"`
+	Profile  string    `json:"profile"`
+	Runtime  string    `json:"runtime"`
+	Services []Service `json:"services"`
+}
+
+// Service is what was gathered about one service or dep that did not become
+// healthy.
+type Service struct {
+	Name string `json:"name"`
+	// Kind is "service" or "dep".
+	Kind string `json:"kind"`
+	// Blocks lists the services that could not start because of this one.
+	Blocks []string `json:"blocks,omitempty"`
+	// Probe describes how devx checked the service, e.g. "http
+	// http://localhost:8080/health".
+	Probe string `json:"probe"`
+	// LastResult is the outcome of the final check.
+	LastResult string                   `json:"lastResult"`
+	Containers []runtime.ContainerState `json:"containers,omitempty"`
+	// Env is the service's resolved environment with credentials masked.
+	Env map[string]string `json:"env,omitempty"`
+	// Logs holds the last lines the service's containers wrote.
+	Logs string `json:"-"`
+	// Problems lists anything that could not be collected.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// WriteText writes the report in a form meant to be read in a terminal.
+func (r *Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "devx up failed at %s (project %s, profile %s, runtime %s)\n",
+		r.Time.Format(time.RFC3339), r.Project, r.Profile, r.Runtime)
+	for _, s := range r.Services {
+		fmt.Fprintf(w, "\n── %s (%s) %s\n", s.Name, s.Kind, strings.Repeat("─", max(0, 60-len(s.Name)-len(s.Kind))))
+		if len(s.Blocks) > 0 {
+			fmt.Fprintf(w, "Blocks:       %s\n", strings.Join(s.Blocks, ", "))
+		}
+		fmt.Fprintf(w, "Probe:        %s\n", s.Probe)
+		fmt.Fprintf(w, "Last result:  %s\n", s.LastResult)
+		if len(s.Containers) == 0 {
+			fmt.Fprintln(w, "Container:    not created")
+		}
+		for _, c := range s.Containers {
+			fmt.Fprintf(w, "Container:    %s\n", describeContainer(c))
+			if n := len(c.HealthLog); n > 0 {
+				last := c.HealthLog[n-1]
+				fmt.Fprintf(w, "Healthcheck:  exit %d at %s: %s\n", last.ExitCode, last.Start, oneLine(last.Output))
+			}
+		}
+		if len(s.Env) > 0 {
+			fmt.Fprintln(w, "Env:")
+			for _, k := range util.SortedKeys(s.Env) {
+				fmt.Fprintf(w, "  %s=%s\n", k, s.Env[k])
+			}
+		}
+		for _, p := range s.Problems {
+			fmt.Fprintf(w, "Not collected: %s\n", p)
+		}
+		if logs := strings.TrimRight(s.Logs, "\n"); logs != "" {
+			fmt.Fprintln(w, "Logs:")
+			for _, line := range strings.Split(logs, "\n") {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+	}
+}
+
+// describeContainer summarises a container's state on one line, e.g.
+// "my-app-api-1 exited with code 137 (OOM killed), restarted 2 times".
+func describeContainer(c runtime.ContainerState) string {
+	var b strings.Builder
+	b.WriteString(c.Name + " " + c.Status)
+	if c.Status == "exited" || c.Status == "dead" {
+		fmt.Fprintf(&b, " with code %d", c.ExitCode)
+	}
+	if c.OOMKilled {
+		b.WriteString(" (OOM killed)")
+	}
+	if c.Health != "" {
+		b.WriteString(", " + c.Health)
+	}
+	if c.RestartCount > 0 {
+		fmt.Fprintf(&b, ", restarted %d times", c.RestartCount)
+	}
+	if c.Error != "" {
+		b.WriteString(": " + c.Error)
+	}
+	return b.String()
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// WriteBundle saves the report under dir as <timestamp>.tar.gz and returns
+// its path. The archive holds report.txt, report.json and one
+// logs/<name>.log per service.
+func (r *Report) WriteBundle(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, r.Time.UTC().Format("20060102T150405Z")+".tar.gz")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	if err := r.writeArchive(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	return path, f.Close()
+}
+
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+func (r *Report) writeArchive(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	var text strings.Builder
+	r.WriteText(&text)
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	files := []bundleFile{
+		{"report.txt", []byte(text.String())},
+		{"report.json", append(data, '\n')},
+	}
+	for _, s := range r.Services {
+		files = append(files, bundleFile{"logs/" + s.Name + ".log", []byte(s.Logs)})
+	}
+
+	for _, file := range files {
+		hdr := &tar.Header{Name: file.name, Mode: 0644, Size: int64(len(file.data)), ModTime: r.Time}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,100 @@
+package diagnostics
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dever-labs/devx/internal/runtime"
+)
+
+func testReport() *Report {
+	return &Report{
+		Time:    time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC),
+		Project: "my-app",
+		Profile: "local",
+		Runtime: "docker",
+		Services: []Service{{
+			Name:       "api",
+			Kind:       "service",
+			Blocks:     []string{"web"},
+			Probe:      "http http://localhost:8080/health",
+			LastResult: "unhealthy after 3 checks in 15s: http://localhost:8080/health returned 503 Service Unavailable",
+			Containers: []runtime.ContainerState{{
+				Name: "my-app-api-1", Status: "exited", ExitCode: 137, OOMKilled: true, RestartCount: 1,
+				HealthLog: []runtime.HealthResult{{Start: "09:29:58", ExitCode: 1, Output: "wget: error\ngetting response"}},
+			}},
+			Env:  map[string]string{"PORT": "8080", "DB_PASSWORD": "****"},
+			Logs: "listening on :8080\nout of memory\n",
+		}},
+	}
+}
+
+func TestReport_WriteText(t *testing.T) {
+	var b strings.Builder
+	testReport().WriteText(&b)
+	out := b.String()
+	for _, want := range []string{
+		"devx up failed at 2026-10-17T09:30:00Z (project my-app, profile local, runtime docker)",
+		"── api (service) ",
+		"Blocks:       web",
+		"Last result:  unhealthy after 3 checks in 15s: http://localhost:8080/health returned 503 Service Unavailable",
+		"Container:    my-app-api-1 exited with code 137 (OOM killed), restarted 1 times",
+		"Healthcheck:  exit 1 at 09:29:58: wget: error getting response",
+		"  DB_PASSWORD=****\n  PORT=8080\n",
+		"Logs:\n  listening on :8080\n  out of memory\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected report to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestReport_WriteBundle(t *testing.T) {
+	dir := t.TempDir()
+	path, err := testReport().WriteBundle(filepath.Join(dir, "diagnostics"))
+	if err != nil {
+		t.Fatalf("write bundle failed: %v", err)
+	}
+	if filepath.Base(path) != "20261017T093000Z.tar.gz" {
+		t.Errorf("unexpected bundle name %s", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		files[hdr.Name] = string(data)
+	}
+
+	if !strings.Contains(files["report.txt"], "OOM killed") {
+		t.Errorf("report.txt missing container state: %q", files["report.txt"])
+	}
+	if !strings.Contains(files["report.json"], `"OOMKilled": true`) {
+		t.Errorf("report.json missing container state: %q", files["report.json"])
+	}
+	if files["logs/api.log"] != "listening on :8080\nout of memory\n" {
+		t.Errorf("unexpected logs/api.log: %q", files["logs/api.log"])
+	}
+}
@@ -14,14 +14,18 @@ import (
 	"github.com/dever-labs/devx/internal/config"
 	"github.com/dever-labs/devx/internal/k8s"
 	"github.com/dever-labs/devx/internal/runtime"
-	"github.com/dever-labs/devx/internal/runtime/docker"
-	"github.com/dever-labs/devx/internal/runtime/podman"
 	"github.com/dever-labs/devx/internal/setup"
 )
 
 type Options struct {
 	Manifest *config.Manifest
 	Fix      bool
+	// Runtimes creates the container runtimes to check.
+	Runtimes *runtime.Factory
+	// Runtime is the container runtime devx commands would ask for, and
+	// RuntimeReason why; an empty name means the first one detected.
+	Runtime       runtime.Config
+	RuntimeReason string
 }
 
 type Check struct {
@@ -52,25 +56,8 @@ func Run(ctx context.Context, opts Options) Report {
 		Detail: fmt.Sprintf("devx (dev) on %s/%s", goruntime.GOOS, goruntime.GOARCH),
 	})
 
-	runtimeChecks := detectAllRuntimes(ctx)
-	for _, info := range runtimeChecks {
-		status := "FAIL"
-		if info.Available {
-			status = "PASS"
-		}
-		detail := info.Details
-		if detail == "" {
-			detail = info.Name
-		}
-		checks = append(checks, Check{
-			Name:   fmt.Sprintf("Runtime: %s", info.Name),
-			Status: status,
-			Detail: detail,
-		})
-
-		if info.Available {
-			checks = append(checks, detectCompose(ctx, info.Name))
-		}
+	if opts.Runtimes != nil {
+		checks = append(checks, checkRuntimes(ctx, opts)...)
 	}
 
 	if opts.Manifest != nil {
@@ -153,21 +140,60 @@ func checkTools(ctx context.Context, m *config.Manifest, fix bool) []Check {
 	return checks
 }
 
-func detectAllRuntimes(ctx context.Context) []runtime.RuntimeInfo {
-	var infos []runtime.RuntimeInfo
-	for _, rt := range []runtime.Runtime{docker.New(), podman.New()} {
+// composeCLIs are the runtimes that run compose files through their CLI's
+// compose command.
+var composeCLIs = map[string]bool{"docker": true, "podman": true, "nerdctl": true}
+
+// checkRuntimes reports whether each container runtime is available and
+// which one devx commands would use.
+func checkRuntimes(ctx context.Context, opts Options) []Check {
+	var checks []Check
+	for _, name := range opts.Runtimes.Names() {
+		cfg := runtime.Config{Name: name}
+		if opts.Runtime.Name == name {
+			cfg = opts.Runtime
+		}
+		rt, err := opts.Runtimes.New(cfg)
+		if err != nil {
+			continue
+		}
 		ok, err := rt.Detect(ctx)
-		info := runtime.RuntimeInfo{Name: rt.Name(), Available: ok}
+		status, detail := "FAIL", name
+		if ok {
+			status = "PASS"
+		}
 		if err != nil {
-			info.Details = err.Error()
+			detail = err.Error()
+		} else if cfg.Binary != "" {
+			detail = cfg.Binary
 		}
-		infos = append(infos, info)
+		checks = append(checks, Check{
+			Name:   fmt.Sprintf("Runtime: %s", name),
+			Status: status,
+			Detail: detail,
+		})
+
+		if ok && composeCLIs[name] {
+			binary := name
+			if cfg.Binary != "" {
+				binary = cfg.Binary
+			}
+			checks = append(checks, detectCompose(ctx, name, binary))
+		}
+	}
+
+	sel, err := opts.Runtimes.Select(ctx, opts.Runtime, opts.RuntimeReason)
+	if err != nil {
+		return append(checks, Check{Name: "Runtime selected", Status: "FAIL", Detail: err.Error()})
 	}
-	return infos
+	return append(checks, Check{
+		Name:   "Runtime selected",
+		Status: "PASS",
+		Detail: fmt.Sprintf("%s (%s)", sel.Runtime.Name(), sel.Reason),
+	})
 }
 
-func detectCompose(ctx context.Context, runtimeName string) Check {
-	binary := runtimeName
+func detectCompose(ctx context.Context, runtimeName, binary string) Check {
 	cmd := exec.CommandContext(ctx, binary, "compose", "version")
 	if err := cmd.Run(); err != nil {
 		return Check{
@@ -189,13 +215,19 @@ func checkPortConflicts(manifest *config.Manifest) Check {
 	for _, prof := range manifest.Profiles {
 		for name, svc := range prof.Services {
 			for _, port := range svc.Ports {
-				host := strings.Split(port, ":")[0]
+				if port.Host.IsZero() {
+					continue
+				}
+				host := port.Host.String() + "/" + port.Proto()
 				ports[host] = append(ports[host], name)
 			}
 		}
 		for name, dep := range prof.Deps {
 			for _, port := range dep.Ports {
-				host := strings.Split(port, ":")[0]
+				if port.Host.IsZero() {
+					continue
+				}
+				host := port.Host.String() + "/" + port.Proto()
 				ports[host] = append(ports[host], name)
 			}
 		}
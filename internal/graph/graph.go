@@ -3,6 +3,7 @@ package graph
 import (
 	"container/heap"
 	"fmt"
+	"sort"
 
 	"github.com/dever-labs/devx/internal/config"
 )
@@ -112,3 +113,99 @@ func TopoSort(g *Graph) ([]string, error) {
 
 	return order, nil
 }
+
+// Waves groups the graph into start order. Each wave holds, sorted by name,
+// the nodes whose dependencies all belong to earlier waves, so the nodes in
+// one wave can start together.
+func Waves(g *Graph) ([][]string, error) {
+	order, err := TopoSort(g)
+	if err != nil {
+		return nil, err
+	}
+
+	level := map[string]int{}
+	var waves [][]string
+	for _, name := range order {
+		l := 0
+		for _, dep := range g.Nodes[name].DependsOn {
+			l = max(l, level[dep]+1)
+		}
+		level[name] = l
+		if l == len(waves) {
+			waves = append(waves, nil)
+		}
+		waves[l] = append(waves[l], name)
+	}
+	for _, wave := range waves {
+		sort.Strings(wave)
+	}
+	return waves, nil
+}
+
+// Dependents returns, sorted, every node that depends on name directly or
+// through other nodes.
+func Dependents(g *Graph, name string) []string {
+	seen := map[string]bool{}
+	var visit func(string)
+	visit = func(target string) {
+		for other, node := range g.Nodes {
+			if seen[other] {
+				continue
+			}
+			for _, dep := range node.DependsOn {
+				if dep == target {
+					seen[other] = true
+					visit(other)
+					break
+				}
+			}
+		}
+	}
+	visit(name)
+
+	out := make([]string, 0, len(seen))
+	for other := range seen {
+		out = append(out, other)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Subgraph returns the graph restricted to names and, when withDeps is
+// set, everything they depend on directly or through other nodes. Edges to
+// nodes left out are dropped, so the result can be ordered on its own.
+func Subgraph(g *Graph, names []string, withDeps bool) (*Graph, error) {
+	keep := map[string]bool{}
+	var visit func(string)
+	visit = func(name string) {
+		if keep[name] {
+			return
+		}
+		keep[name] = true
+		if withDeps {
+			for _, dep := range g.Nodes[name].DependsOn {
+				visit(dep)
+			}
+		}
+	}
+	for _, name := range names {
+		if _, ok := g.Nodes[name]; !ok {
+			return nil, fmt.Errorf("unknown service '%s'", name)
+		}
+		visit(name)
+	}
+
+	nodes := make(map[string]Node, len(keep))
+	for name := range keep {
+		node := g.Nodes[name]
+		var deps []string
+		for _, dep := range node.DependsOn {
+			if keep[dep] {
+				deps = append(deps, dep)
+			}
+		}
+		node.DependsOn = deps
+		nodes[name] = node
+	}
+	return &Graph{Nodes: nodes}, nil
+}
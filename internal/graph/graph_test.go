@@ -1,6 +1,7 @@
 package graph
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/dever-labs/devx/internal/config"
@@ -48,3 +49,74 @@ func TestTopoSortCycle(t *testing.T) {
 		t.Fatalf("expected cycle error")
 	}
 }
+
+func TestWaves(t *testing.T) {
+	prof := &config.Profile{
+		Services: map[string]config.Service{
+			"api":    {DependsOn: []string{"db", "cache"}},
+			"worker": {DependsOn: []string{"db"}},
+			"web":    {DependsOn: []string{"api"}},
+		},
+		Deps: map[string]config.Dep{
+			"db":    {Kind: "postgres"},
+			"cache": {Kind: "redis"},
+		},
+	}
+
+	g, err := Build(prof)
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	waves, err := Waves(g)
+	if err != nil {
+		t.Fatalf("waves failed: %v", err)
+	}
+	want := [][]string{{"cache", "db"}, {"api", "worker"}, {"web"}}
+	if !reflect.DeepEqual(waves, want) {
+		t.Fatalf("got %v, want %v", waves, want)
+	}
+
+	if got := Dependents(g, "db"); !reflect.DeepEqual(got, []string{"api", "web", "worker"}) {
+		t.Fatalf("unexpected dependents of db: %v", got)
+	}
+}
+
+func TestSubgraph(t *testing.T) {
+	prof := &config.Profile{
+		Services: map[string]config.Service{
+			"api":    {DependsOn: []string{"db", "cache"}},
+			"worker": {DependsOn: []string{"db"}},
+			"web":    {DependsOn: []string{"api"}},
+		},
+		Deps: map[string]config.Dep{
+			"db":    {Kind: "postgres"},
+			"cache": {Kind: "redis"},
+		},
+	}
+	g, err := Build(prof)
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	sub, err := Subgraph(g, []string{"web", "worker"}, true)
+	if err != nil {
+		t.Fatalf("subgraph failed: %v", err)
+	}
+	waves, _ := Waves(sub)
+	if want := [][]string{{"cache", "db"}, {"api", "worker"}, {"web"}}; !reflect.DeepEqual(waves, want) {
+		t.Fatalf("with deps: got %v, want %v", waves, want)
+	}
+
+	sub, err = Subgraph(g, []string{"web", "api"}, false)
+	if err != nil {
+		t.Fatalf("subgraph failed: %v", err)
+	}
+	waves, _ = Waves(sub)
+	if want := [][]string{{"api"}, {"web"}}; !reflect.DeepEqual(waves, want) {
+		t.Fatalf("without deps: got %v, want %v", waves, want)
+	}
+
+	if _, err := Subgraph(g, []string{"nope"}, true); err == nil || err.Error() != "unknown service 'nope'" {
+		t.Fatalf("expected an unknown service error, got %v", err)
+	}
+}
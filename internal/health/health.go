@@ -0,0 +1,96 @@
+// Package health runs service health checks from the host and waits for
+// them to pass on the schedule the manifest declares.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/dever-labs/devx/internal/config"
+)
+
+// Check runs a single health check attempt. The context carries the
+// attempt's timeout.
+type Check func(ctx context.Context) error
+
+// HTTP returns a check that requests url and passes when accept returns
+// true for the response code.
+func HTTP(url string, accept func(code int) bool) Check {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if !accept(resp.StatusCode) {
+			return fmt.Errorf("%s returned %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// TCP returns a check that passes when addr accepts a connection.
+func TCP(addr string) Check {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// initialBackoff is the delay before the first retry. Each failed check
+// doubles it, up to the probe's interval, so services that start quickly are
+// noticed quickly without polling slow ones harder than declared.
+const initialBackoff = 100 * time.Millisecond
+
+// Wait runs check until it passes timing.SuccessThreshold times in a row,
+// or until timing.Deadline has passed since the first check. Retries back
+// off exponentially up to timing.Interval; passing checks are repeated at
+// timing.Interval. The error wraps the last failure, or is ctx's error when
+// ctx is cancelled.
+func Wait(ctx context.Context, check Check, timing config.HealthTiming) error {
+	start := time.Now()
+	deadline := timing.Deadline()
+	backoff := min(initialBackoff, timing.Interval)
+	checks, successes := 0, 0
+	for {
+		attemptCtx, cancel := context.WithTimeout(ctx, timing.Timeout)
+		err := check(attemptCtx)
+		cancel()
+		checks++
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		delay := timing.Interval
+		if err == nil {
+			successes++
+			if successes >= timing.SuccessThreshold {
+				return nil
+			}
+		} else {
+			successes = 0
+			if elapsed := time.Since(start); elapsed >= deadline {
+				return fmt.Errorf("unhealthy after %d checks in %s: %w", checks, elapsed.Round(time.Millisecond), err)
+			}
+			delay = backoff
+			backoff = min(backoff*2, timing.Interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dever-labs/devx/internal/config"
+)
+
+func TestHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ready" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	twoXX := config.HTTPProbe{}.Accepts
+	if err := HTTP(srv.URL+"/ready", twoXX)(context.Background()); err != nil {
+		t.Fatalf("expected /ready to pass: %v", err)
+	}
+	if err := HTTP(srv.URL+"/live", twoXX)(context.Background()); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("expected /live to fail with 503, got %v", err)
+	}
+	if err := HTTP(srv.URL+"/live", config.HTTPProbe{Status: []int{503}}.Accepts)(context.Background()); err != nil {
+		t.Fatalf("expected 503 to be accepted: %v", err)
+	}
+}
+
+func TestWait(t *testing.T) {
+	timing := config.HealthTiming{Interval: time.Millisecond, Timeout: time.Second, Retries: 3, SuccessThreshold: 2}
+
+	calls := 0
+	flaky := func(ctx context.Context) error {
+		calls++
+		if calls%2 == 1 || calls > 4 {
+			return nil
+		}
+		return errors.New("not yet")
+	}
+	// pass, fail, pass, fail, pass, pass: only the last two are consecutive.
+	if err := Wait(context.Background(), flaky, timing); err != nil {
+		t.Fatalf("expected wait to succeed: %v", err)
+	}
+	if calls != 6 {
+		t.Fatalf("expected 6 checks, got %d", calls)
+	}
+
+	calls = 0
+	failing := func(ctx context.Context) error {
+		calls++
+		return errors.New("connection refused")
+	}
+	err := Wait(context.Background(), failing, timing)
+	if err == nil || !strings.HasPrefix(err.Error(), "unhealthy after ") || !strings.HasSuffix(err.Error(), ": connection refused") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Failing checks keep being retried until the start period and
+	// retries have both run out.
+	calls = 0
+	timing.StartPeriod = 20 * time.Millisecond
+	start := time.Now()
+	_ = Wait(context.Background(), failing, timing)
+	if elapsed := time.Since(start); elapsed < timing.Deadline() || calls <= 3 {
+		t.Fatalf("expected retries until the deadline, got %d checks in %s", calls, elapsed)
+	}
+}
+
+func TestWait_Backoff(t *testing.T) {
+	timing := config.HealthTiming{Interval: time.Second, Timeout: time.Second, Retries: 1, SuccessThreshold: 1}
+
+	var times []time.Time
+	check := func(ctx context.Context) error {
+		times = append(times, time.Now())
+		if len(times) < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}
+	if err := Wait(context.Background(), check, timing); err != nil {
+		t.Fatalf("expected wait to succeed: %v", err)
+	}
+	// Retries start at 100ms and double, well short of the 1s interval.
+	first, second := times[1].Sub(times[0]), times[2].Sub(times[1])
+	if first >= timing.Interval/2 || second < first*3/2 {
+		t.Fatalf("expected a short, doubling backoff, got %s then %s", first, second)
+	}
+}
+
+func TestWait_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	timing := config.HealthTiming{Interval: time.Hour, Timeout: time.Second, Retries: 1, SuccessThreshold: 1}
+
+	done := make(chan error)
+	go func() {
+		done <- Wait(ctx, func(context.Context) error { return errors.New("not yet") }, timing)
+	}()
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("wait did not stop when cancelled")
+	}
+}
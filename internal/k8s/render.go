@@ -2,9 +2,12 @@ package k8s
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dever-labs/devx/internal/config"
 	"github.com/dever-labs/devx/internal/util"
@@ -45,13 +48,48 @@ type PodSpec struct {
 }
 
 type Container struct {
-	Name         string          `yaml:"name"`
-	Image        string          `yaml:"image"`
-	Command      []string        `yaml:"command,omitempty"`
-	WorkingDir   string          `yaml:"workingDir,omitempty"`
-	Env          []EnvVar        `yaml:"env,omitempty"`
-	Ports        []ContainerPort `yaml:"ports,omitempty"`
-	VolumeMounts []VolumeMount   `yaml:"volumeMounts,omitempty"`
+	Name           string          `yaml:"name"`
+	Image          string          `yaml:"image"`
+	Command        []string        `yaml:"command,omitempty"`
+	WorkingDir     string          `yaml:"workingDir,omitempty"`
+	Env            []EnvVar        `yaml:"env,omitempty"`
+	Ports          []ContainerPort `yaml:"ports,omitempty"`
+	VolumeMounts   []VolumeMount   `yaml:"volumeMounts,omitempty"`
+	StartupProbe   *Probe          `yaml:"startupProbe,omitempty"`
+	ReadinessProbe *Probe          `yaml:"readinessProbe,omitempty"`
+	LivenessProbe  *Probe          `yaml:"livenessProbe,omitempty"`
+}
+
+type Probe struct {
+	HTTPGet             *HTTPGetAction   `yaml:"httpGet,omitempty"`
+	TCPSocket           *TCPSocketAction `yaml:"tcpSocket,omitempty"`
+	Exec                *ExecAction      `yaml:"exec,omitempty"`
+	GRPC                *GRPCAction      `yaml:"grpc,omitempty"`
+	InitialDelaySeconds int              `yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int              `yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds      int              `yaml:"timeoutSeconds,omitempty"`
+	SuccessThreshold    int              `yaml:"successThreshold,omitempty"`
+	FailureThreshold    int              `yaml:"failureThreshold,omitempty"`
+}
+
+type HTTPGetAction struct {
+	Host   string `yaml:"host,omitempty"`
+	Path   string `yaml:"path"`
+	Port   int    `yaml:"port"`
+	Scheme string `yaml:"scheme,omitempty"`
+}
+
+type TCPSocketAction struct {
+	Port int `yaml:"port"`
+}
+
+type ExecAction struct {
+	Command []string `yaml:"command"`
+}
+
+type GRPCAction struct {
+	Port    int    `yaml:"port"`
+	Service string `yaml:"service,omitempty"`
 }
 
 type EnvVar struct {
@@ -60,21 +98,42 @@ type EnvVar struct {
 }
 
 type ContainerPort struct {
-	ContainerPort int `yaml:"containerPort"`
+	Name          string `yaml:"name,omitempty"`
+	ContainerPort int    `yaml:"containerPort"`
+	Protocol      string `yaml:"protocol,omitempty"`
 }
 
 type VolumeMount struct {
 	Name      string `yaml:"name"`
 	MountPath string `yaml:"mountPath"`
+	SubPath   string `yaml:"subPath,omitempty"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
 }
 
 type Volume struct {
-	Name     string    `yaml:"name"`
-	EmptyDir *EmptyDir `yaml:"emptyDir,omitempty"`
+	Name     string        `yaml:"name"`
+	EmptyDir *EmptyDir     `yaml:"emptyDir,omitempty"`
+	Secret   *SecretVolume `yaml:"secret,omitempty"`
 }
 
 type EmptyDir struct{}
 
+type SecretVolume struct {
+	SecretName string `yaml:"secretName"`
+}
+
+type Secret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   ObjectMeta        `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	Data       map[string]string `yaml:"data"`
+}
+
+// secretKey is the single data key of every Secret devx renders; it is
+// mounted as the file /run/secrets/<name>, matching compose.
+const secretKey = "value"
+
 type Service struct {
 	APIVersion string      `yaml:"apiVersion"`
 	Kind       string      `yaml:"kind"`
@@ -92,9 +151,13 @@ type ServicePort struct {
 	Name       string `yaml:"name,omitempty"`
 	Port       int    `yaml:"port"`
 	TargetPort int    `yaml:"targetPort"`
+	Protocol   string `yaml:"protocol,omitempty"`
 }
 
-func Render(manifest *config.Manifest, profileName string, profile *config.Profile, namespace string) (string, error) {
+// Render converts a profile into Kubernetes manifests. secrets holds the
+// resolved value of every secret the profile references; each becomes an
+// Opaque Secret mounted into the pods that use it.
+func Render(manifest *config.Manifest, profileName string, profile *config.Profile, namespace string, secrets map[string]string) (string, error) {
 	if manifest == nil || profile == nil {
 		return "", fmt.Errorf("manifest and profile are required")
 	}
@@ -102,6 +165,20 @@ func Render(manifest *config.Manifest, profileName string, profile *config.Profi
 	var docs []any
 	project := sanitizeName(manifest.Project.Name)
 
+	for _, name := range config.ProfileSecrets(profile) {
+		value, ok := secrets[name]
+		if !ok {
+			return "", fmt.Errorf("secret '%s' has no value for k8s render", name)
+		}
+		docs = append(docs, Secret{
+			APIVersion: "v1",
+			Kind:       "Secret",
+			Metadata:   ObjectMeta{Name: secretName(project, name), Namespace: namespace},
+			Type:       "Opaque",
+			Data:       map[string]string{secretKey: base64.StdEncoding.EncodeToString([]byte(value))},
+		})
+	}
+
 	for _, name := range util.SortedKeys(profile.Services) {
 		svc := profile.Services[name]
 		if svc.Build != nil && svc.Image == "" {
@@ -125,6 +202,13 @@ func Render(manifest *config.Manifest, profileName string, profile *config.Profi
 			Env:        envVars(svc.Env),
 			Ports:      containerPorts(svc.Ports),
 		}
+		volumes, mounts := secretVolumes(project, svc.Secrets)
+		container.VolumeMounts = mounts
+		if svc.Health != nil && svc.Health.ProbeKind() != "" {
+			if err := setProbes(&container, svc); err != nil {
+				return "", fmt.Errorf("service '%s' health: %w", name, err)
+			}
+		}
 
 		docs = append(docs, Deployment{
 			APIVersion: "apps/v1",
@@ -135,7 +219,7 @@ func Render(manifest *config.Manifest, profileName string, profile *config.Profi
 				Selector: LabelSelector{MatchLabels: labels},
 				Template: PodTemplateSpec{
 					Metadata: ObjectMeta{Labels: labels},
-					Spec:     PodSpec{Containers: []Container{container}},
+					Spec:     PodSpec{Containers: []Container{container}, Volumes: volumes},
 				},
 			},
 		})
@@ -172,7 +256,9 @@ func Render(manifest *config.Manifest, profileName string, profile *config.Profi
 		if err != nil {
 			return "", err
 		}
-		container.VolumeMounts = mounts
+		secretVols, secretMounts := secretVolumes(project, dep.Secrets)
+		volumes = append(volumes, secretVols...)
+		container.VolumeMounts = append(mounts, secretMounts...)
 
 		docs = append(docs, Deployment{
 			APIVersion: "apps/v1",
@@ -228,19 +314,25 @@ func envVars(env map[string]string) []EnvVar {
 	return vars
 }
 
-func containerPorts(ports []string) []ContainerPort {
+// containerPorts lists every container port once. Host ports do not apply
+// in a cluster, and ranges expand to one port each since Kubernetes has no
+// range syntax. Ports that fail validation are skipped.
+func containerPorts(ports []config.Port) []ContainerPort {
 	var out []ContainerPort
-	seen := map[int]bool{}
+	seen := map[string]bool{}
 	for _, port := range ports {
-		cport, err := parseContainerPort(port)
-		if err != nil {
-			continue
+		protocol := ""
+		if port.Proto() != "tcp" {
+			protocol = strings.ToUpper(port.Proto())
 		}
-		if seen[cport] {
-			continue
+		for _, n := range port.Container.Ports() {
+			key := fmt.Sprintf("%d/%s", n, protocol)
+			if n < 1 || n > 65535 || seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, ContainerPort{Name: port.Name, ContainerPort: n, Protocol: protocol})
 		}
-		seen[cport] = true
-		out = append(out, ContainerPort{ContainerPort: cport})
 	}
 	return out
 }
@@ -248,20 +340,83 @@ func containerPorts(ports []string) []ContainerPort {
 func servicePorts(ports []ContainerPort) []ServicePort {
 	out := make([]ServicePort, 0, len(ports))
 	for _, port := range ports {
-		name := fmt.Sprintf("p-%d", port.ContainerPort)
-		out = append(out, ServicePort{Name: name, Port: port.ContainerPort, TargetPort: port.ContainerPort})
+		name := port.Name
+		if name == "" {
+			name = fmt.Sprintf("p-%d", port.ContainerPort)
+			if port.Protocol != "" {
+				name += "-" + strings.ToLower(port.Protocol)
+			}
+		}
+		out = append(out, ServicePort{Name: name, Port: port.ContainerPort, TargetPort: port.ContainerPort, Protocol: port.Protocol})
 	}
 	return out
 }
 
-func parseContainerPort(port string) (int, error) {
-	parts := strings.Split(port, ":")
-	last := parts[len(parts)-1]
-	value, err := strconv.Atoi(last)
-	if err != nil {
-		return 0, err
+// setProbes renders a service's health check as startup, readiness and
+// liveness probes sharing one handler. The startup probe carries the start
+// period and retries, so a slow start is not mistaken for a failure; the
+// readiness probe carries the success threshold, which Kubernetes only
+// allows there.
+func setProbes(container *Container, svc config.Service) error {
+	h := svc.Health
+	var handler Probe
+	switch h.ProbeKind() {
+	case "http":
+		raw, err := h.HTTP.ContainerURL(svc.Ports)
+		if err != nil {
+			return err
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return err
+		}
+		action := &HTTPGetAction{Path: u.RequestURI()}
+		if u.Scheme == "https" {
+			action.Scheme = "HTTPS"
+		}
+		if action.Port, err = strconv.Atoi(u.Port()); err != nil {
+			action.Port = 80
+			if u.Scheme == "https" {
+				action.Port = 443
+			}
+		}
+		if host := u.Hostname(); host != "localhost" && host != "127.0.0.1" && host != "::1" {
+			action.Host = host
+		}
+		handler.HTTPGet = action
+	case "tcp":
+		port, ok := config.ProbePort(svc.Ports, h.TCP.Port)
+		if !ok {
+			return fmt.Errorf("port '%s' is not declared", h.TCP.Port)
+		}
+		handler.TCPSocket = &TCPSocketAction{Port: port}
+	case "exec":
+		handler.Exec = &ExecAction{Command: h.Exec.Command}
+	case "grpc":
+		port, ok := config.ProbePort(svc.Ports, h.GRPC.Port)
+		if !ok {
+			return fmt.Errorf("port '%s' is not declared", h.GRPC.Port)
+		}
+		handler.GRPC = &GRPCAction{Port: port, Service: h.GRPC.Service}
 	}
-	return value, nil
+
+	timing := h.Timing()
+	handler.PeriodSeconds = seconds(timing.Interval)
+	handler.TimeoutSeconds = seconds(timing.Timeout)
+
+	startup, readiness, liveness := handler, handler, handler
+	startup.InitialDelaySeconds = int(timing.StartPeriod / time.Second)
+	startup.FailureThreshold = timing.Retries
+	if timing.SuccessThreshold > 1 {
+		readiness.SuccessThreshold = timing.SuccessThreshold
+	}
+	container.StartupProbe, container.ReadinessProbe, container.LivenessProbe = &startup, &readiness, &liveness
+	return nil
+}
+
+// seconds rounds d up to whole seconds, the unit probe settings take.
+func seconds(d time.Duration) int {
+	return max(int((d+time.Second-1)/time.Second), 1)
 }
 
 func depVolumes(depName string, volume string) ([]Volume, []VolumeMount, error) {
@@ -279,6 +434,27 @@ func depVolumes(depName string, volume string) ([]Volume, []VolumeMount, error)
 	return []Volume{{Name: volName, EmptyDir: &EmptyDir{}}}, []VolumeMount{{Name: volName, MountPath: mountPath}}, nil
 }
 
+// secretVolumes mounts each named secret read-only at /run/secrets/<name>.
+func secretVolumes(project string, names []string) ([]Volume, []VolumeMount) {
+	var volumes []Volume
+	var mounts []VolumeMount
+	for _, name := range names {
+		volName := sanitizeName("secret-" + name)
+		volumes = append(volumes, Volume{Name: volName, Secret: &SecretVolume{SecretName: secretName(project, name)}})
+		mounts = append(mounts, VolumeMount{
+			Name:      volName,
+			MountPath: config.SecretMountPath + "/" + name,
+			SubPath:   secretKey,
+			ReadOnly:  true,
+		})
+	}
+	return volumes, mounts
+}
+
+func secretName(project, name string) string {
+	return project + "-" + sanitizeName(name)
+}
+
 func sanitizeName(value string) string {
 	value = strings.ToLower(value)
 	var out strings.Builder
@@ -14,14 +14,14 @@ func TestRenderK8s(t *testing.T) {
 	}
 	profile := &config.Profile{
 		Services: map[string]config.Service{
-			"api": {Image: "nginx:alpine", Ports: []string{"8080:80"}},
+			"api": {Image: "nginx:alpine", Ports: []config.Port{{Host: config.PortRange{First: 8080}, Container: config.PortRange{First: 80}}}},
 		},
 		Deps: map[string]config.Dep{
-			"db": {Image: "postgres:16", Ports: []string{"5432:5432"}},
+			"db": {Image: "postgres:16", Ports: []config.Port{{Host: config.PortRange{First: 5432}, Container: config.PortRange{First: 5432}}}},
 		},
 	}
 
-	out, err := Render(manifest, "local", profile, "")
+	out, err := Render(manifest, "local", profile, "", nil)
 	if err != nil {
 		t.Fatalf("render failed: %v", err)
 	}
@@ -39,3 +39,117 @@ func TestRenderK8s(t *testing.T) {
 		t.Fatalf("expected dep image in output")
 	}
 }
+
+func TestRenderK8s_Secrets(t *testing.T) {
+	manifest := &config.Manifest{
+		Version: 1,
+		Project: config.Project{Name: "my-app", DefaultProfile: "k8s"},
+		Secrets: map[string]config.Secret{"db_password": {Env: "DB_PASSWORD"}},
+	}
+	profile := &config.Profile{
+		Deps: map[string]config.Dep{
+			"db": {Image: "postgres:16", Secrets: []string{"db_password"}},
+		},
+	}
+
+	out, err := Render(manifest, "k8s", profile, "", map[string]string{"db_password": "s3cret"})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"kind: Secret",
+		"name: my-app-db-password",
+		"value: czNjcmV0",
+		"secretName: my-app-db-password",
+		"mountPath: /run/secrets/db_password",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "s3cret") {
+		t.Fatalf("secret value must not appear in plain text:\n%s", out)
+	}
+
+	if _, err := Render(manifest, "k8s", profile, "", nil); err == nil {
+		t.Fatalf("expected error when secret value is missing")
+	}
+}
+
+func TestRenderK8s_Ports(t *testing.T) {
+	manifest := &config.Manifest{
+		Version: 2,
+		Project: config.Project{Name: "my-app", DefaultProfile: "k8s"},
+	}
+	profile := &config.Profile{
+		Services: map[string]config.Service{
+			"api": {Image: "nginx:alpine", Ports: []config.Port{
+				{Name: "http", Host: config.PortRange{First: 8080}, Container: config.PortRange{First: 80}},
+				{Container: config.PortRange{First: 53}, Protocol: "udp"},
+				{Host: config.PortRange{First: 9000, Last: 9001}, Container: config.PortRange{First: 9000, Last: 9001}},
+			}},
+		},
+	}
+
+	out, err := Render(manifest, "k8s", profile, "", nil)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"- name: http\n              containerPort: 80\n",
+		"- containerPort: 53\n              protocol: UDP\n",
+		"- containerPort: 9000\n",
+		"- containerPort: 9001\n",
+		"- name: http\n      port: 80\n      targetPort: 80\n",
+		"- name: p-53-udp\n      port: 53\n      targetPort: 53\n      protocol: UDP\n",
+		"- name: p-9001\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderK8s_Probes(t *testing.T) {
+	manifest := &config.Manifest{
+		Version: 2,
+		Project: config.Project{Name: "my-app", DefaultProfile: "k8s"},
+	}
+	profile := &config.Profile{
+		Services: map[string]config.Service{
+			"api": {
+				Image: "api:latest",
+				Ports: []config.Port{{Name: "http", Host: config.PortRange{First: 8080}, Container: config.PortRange{First: 80}}},
+				Health: &config.Health{
+					HTTP:             &config.HTTPProbe{URL: "http://localhost:8080/health?deep=1"},
+					Interval:         "10s",
+					StartPeriod:      "30s",
+					Retries:          5,
+					SuccessThreshold: 2,
+				},
+			},
+			"rpc": {
+				Image:  "rpc:latest",
+				Ports:  []config.Port{{Name: "grpc", Container: config.PortRange{First: 50051}}},
+				Health: &config.Health{GRPC: &config.GRPCProbe{Port: "grpc", Service: "rpc.v1"}, Timeout: "1500ms"},
+			},
+		},
+	}
+
+	out, err := Render(manifest, "k8s", profile, "", nil)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"startupProbe:\n            httpGet:\n              path: /health?deep=1\n              port: 80\n            initialDelaySeconds: 30\n            periodSeconds: 10\n            timeoutSeconds: 2\n            failureThreshold: 5\n",
+		"readinessProbe:\n            httpGet:\n              path: /health?deep=1\n              port: 80\n            periodSeconds: 10\n            timeoutSeconds: 2\n            successThreshold: 2\n",
+		"livenessProbe:\n            grpc:\n              port: 50051\n              service: rpc.v1\n            periodSeconds: 5\n            timeoutSeconds: 2\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
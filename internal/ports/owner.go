@@ -0,0 +1,147 @@
+package ports
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Owner is the process listening on a host port.
+type Owner struct {
+	PID     int
+	Command string
+}
+
+// containerProxies are the processes container runtimes publish ports
+// through, so a port they hold belongs to some container.
+var containerProxies = map[string]bool{
+	"docker-proxy":       true,
+	"com.docker.backend": true,
+	"vpnkit":             true,
+	"rootlessport":       true,
+	"gvproxy":            true,
+}
+
+// String describes the owner, e.g. "postgres (pid 812)".
+func (o Owner) String() string {
+	if containerProxies[o.Command] {
+		return fmt.Sprintf("a container, via %s (pid %d)", o.Command, o.PID)
+	}
+	return fmt.Sprintf("%s (pid %d)", o.Command, o.PID)
+}
+
+// FindOwner looks up the process listening on port: through /proc on
+// Linux, otherwise with lsof when it is installed. It reports false when
+// the owner cannot be found, e.g. because it belongs to another user.
+func FindOwner(port int, protocol string) (Owner, bool) {
+	if o, ok := procOwner("/proc", port, protocolName(protocol)); ok {
+		return o, true
+	}
+	return lsofOwner(port, protocolName(protocol))
+}
+
+// procOwner finds the socket listening on port in <root>/net and then the
+// process holding it open.
+func procOwner(root string, port int, protocol string) (Owner, bool) {
+	inodes := map[string]bool{}
+	for _, suffix := range []string{"", "6"} {
+		data, err := os.ReadFile(filepath.Join(root, "net", protocol+suffix))
+		if err != nil {
+			continue
+		}
+		for _, inode := range listeningInodes(string(data), port, protocol) {
+			inodes[inode] = true
+		}
+	}
+	if len(inodes) == 0 {
+		return Owner{}, false
+	}
+
+	fds, _ := filepath.Glob(filepath.Join(root, "[0-9]*", "fd", "*"))
+	for _, fd := range fds {
+		link, err := os.Readlink(fd)
+		if err != nil || !strings.HasPrefix(link, "socket:[") {
+			continue
+		}
+		if !inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")] {
+			continue
+		}
+		pidDir := filepath.Dir(filepath.Dir(fd))
+		pid, _ := strconv.Atoi(filepath.Base(pidDir))
+		comm, _ := os.ReadFile(filepath.Join(pidDir, "comm"))
+		return Owner{PID: pid, Command: strings.TrimSpace(string(comm))}, true
+	}
+	return Owner{}, false
+}
+
+// listeningInodes returns the inodes of the sockets bound to port in a
+// /proc/net/tcp or /proc/net/udp table. TCP sockets count only while
+// listening.
+func listeningInodes(table string, port int, protocol string) []string {
+	var inodes []string
+	scanner := bufio.NewScanner(strings.NewReader(table))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[0] == "sl" {
+			continue
+		}
+		_, hexPort, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		p, err := strconv.ParseInt(hexPort, 16, 32)
+		if err != nil || int(p) != port {
+			continue
+		}
+		// 0A is TCP_LISTEN.
+		if protocol == "tcp" && fields[3] != "0A" {
+			continue
+		}
+		inodes = append(inodes, fields[9])
+	}
+	return inodes
+}
+
+func lsofOwner(port int, protocol string) (Owner, bool) {
+	if _, err := exec.LookPath("lsof"); err != nil {
+		return Owner{}, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	args := []string{"-nP", "-i" + strings.ToUpper(protocol) + ":" + strconv.Itoa(port), "-Fpc"}
+	if protocol == "tcp" {
+		args = append(args, "-sTCP:LISTEN")
+	}
+	out, err := exec.CommandContext(ctx, "lsof", args...).Output()
+	if err != nil {
+		return Owner{}, false
+	}
+	return parseLsof(string(out))
+}
+
+// parseLsof reads the first process from `lsof -F pc` output, which puts
+// each field on its own line prefixed by its letter.
+func parseLsof(out string) (Owner, bool) {
+	var o Owner
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			if o.PID != 0 {
+				return o, true
+			}
+			o.PID, _ = strconv.Atoi(line[1:])
+		case 'c':
+			o.Command = line[1:]
+		}
+	}
+	return o, o.PID != 0
+}
@@ -0,0 +1,60 @@
+// Package ports checks which host ports are free so devx can publish
+// container ports without clashing with what is already listening.
+package ports
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// maxPort is the highest valid port number.
+const maxPort = 65535
+
+// Free reports whether port can be bound on hostIP. An empty hostIP checks
+// every address, as publishing without one does. Protocols other than tcp
+// and udp cannot be checked and are reported free.
+func Free(hostIP string, port int, protocol string) bool {
+	addr := net.JoinHostPort(hostIP, strconv.Itoa(port))
+	switch protocol {
+	case "", "tcp":
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return false
+		}
+		l.Close()
+	case "udp":
+		c, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return false
+		}
+		c.Close()
+	}
+	return true
+}
+
+// FindFree returns the first of size consecutive ports, starting at from or
+// above, that are all free and none of which is in taken.
+func FindFree(hostIP string, from, size int, protocol string, taken map[int]bool) (int, error) {
+	for first := from; first+size-1 <= maxPort; first++ {
+		ok := true
+		for p := first; p < first+size; p++ {
+			if taken[p] || !Free(hostIP, p, protocol) {
+				// No run containing p can work; resume after it.
+				first, ok = p, false
+				break
+			}
+		}
+		if ok {
+			return first, nil
+		}
+	}
+	return 0, fmt.Errorf("no free %s port at or above %d", protocolName(protocol), from)
+}
+
+func protocolName(protocol string) string {
+	if protocol == "" {
+		return "tcp"
+	}
+	return protocol
+}
@@ -0,0 +1,104 @@
+package ports
+
+import (
+	"net"
+	"os"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestFree(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	busy := l.Addr().(*net.TCPAddr).Port
+
+	if Free("127.0.0.1", busy, "tcp") {
+		t.Fatalf("expected port %d to be in use", busy)
+	}
+	if !Free("127.0.0.1", busy, "sctp") {
+		t.Fatal("expected unsupported protocols to be reported free")
+	}
+}
+
+func TestFindFree(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	busy := l.Addr().(*net.TCPAddr).Port
+
+	got, err := FindFree("127.0.0.1", busy, 1, "tcp", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got <= busy {
+		t.Fatalf("expected a port above %d, got %d", busy, got)
+	}
+
+	// A range may not straddle the busy port, and taken ports are skipped.
+	got, err = FindFree("127.0.0.1", busy-1, 2, "tcp", map[int]bool{busy + 1: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got <= busy+1 {
+		t.Fatalf("expected a range above %d, got %d", busy+1, got)
+	}
+
+	if _, err := FindFree("127.0.0.1", maxPort, 2, "tcp", nil); err == nil {
+		t.Fatal("expected an error when no range fits")
+	}
+}
+
+func TestListeningInodes(t *testing.T) {
+	table := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1538 00000000:0000 0A 00000000:00000000 00:00000000 00000000   999        0 23456 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:1538 0100007F:D431 01 00000000:00000000 00:00000000 00000000   999        0 23999 1 0000000000000000 20 4 30 10 -1
+   2: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 31337 1 0000000000000000 100 0 0 10 0
+`
+	if got := listeningInodes(table, 5432, "tcp"); !reflect.DeepEqual(got, []string{"23456"}) {
+		t.Fatalf("expected only the listening socket on 5432, got %v", got)
+	}
+	if got := listeningInodes(table, 5432, "udp"); len(got) != 2 {
+		t.Fatalf("expected every udp socket on 5432, got %v", got)
+	}
+}
+
+func TestParseLsof(t *testing.T) {
+	o, ok := parseLsof("p812\ncpostgres\nf7\np913\ncother\n")
+	if !ok || o != (Owner{PID: 812, Command: "postgres"}) {
+		t.Fatalf("unexpected owner %+v", o)
+	}
+	if _, ok := parseLsof(""); ok {
+		t.Fatal("expected no owner in empty output")
+	}
+}
+
+func TestFindOwner(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("owner lookup through /proc is Linux-only")
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	o, ok := FindOwner(l.Addr().(*net.TCPAddr).Port, "tcp")
+	if !ok || o.PID != os.Getpid() {
+		t.Fatalf("expected this process to own the port, got %+v, %v", o, ok)
+	}
+}
+
+func TestOwnerString(t *testing.T) {
+	if got := (Owner{PID: 812, Command: "postgres"}).String(); got != "postgres (pid 812)" {
+		t.Fatalf("got %q", got)
+	}
+	if got := (Owner{PID: 90, Command: "docker-proxy"}).String(); got != "a container, via docker-proxy (pid 90)" {
+		t.Fatalf("got %q", got)
+	}
+}
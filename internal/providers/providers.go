@@ -11,7 +11,10 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+
+	"github.com/dever-labs/devx/internal/config"
 )
 
 // ProviderMeta is the metadata returned by a provider binary's describe
@@ -157,17 +160,31 @@ func splitSource(source string) (org, name string, err error) {
 // block. The map contains:
 //   - "host" → depName (the service hostname within the compose network)
 //   - "port" → the first container-side port from depPorts (e.g. "5432" from "5432:5432")
+//   - "port.<name>" → the container-side port of each named port
+//   - "hostPort" and "hostPort.<name>" → the same ports as published on the
+//     host, for connection strings used from outside the compose network
 //   - one entry per key in depEnv (so templates can use ${POSTGRES_PASSWORD} etc.)
-func ResolveOutputValues(depName string, depPorts []string, depEnv map[string]string) map[string]string {
+func ResolveOutputValues(depName string, depPorts []config.Port, depEnv map[string]string) map[string]string {
 	vals := map[string]string{"host": depName}
 
-	if len(depPorts) > 0 {
-		// Take the container-side port (right of the colon, or the whole value if no colon).
-		p := depPorts[0]
-		if idx := strings.LastIndex(p, ":"); idx >= 0 {
-			p = p[idx+1:]
+	for i, p := range depPorts {
+		port := strconv.Itoa(p.Container.First)
+		if i == 0 {
+			vals["port"] = port
+		}
+		if p.Name != "" {
+			vals["port."+p.Name] = port
+		}
+		if p.Host.IsZero() {
+			continue
+		}
+		hostPort := strconv.Itoa(p.Host.First)
+		if i == 0 {
+			vals["hostPort"] = hostPort
+		}
+		if p.Name != "" {
+			vals["hostPort."+p.Name] = hostPort
 		}
-		vals["port"] = p
 	}
 
 	for k, v := range depEnv {
@@ -0,0 +1,30 @@
+package providers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dever-labs/devx/internal/config"
+)
+
+func TestResolveOutputValues(t *testing.T) {
+	ports := []config.Port{
+		{Host: config.PortRange{First: 15672}, Container: config.PortRange{First: 15672}, Name: "admin"},
+		{Host: config.PortRange{First: 5672}, Container: config.PortRange{First: 5672}, Name: "amqp"},
+		{Container: config.PortRange{First: 25672}},
+	}
+	got := ResolveOutputValues("rabbit", ports, map[string]string{"RABBITMQ_DEFAULT_USER": "guest"})
+	want := map[string]string{
+		"host":                  "rabbit",
+		"port":                  "15672",
+		"port.admin":            "15672",
+		"port.amqp":             "5672",
+		"hostPort":              "15672",
+		"hostPort.admin":        "15672",
+		"hostPort.amqp":         "5672",
+		"RABBITMQ_DEFAULT_USER": "guest",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/dever-labs/devx/internal/runtime"
@@ -16,6 +17,10 @@ import (
 
 type Runtime struct {
 	Binary string
+	// Context and Host, when set, are passed to every command as
+	// --context and --host.
+	Context string
+	Host    string
 }
 
 func New() *Runtime {
@@ -27,7 +32,7 @@ func (r *Runtime) Name() string {
 }
 
 func (r *Runtime) Detect(ctx context.Context) (bool, error) {
-	cmd := exec.CommandContext(ctx, r.Binary, "version", "--format", "{{.Server.Version}}")
+	cmd := r.command(ctx, "version", "--format", "{{.Server.Version}}")
 	out, err := cmd.Output()
 	if err != nil {
 		return false, nil
@@ -43,15 +48,36 @@ func (r *Runtime) Up(ctx context.Context, composePath string, projectName string
 	if opts.Pull {
 		args = append(args, "--pull", "always")
 	}
-	return run(ctx, r.Binary, args...)
+	if opts.NoDeps {
+		args = append(args, "--no-deps")
+	}
+	if opts.ForceRecreate {
+		args = append(args, "--force-recreate")
+	}
+	args = append(args, opts.Services...)
+	return r.run(ctx, args...)
 }
 
-func (r *Runtime) Down(ctx context.Context, composePath string, projectName string, removeVolumes bool) error {
+func (r *Runtime) Down(ctx context.Context, composePath string, projectName string, opts runtime.DownOptions) error {
 	args := []string{"compose", "-f", composePath, "-p", projectName, "down"}
-	if removeVolumes {
+	if len(opts.Services) > 0 {
+		args = []string{"compose", "-f", composePath, "-p", projectName, "rm", "--stop", "--force"}
+	}
+	if opts.Volumes {
 		args = append(args, "--volumes")
 	}
-	return run(ctx, r.Binary, args...)
+	args = append(args, opts.Services...)
+	return r.run(ctx, args...)
+}
+
+func (r *Runtime) Stop(ctx context.Context, composePath string, projectName string, services []string) error {
+	args := append([]string{"compose", "-f", composePath, "-p", projectName, "stop"}, services...)
+	return r.run(ctx, args...)
+}
+
+func (r *Runtime) Restart(ctx context.Context, composePath string, projectName string, services []string) error {
+	args := append([]string{"compose", "-f", composePath, "-p", projectName, "restart"}, services...)
+	return r.run(ctx, args...)
 }
 
 func (r *Runtime) Logs(ctx context.Context, composePath string, projectName string, opts runtime.LogsOptions) (io.ReadCloser, error) {
@@ -62,34 +88,28 @@ func (r *Runtime) Logs(ctx context.Context, composePath string, projectName stri
 	if opts.Since != "" {
 		args = append(args, "--since", opts.Since)
 	}
-	if opts.Service != "" {
-		args = append(args, opts.Service)
+	if opts.Tail > 0 {
+		args = append(args, "--tail", strconv.Itoa(opts.Tail))
 	}
+	args = append(args, opts.Services...)
 
-	cmd := exec.CommandContext(ctx, r.Binary, args...)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
-	}
-	cmd.Stderr = cmd.Stdout
-	if err := cmd.Start(); err != nil {
-		return nil, err
-	}
+	return runtime.CommandLogs(r.command(ctx, args...), opts.Stderr)
+}
 
-	return newCommandReader(cmd, stdout), nil
+func (r *Runtime) Exec(ctx context.Context, composePath string, projectName string, service string, opts runtime.ExecOptions) (int, error) {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "exec"}
+	args = append(args, runtime.ComposeExecArgs(opts)...)
+	args = append(args, service)
+	args = append(args, opts.Cmd...)
+	return runtime.RunCommand(r.command(ctx, args...), opts)
 }
 
-func (r *Runtime) Exec(ctx context.Context, composePath string, projectName string, service string, cmdArgs []string) (int, error) {
-	args := []string{"compose", "-f", composePath, "-p", projectName, "exec", "-T", service}
-	args = append(args, cmdArgs...)
-	cmd := exec.CommandContext(ctx, r.Binary, args...)
-	if err := cmd.Run(); err != nil {
-		if exit, ok := err.(*exec.ExitError); ok {
-			return exit.ExitCode(), nil
-		}
-		return 1, err
-	}
-	return 0, nil
+func (r *Runtime) Run(ctx context.Context, composePath string, projectName string, service string, opts runtime.ExecOptions) (int, error) {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "run", "--rm"}
+	args = append(args, runtime.ComposeExecArgs(opts)...)
+	args = append(args, service)
+	args = append(args, opts.Cmd...)
+	return runtime.RunCommand(r.command(ctx, args...), opts)
 }
 
 func parseStatusEntries(out []byte) ([]map[string]any, error) {
@@ -115,7 +135,7 @@ func parseStatusEntries(out []byte) ([]map[string]any, error) {
 
 func (r *Runtime) Status(ctx context.Context, composePath string, projectName string) ([]runtime.ServiceStatus, error) {
 	args := []string{"compose", "-f", composePath, "-p", projectName, "ps", "--format", "json"}
-	cmd := exec.CommandContext(ctx, r.Binary, args...)
+	cmd := r.command(ctx, args...)
 	out, err := cmd.Output()
 	if err != nil {
 		return nil, err
@@ -152,21 +172,72 @@ func (r *Runtime) Status(ctx context.Context, composePath string, projectName st
 	return results, nil
 }
 
+// Copy copies src on the host to dst inside service's container.
+func (r *Runtime) Copy(ctx context.Context, composePath string, projectName string, service string, src string, dst string) error {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "cp", src, service + ":" + dst}
+	out, err := r.command(ctx, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Inspect reports the state of every container of service, including
+// stopped ones.
+func (r *Runtime) Inspect(ctx context.Context, composePath string, projectName string, service string) ([]runtime.ContainerState, error) {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "ps", "--all", "--quiet", service}
+	out, err := r.command(ctx, args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	ids := strings.Fields(string(out))
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	out, err = r.command(ctx, append([]string{"inspect"}, ids...)...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return runtime.ParseInspect(out)
+}
+
+// List reports every container with a devx.project label, in any project.
+func (r *Runtime) List(ctx context.Context) ([]runtime.Container, error) {
+	args := []string{"ps", "--all", "--filter", "label=devx.project", "--format", "{{json .}}"}
+	out, err := r.command(ctx, args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return runtime.ParseContainerList(out)
+}
+
+// Events streams `docker events` for the project's devx containers.
+func (r *Runtime) Events(ctx context.Context, composePath string, projectName string, opts runtime.EventsOptions) (<-chan runtime.Event, <-chan error) {
+	args := []string{"events", "--format", "{{json .}}", "--filter", "type=container"}
+	for _, label := range runtime.EventFilters(projectName) {
+		args = append(args, "--filter", "label="+label)
+	}
+	if opts.Since != "" {
+		args = append(args, "--since", opts.Since)
+	}
+	return runtime.CommandEvents(ctx, r.command(ctx, args...), runtime.ParseDockerEvent)
+}
+
 func (r *Runtime) ResolveImageDigest(ctx context.Context, image string) (string, error) {
-	digest, err := resolveRepoDigest(ctx, r.Binary, image)
+	digest, err := r.resolveRepoDigest(ctx, image)
 	if err == nil {
 		return digest, nil
 	}
 
-	if err := run(ctx, r.Binary, "pull", image); err != nil {
+	if err := r.run(ctx, "pull", image); err != nil {
 		return "", err
 	}
 
-	return resolveRepoDigest(ctx, r.Binary, image)
+	return r.resolveRepoDigest(ctx, image)
 }
 
-func resolveRepoDigest(ctx context.Context, binary string, image string) (string, error) {
-	cmd := exec.CommandContext(ctx, binary, "image", "inspect", "--format", "{{join .RepoDigests \"\\n\"}}", image)
+func (r *Runtime) resolveRepoDigest(ctx context.Context, image string) (string, error) {
+	cmd := r.command(ctx, "image", "inspect", "--format", "{{join .RepoDigests \"\\n\"}}", image)
 	out, err := cmd.Output()
 	if err != nil {
 		return "", err
@@ -185,27 +256,21 @@ func resolveRepoDigest(ctx context.Context, binary string, image string) (string
 	return "", fmt.Errorf("no digest found for %s", image)
 }
 
-func run(ctx context.Context, binary string, args ...string) error {
-	cmd := exec.CommandContext(ctx, binary, args...)
+// command returns a docker command using the runtime's context and host.
+func (r *Runtime) command(ctx context.Context, args ...string) *exec.Cmd {
+	var global []string
+	if r.Context != "" {
+		global = append(global, "--context", r.Context)
+	}
+	if r.Host != "" {
+		global = append(global, "--host", r.Host)
+	}
+	return exec.CommandContext(ctx, r.Binary, append(global, args...)...)
+}
+
+func (r *Runtime) run(ctx context.Context, args ...string) error {
+	cmd := r.command(ctx, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
-
-type commandReader struct {
-	cmd *exec.Cmd
-	rc  io.ReadCloser
-}
-
-func newCommandReader(cmd *exec.Cmd, rc io.ReadCloser) io.ReadCloser {
-	return &commandReader{cmd: cmd, rc: rc}
-}
-
-func (c *commandReader) Read(p []byte) (int, error) {
-	return c.rc.Read(p)
-}
-
-func (c *commandReader) Close() error {
-	_ = c.cmd.Process.Kill()
-	return c.rc.Close()
-}
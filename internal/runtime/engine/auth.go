@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// dockerHubAuthKey is the key Docker Hub credentials are stored under in
+// config.json.
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// AuthConfig is a registry credential as the daemon expects it in the
+// X-Registry-Auth header.
+type AuthConfig struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+	ServerAddress string `json:"serveraddress,omitempty"`
+}
+
+// dockerConfig is the part of ~/.docker/config.json that holds registry
+// credentials.
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"`
+		IdentityToken string `json:"identitytoken"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// registryAuth returns the credential for the registry image is pulled
+// from, read from the docker CLI's config.json in DOCKER_CONFIG or
+// ~/.docker, as `docker login` stored it: inline under auths, or in a
+// credential helper. ok is false when there is none, and the pull goes
+// ahead anonymously.
+func registryAuth(ctx context.Context, image string) (auth AuthConfig, ok bool, err error) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return AuthConfig{}, false, nil
+	}
+	if err != nil {
+		return AuthConfig{}, false, err
+	}
+	var cfg dockerConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return AuthConfig{}, false, fmt.Errorf("%s: %w", filepath.Join(dir, "config.json"), err)
+	}
+
+	host := registryHost(image)
+	key := host
+	if host == "docker.io" {
+		key = dockerHubAuthKey
+	}
+	helper := cfg.CredHelpers[host]
+	if helper == "" {
+		helper = cfg.CredsStore
+	}
+	if helper != "" {
+		return helperAuth(ctx, helper, key)
+	}
+
+	for server, entry := range cfg.Auths {
+		if server != key && authHost(server) != host {
+			continue
+		}
+		auth := AuthConfig{IdentityToken: entry.IdentityToken, ServerAddress: server}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return AuthConfig{}, false, fmt.Errorf("credentials for %s: %w", server, err)
+			}
+			auth.Username, auth.Password, _ = strings.Cut(string(decoded), ":")
+		}
+		return auth, true, nil
+	}
+	return AuthConfig{}, false, nil
+}
+
+// helperAuth asks the docker-credential-<helper> program for server's
+// credential.
+func helperAuth(ctx context.Context, helper, server string) (AuthConfig, bool, error) {
+	cmd := exec.CommandContext(ctx, "docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		// Helpers report a missing credential by failing with this message.
+		if strings.Contains(stdout.String(), "credentials not found") {
+			return AuthConfig{}, false, nil
+		}
+		return AuthConfig{}, false, fmt.Errorf("docker-credential-%s: %w", helper, err)
+	}
+	var out struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return AuthConfig{}, false, fmt.Errorf("docker-credential-%s: %w", helper, err)
+	}
+	auth := AuthConfig{ServerAddress: server}
+	if out.Username == "<token>" {
+		auth.IdentityToken = out.Secret
+	} else {
+		auth.Username, auth.Password = out.Username, out.Secret
+	}
+	return auth, true, nil
+}
+
+// encode returns auth as the X-Registry-Auth header value.
+func (a AuthConfig) encode() string {
+	data, _ := json.Marshal(a)
+	return base64.URLEncoding.EncodeToString(data)
+}
+
+// registryHost returns the registry an image reference points at:
+// docker.io unless its first component looks like a host name.
+func registryHost(image string) string {
+	first, _, ok := strings.Cut(image, "/")
+	if !ok || (!strings.ContainsAny(first, ".:") && first != "localhost") {
+		return "docker.io"
+	}
+	if first == "index.docker.io" || first == "registry-1.docker.io" {
+		return "docker.io"
+	}
+	return first
+}
+
+// authHost strips the scheme and path config.json keys may carry, e.g.
+// "https://registry.example.com/v1/".
+func authHost(server string) string {
+	server = strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	host, _, _ := strings.Cut(server, "/")
+	return host
+}
@@ -0,0 +1,689 @@
+// Package engine runs devx environments by talking to the Docker Engine API
+// directly, over the daemon's Unix socket or DOCKER_HOST, instead of
+// through the docker CLI and its compose plugin.
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultHost is where the Docker daemon listens when DOCKER_HOST is unset.
+const DefaultHost = "unix:///var/run/docker.sock"
+
+// apiVersion is the Engine API version devx speaks. Docker 20.10 and
+// Podman's compatibility API both support it.
+const apiVersion = "v1.41"
+
+// Client is a minimal Docker Engine API client.
+type Client struct {
+	http *http.Client
+	base string
+	// dial opens a connection to the daemon, for requests that take over
+	// the connection once answered.
+	dial func(ctx context.Context) (net.Conn, error)
+}
+
+// APIError is an error response from the daemon.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("docker engine: %s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// IsNotFound reports whether err is a 404 from the daemon.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// HostFromEnv returns DOCKER_HOST, or DefaultHost when it is unset.
+func HostFromEnv() string {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return host
+	}
+	return DefaultHost
+}
+
+// NewClient returns a client for host, a unix:// socket path or a tcp://
+// address. TCP connections use TLS when DOCKER_TLS_VERIFY is set, with the
+// certificates in DOCKER_CERT_PATH.
+func NewClient(host string) (*Client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+	transport := &http.Transport{}
+	c := &Client{http: &http.Client{Transport: transport}}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		c.dial = func(ctx context.Context) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return c.dial(ctx)
+		}
+		c.base = "http://docker"
+	case "tcp", "http", "https":
+		scheme := "http"
+		if u.Scheme == "https" || os.Getenv("DOCKER_TLS_VERIFY") != "" {
+			cfg, err := tlsConfig(os.Getenv("DOCKER_CERT_PATH"))
+			if err != nil {
+				return nil, err
+			}
+			transport.TLSClientConfig = cfg
+			scheme = "https"
+		}
+		c.base = scheme + "://" + u.Host
+		addr, tlsCfg := u.Host, transport.TLSClientConfig
+		c.dial = func(ctx context.Context) (net.Conn, error) {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", addr)
+			if err != nil || tlsCfg == nil {
+				return conn, err
+			}
+			cfg := tlsCfg.Clone()
+			cfg.ServerName, _, _ = net.SplitHostPort(addr)
+			return tls.Client(conn, cfg), nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported docker host %q: use unix:// or tcp://", host)
+	}
+	return c, nil
+}
+
+func tlsConfig(certPath string) (*tls.Config, error) {
+	if certPath == "" {
+		home, _ := os.UserHomeDir()
+		certPath = filepath.Join(home, ".docker")
+	}
+	cert, err := tls.LoadX509KeyPair(filepath.Join(certPath, "cert.pem"), filepath.Join(certPath, "key.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("docker TLS: %w", err)
+	}
+	ca, err := os.ReadFile(filepath.Join(certPath, "ca.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("docker TLS: %w", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca)
+	return &tls.Config{Certificates: []tls.Certificate{cert}, RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+// request sends a request and returns the response when its status is 2xx
+// or 304; the caller closes the body. Other statuses become an *APIError.
+func (c *Client) request(ctx context.Context, method, path string, query url.Values, body any) (*http.Response, error) {
+	return c.requestHeader(ctx, method, path, query, body, nil)
+}
+
+// requestHeader is request with extra headers.
+func (c *Client) requestHeader(ctx context.Context, method, path string, query url.Values, body any, header http.Header) (*http.Response, error) {
+	var reader io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		reader, contentType = b, "application/x-tar"
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+		reader, contentType = bytes.NewReader(data), "application/json"
+	}
+
+	u := c.base + "/" + apiVersion + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 || resp.StatusCode == http.StatusNotModified {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	var msg struct{ Message string }
+	if json.Unmarshal(data, &msg) != nil || msg.Message == "" {
+		msg.Message = strings.TrimSpace(string(data))
+	}
+	return nil, &APIError{StatusCode: resp.StatusCode, Message: msg.Message}
+}
+
+// hijack sends a request asking the daemon to take over the connection,
+// as it does to attach to a process's streams, and returns the connection
+// once it has answered.
+func (c *Client) hijack(ctx context.Context, path string, query url.Values, body any) (*hijackedConn, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	u := c.base + "/" + apiVersion + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols && resp.StatusCode != http.StatusOK {
+		defer conn.Close()
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+	return &hijackedConn{Conn: conn, r: br}, nil
+}
+
+// hijackedConn reads what the daemon sent after its response headers
+// before reading from the connection.
+type hijackedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (h *hijackedConn) Read(p []byte) (int, error) {
+	return h.r.Read(p)
+}
+
+// CloseWrite tells the daemon that there is no more input.
+func (h *hijackedConn) CloseWrite() error {
+	if cw, ok := h.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return nil
+}
+
+// do sends a request and decodes a JSON response into out, if not nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	resp, err := c.request(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Ping checks that the daemon answers.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/_ping", nil, nil, nil)
+}
+
+// Version returns the daemon's version.
+func (c *Client) Version(ctx context.Context) (string, error) {
+	var v struct{ Version string }
+	err := c.do(ctx, http.MethodGet, "/version", nil, nil, &v)
+	return v.Version, err
+}
+
+// labelFilter encodes a filters query parameter matching every label.
+func labelFilter(labels ...string) url.Values {
+	data, _ := json.Marshal(map[string][]string{"label": labels})
+	return url.Values{"filters": {string(data)}}
+}
+
+// Port is a port of a listed container.
+type Port struct {
+	IP          string
+	PrivatePort int
+	PublicPort  int
+	Type        string
+}
+
+// ContainerSummary is a container as returned by ContainerList.
+type ContainerSummary struct {
+	ID      string `json:"Id"`
+	Names   []string
+	Image   string
+	ImageID string
+	State   string
+	Status  string
+	Ports   []Port
+	Labels  map[string]string
+}
+
+// ContainerList lists containers, stopped ones included, that carry every
+// label ("key" or "key=value").
+func (c *Client) ContainerList(ctx context.Context, labels ...string) ([]ContainerSummary, error) {
+	query := labelFilter(labels...)
+	query.Set("all", "1")
+	var out []ContainerSummary
+	err := c.do(ctx, http.MethodGet, "/containers/json", query, nil, &out)
+	return out, err
+}
+
+// ContainerCreate creates a container from config and returns its ID.
+func (c *Client) ContainerCreate(ctx context.Context, name string, config *ContainerConfig) (string, error) {
+	var out struct {
+		ID string `json:"Id"`
+	}
+	err := c.do(ctx, http.MethodPost, "/containers/create", url.Values{"name": {name}}, config, &out)
+	return out.ID, err
+}
+
+// ContainerStart starts a container; starting a running one is not an error.
+func (c *Client) ContainerStart(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/containers/"+id+"/start", nil, nil, nil)
+}
+
+// ContainerStop stops a container, killing it after timeout.
+func (c *Client) ContainerStop(ctx context.Context, id string, timeout time.Duration) error {
+	query := url.Values{"t": {fmt.Sprint(int(timeout.Seconds()))}}
+	return c.do(ctx, http.MethodPost, "/containers/"+id+"/stop", query, nil, nil)
+}
+
+// ContainerRestart restarts a container, killing it after timeout.
+func (c *Client) ContainerRestart(ctx context.Context, id string, timeout time.Duration) error {
+	query := url.Values{"t": {fmt.Sprint(int(timeout.Seconds()))}}
+	return c.do(ctx, http.MethodPost, "/containers/"+id+"/restart", query, nil, nil)
+}
+
+// ContainerRemove removes a container, stopping it first, and its
+// anonymous volumes when volumes is set.
+func (c *Client) ContainerRemove(ctx context.Context, id string, volumes bool) error {
+	query := url.Values{"force": {"1"}}
+	if volumes {
+		query.Set("v", "1")
+	}
+	return c.do(ctx, http.MethodDelete, "/containers/"+id, query, nil, nil)
+}
+
+// ContainerAttach attaches to a container's output, and to its input when
+// stdin is set. Attaching before the container starts catches all of it.
+func (c *Client) ContainerAttach(ctx context.Context, id string, stdin bool) (*hijackedConn, error) {
+	query := url.Values{"stream": {"1"}, "stdout": {"1"}, "stderr": {"1"}}
+	if stdin {
+		query.Set("stdin", "1")
+	}
+	return c.hijack(ctx, "/containers/"+id+"/attach", query, nil)
+}
+
+// ContainerWait waits for a container to stop and returns its exit code.
+func (c *Client) ContainerWait(ctx context.Context, id string) (int, error) {
+	var out struct{ StatusCode int }
+	err := c.do(ctx, http.MethodPost, "/containers/"+id+"/wait", nil, nil, &out)
+	return out.StatusCode, err
+}
+
+// ContainerResize sets the size of a container's terminal.
+func (c *Client) ContainerResize(ctx context.Context, id string, height, width int) error {
+	query := url.Values{"h": {fmt.Sprint(height)}, "w": {fmt.Sprint(width)}}
+	return c.do(ctx, http.MethodPost, "/containers/"+id+"/resize", query, nil, nil)
+}
+
+// ContainerInspect returns the daemon's JSON description of a container.
+func (c *Client) ContainerInspect(ctx context.Context, id string) (json.RawMessage, error) {
+	var out json.RawMessage
+	err := c.do(ctx, http.MethodGet, "/containers/"+id+"/json", nil, nil, &out)
+	return out, err
+}
+
+// LogsOptions selects what ContainerLogs returns.
+type LogsOptions struct {
+	Follow bool
+	Since  string
+	Tail   int
+}
+
+// ContainerLogs returns a container's stdout and stderr, each line
+// prefixed by its timestamp. Containers without a TTY send a multiplexed
+// stream; read it with Demux.
+func (c *Client) ContainerLogs(ctx context.Context, id string, opts LogsOptions) (io.ReadCloser, error) {
+	query := url.Values{"stdout": {"1"}, "stderr": {"1"}, "timestamps": {"1"}}
+	if opts.Follow {
+		query.Set("follow", "1")
+	}
+	if opts.Since != "" {
+		since, err := sinceParam(opts.Since, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		query.Set("since", since)
+	}
+	if opts.Tail > 0 {
+		query.Set("tail", fmt.Sprint(opts.Tail))
+	}
+	resp, err := c.request(ctx, http.MethodGet, "/containers/"+id+"/logs", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// sinceParam converts a relative duration such as "10m" or an RFC 3339
+// time into the Unix timestamp the API expects.
+func sinceParam(since string, now time.Time) (string, error) {
+	if d, err := time.ParseDuration(since); err == nil {
+		return fmt.Sprint(now.Add(-d).Unix()), nil
+	}
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return fmt.Sprint(t.Unix()), nil
+	}
+	return "", fmt.Errorf("invalid --since %q: use a duration such as 10m or an RFC 3339 time", since)
+}
+
+// Stream identifies the output a multiplexed frame carries.
+const (
+	Stdout = 1
+	Stderr = 2
+)
+
+// Demux splits a multiplexed stream into stdout and stderr. Each frame
+// has an 8-byte header: the stream, three zero bytes and the frame's
+// length as a big-endian uint32.
+func Demux(r io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		w := stdout
+		if header[0] == Stderr {
+			w = stderr
+		}
+		if _, err := io.CopyN(w, r, int64(binary.BigEndian.Uint32(header[4:]))); err != nil {
+			return err
+		}
+	}
+}
+
+// ExecConfig describes a command to run in a running container.
+type ExecConfig struct {
+	Cmd          []string
+	User         string   `json:",omitempty"`
+	WorkingDir   string   `json:",omitempty"`
+	Env          []string `json:",omitempty"`
+	Tty          bool
+	AttachStdin  bool
+	AttachStdout bool
+	AttachStderr bool
+}
+
+// Exec runs config's command in a container, feeding it stdin when that is
+// not nil and copying its output to stdout and stderr, and returns its
+// exit code. With config.Tty, output is a single stream written to stdout,
+// and resize, when not nil, is called once the terminal exists so the
+// caller can size it.
+func (c *Client) Exec(ctx context.Context, id string, config ExecConfig, stdin io.Reader, stdout, stderr io.Writer, resize func(execID string)) (int, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+	config.AttachStdin = stdin != nil
+	config.AttachStdout, config.AttachStderr = true, true
+	if err := c.do(ctx, http.MethodPost, "/containers/"+id+"/exec", nil, config, &created); err != nil {
+		return 1, err
+	}
+	start := map[string]bool{"Detach": false, "Tty": config.Tty}
+	var err error
+	if stdin == nil {
+		var resp *http.Response
+		resp, err = c.request(ctx, http.MethodPost, "/exec/"+created.ID+"/start", nil, start)
+		if err != nil {
+			return 1, err
+		}
+		err = copyOutput(resp.Body, config.Tty, stdout, stderr)
+		resp.Body.Close()
+	} else {
+		var conn *hijackedConn
+		conn, err = c.hijack(ctx, "/exec/"+created.ID+"/start", nil, start)
+		if err != nil {
+			return 1, err
+		}
+		if config.Tty && resize != nil {
+			resize(created.ID)
+		}
+		go func() {
+			_, _ = io.Copy(conn, stdin)
+			_ = conn.CloseWrite()
+		}()
+		err = copyOutput(conn, config.Tty, stdout, stderr)
+		conn.Close()
+	}
+	if err != nil {
+		return 1, err
+	}
+	var inspect struct{ ExitCode int }
+	if err := c.do(ctx, http.MethodGet, "/exec/"+created.ID+"/json", nil, nil, &inspect); err != nil {
+		return 1, err
+	}
+	return inspect.ExitCode, nil
+}
+
+// ExecResize sets the size of an exec's terminal.
+func (c *Client) ExecResize(ctx context.Context, execID string, height, width int) error {
+	query := url.Values{"h": {fmt.Sprint(height)}, "w": {fmt.Sprint(width)}}
+	return c.do(ctx, http.MethodPost, "/exec/"+execID+"/resize", query, nil, nil)
+}
+
+// copyOutput copies a process's output: one raw stream with a terminal,
+// multiplexed stdout and stderr without.
+func copyOutput(r io.Reader, tty bool, stdout, stderr io.Writer) error {
+	if !tty {
+		return Demux(r, stdout, stderr)
+	}
+	_, err := io.Copy(stdout, r)
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// CopyTo extracts a tar archive into dir inside a container.
+func (c *Client) CopyTo(ctx context.Context, id, dir string, archive io.Reader) error {
+	return c.do(ctx, http.MethodPut, "/containers/"+id+"/archive", url.Values{"path": {dir}}, archive, nil)
+}
+
+// NetworkCreate creates a bridge network unless one with the name exists.
+func (c *Client) NetworkCreate(ctx context.Context, name string, labels map[string]string) error {
+	var existing []struct{ Name string }
+	data, _ := json.Marshal(map[string][]string{"name": {name}})
+	if err := c.do(ctx, http.MethodGet, "/networks", url.Values{"filters": {string(data)}}, nil, &existing); err != nil {
+		return err
+	}
+	for _, n := range existing {
+		if n.Name == name {
+			return nil
+		}
+	}
+	body := map[string]any{"Name": name, "Driver": "bridge", "Labels": labels, "CheckDuplicate": true}
+	return c.do(ctx, http.MethodPost, "/networks/create", nil, body, nil)
+}
+
+// NetworkRemove removes a network; a missing one is not an error.
+func (c *Client) NetworkRemove(ctx context.Context, name string) error {
+	if err := c.do(ctx, http.MethodDelete, "/networks/"+name, nil, nil, nil); err != nil && !IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// VolumeCreate creates a named volume; creating an existing one is not an
+// error.
+func (c *Client) VolumeCreate(ctx context.Context, name string, labels map[string]string) error {
+	return c.do(ctx, http.MethodPost, "/volumes/create", nil, map[string]any{"Name": name, "Labels": labels}, nil)
+}
+
+// VolumeRemove removes a named volume; a missing one is not an error.
+func (c *Client) VolumeRemove(ctx context.Context, name string) error {
+	if err := c.do(ctx, http.MethodDelete, "/volumes/"+name, nil, nil, nil); err != nil && !IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Image is a local image as returned by ImageInspect.
+type Image struct {
+	ID          string `json:"Id"`
+	RepoDigests []string
+}
+
+// ImageInspect describes a local image, or returns an error for which
+// IsNotFound is true when the image is not present.
+func (c *Client) ImageInspect(ctx context.Context, image string) (Image, error) {
+	var out Image
+	err := c.do(ctx, http.MethodGet, "/images/"+image+"/json", nil, nil, &out)
+	return out, err
+}
+
+// ImagePull pulls an image, waiting until the daemon has finished, with the
+// credential `docker login` stored for its registry, if any. Errors during
+// the pull arrive in the progress stream rather than as a status.
+func (c *Client) ImagePull(ctx context.Context, image string) error {
+	name, tag := splitImage(image)
+	header := http.Header{}
+	auth, ok, err := registryAuth(ctx, image)
+	if err != nil {
+		return fmt.Errorf("pull %s: %w", image, err)
+	}
+	if ok {
+		header.Set("X-Registry-Auth", auth.encode())
+	}
+	resp, err := c.requestHeader(ctx, http.MethodPost, "/images/create", url.Values{"fromImage": {name}, "tag": {tag}}, nil, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var msg struct{ Error string }
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("pull %s: %s", image, msg.Error)
+		}
+	}
+}
+
+// splitImage splits an image reference into its name and tag or digest,
+// defaulting to latest.
+func splitImage(image string) (name, tag string) {
+	if name, digest, ok := strings.Cut(image, "@"); ok {
+		return name, digest
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+// Message is one event from the daemon's event stream.
+type Message struct {
+	Type   string
+	Action string
+	Actor  struct {
+		ID         string
+		Attributes map[string]string
+	}
+	TimeNano int64 `json:"timeNano"`
+}
+
+// Events streams daemon events for objects carrying every label until ctx
+// is cancelled or the connection fails, starting from since when it is set.
+// The error channel receives one value when the stream ends.
+func (c *Client) Events(ctx context.Context, since string, labels ...string) (<-chan Message, <-chan error) {
+	messages := make(chan Message)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(messages)
+		query := labelFilter(labels...)
+		if since != "" {
+			s, err := sinceParam(since, time.Now())
+			if err != nil {
+				errs <- err
+				return
+			}
+			query.Set("since", s)
+		}
+		resp, err := c.request(ctx, http.MethodGet, "/events", query, nil)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var m Message
+			if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case messages <- m:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			errs <- err
+			return
+		}
+		errs <- ctx.Err()
+	}()
+	return messages, errs
+}
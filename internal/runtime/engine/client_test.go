@@ -0,0 +1,363 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDaemon is an HTTP server on a Unix socket standing in for dockerd.
+// It records each request as "METHOD /path" (without the API version) and
+// answers with the handler registered for it, or 404.
+type fakeDaemon struct {
+	t        *testing.T
+	host     string
+	mu       sync.Mutex
+	requests []string
+	bodies   map[string][]byte
+	handlers map[string]http.HandlerFunc
+}
+
+func newFakeDaemon(t *testing.T) *fakeDaemon {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	d := &fakeDaemon{t: t, host: "unix://" + socket, bodies: map[string][]byte{}, handlers: map[string]http.HandlerFunc{}}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(d.serve))
+	srv.Listener = ln
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return d
+}
+
+func (d *fakeDaemon) handle(route string, h http.HandlerFunc) {
+	d.handlers[route] = h
+}
+
+// json registers a handler answering route with v encoded as JSON.
+func (d *fakeDaemon) json(route string, v any) {
+	d.handle(route, func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(v)
+	})
+}
+
+func (d *fakeDaemon) serve(w http.ResponseWriter, req *http.Request) {
+	path := strings.TrimPrefix(req.URL.Path, "/"+apiVersion)
+	route := req.Method + " " + path
+	body, _ := io.ReadAll(req.Body)
+	d.mu.Lock()
+	d.requests = append(d.requests, route)
+	d.bodies[route] = body
+	d.mu.Unlock()
+	if h, ok := d.handlers[route]; ok {
+		h(w, req)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = w.Write([]byte(`{"message":"no such route: ` + route + `"}`))
+}
+
+func (d *fakeDaemon) client() *Client {
+	d.t.Helper()
+	c, err := NewClient(d.host)
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	return c
+}
+
+// frame encodes one frame of a multiplexed stream.
+func frame(stream byte, s string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(s)))
+	return append(header, s...)
+}
+
+func TestClient_PingAndErrors(t *testing.T) {
+	d := newFakeDaemon(t)
+	d.handle("GET /_ping", func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("OK")) })
+	c := d.client()
+	ctx := context.Background()
+
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	_, err := c.ImageInspect(ctx, "postgres:16")
+	if !IsNotFound(err) {
+		t.Fatalf("expected a not-found error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "no such route") {
+		t.Errorf("expected the daemon's message in %q", err)
+	}
+}
+
+func TestClient_ContainerListFilters(t *testing.T) {
+	d := newFakeDaemon(t)
+	var query string
+	d.handle("GET /containers/json", func(w http.ResponseWriter, req *http.Request) {
+		query = req.URL.RawQuery
+		_, _ = w.Write([]byte(`[{"Id":"abc","Names":["/demo-db-1"],"State":"running","Labels":{"com.docker.compose.service":"db"}}]`))
+	})
+
+	list, err := d.client().ContainerList(context.Background(), "com.docker.compose.project=demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != "abc" || list[0].Labels[labelService] != "db" {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+	if !strings.Contains(query, "all=1") {
+		t.Errorf("expected stopped containers to be listed: %s", query)
+	}
+	if !strings.Contains(query, "filters=%7B%22label%22%3A%5B%22com.docker.compose.project%3Ddemo%22%5D%7D") {
+		t.Errorf("unexpected filters: %s", query)
+	}
+}
+
+func TestDemux(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(frame(Stdout, "out 1\n"))
+	stream.Write(frame(Stderr, "err 1\n"))
+	stream.Write(frame(Stdout, "out 2\n"))
+
+	var stdout, stderr bytes.Buffer
+	if err := Demux(&stream, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "out 1\nout 2\n" || stderr.String() != "err 1\n" {
+		t.Fatalf("stdout %q, stderr %q", stdout.String(), stderr.String())
+	}
+
+	truncated := frame(Stdout, "partial")[:10]
+	if err := Demux(bytes.NewReader(truncated), io.Discard, io.Discard); err == nil {
+		t.Error("expected an error for a truncated frame")
+	}
+}
+
+func TestClient_Exec(t *testing.T) {
+	d := newFakeDaemon(t)
+	d.json("POST /containers/abc/exec", map[string]string{"Id": "e1"})
+	d.handle("POST /exec/e1/start", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(frame(Stdout, "hello\n"))
+		_, _ = w.Write(frame(Stderr, "oops\n"))
+	})
+	d.json("GET /exec/e1/json", map[string]int{"ExitCode": 3})
+
+	var stdout, stderr bytes.Buffer
+	code, err := d.client().Exec(context.Background(), "abc", ExecConfig{Cmd: []string{"echo", "hello"}, User: "app"}, nil, &stdout, &stderr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 3 || stdout.String() != "hello\n" || stderr.String() != "oops\n" {
+		t.Fatalf("code %d, stdout %q, stderr %q", code, stdout.String(), stderr.String())
+	}
+	var cfg ExecConfig
+	if err := json.Unmarshal(d.bodies["POST /containers/abc/exec"], &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(cfg.Cmd, " ") != "echo hello" || cfg.User != "app" || cfg.AttachStdin || !cfg.AttachStdout || !cfg.AttachStderr {
+		t.Errorf("unexpected exec config: %+v", cfg)
+	}
+}
+
+func TestClient_ExecInteractive(t *testing.T) {
+	d := newFakeDaemon(t)
+	d.json("POST /containers/abc/exec", map[string]string{"Id": "e1"})
+	d.handle("POST /exec/e1/start", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Upgrade") != "tcp" {
+			t.Errorf("expected an upgrade request, got headers %v", req.Header)
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+		_ = rw.Flush()
+		input, _ := io.ReadAll(rw)
+		_, _ = conn.Write([]byte("got " + string(input)))
+	})
+	d.handle("POST /exec/e1/resize", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("h") != "40" || req.URL.Query().Get("w") != "120" {
+			t.Errorf("unexpected size: %s", req.URL.RawQuery)
+		}
+	})
+	d.json("GET /exec/e1/json", map[string]int{"ExitCode": 0})
+
+	c := d.client()
+	ctx := context.Background()
+	var stdout bytes.Buffer
+	resize := func(id string) { _ = c.ExecResize(ctx, id, 40, 120) }
+	code, err := c.Exec(ctx, "abc", ExecConfig{Cmd: []string{"cat"}, Tty: true}, strings.NewReader("hello"), &stdout, io.Discard, resize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 0 || stdout.String() != "got hello" {
+		t.Fatalf("code %d, stdout %q", code, stdout.String())
+	}
+	var cfg ExecConfig
+	if err := json.Unmarshal(d.bodies["POST /containers/abc/exec"], &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.AttachStdin || !cfg.Tty {
+		t.Errorf("unexpected exec config: %+v", cfg)
+	}
+	if !strings.Contains(strings.Join(d.requests, "\n"), "POST /exec/e1/resize") {
+		t.Errorf("expected the terminal to be resized: %v", d.requests)
+	}
+}
+
+func TestClient_ImagePullError(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	d := newFakeDaemon(t)
+	d.handle("POST /images/create", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("fromImage") != "postgres" || req.URL.Query().Get("tag") != "16" {
+			t.Errorf("unexpected query: %s", req.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"status":"Pulling from library/postgres"}` + "\n" + `{"error":"manifest unknown"}` + "\n"))
+	})
+
+	err := d.client().ImagePull(context.Background(), "postgres:16")
+	if err == nil || !strings.Contains(err.Error(), "manifest unknown") {
+		t.Fatalf("expected the stream's error, got %v", err)
+	}
+}
+
+func TestClient_Events(t *testing.T) {
+	d := newFakeDaemon(t)
+	d.handle("GET /events", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"Type":"container","Action":"start","Actor":{"ID":"abc","Attributes":{"com.docker.compose.service":"db"}},"timeNano":1700000000000000000}` + "\n"))
+		w.(http.Flusher).Flush()
+		<-time.After(50 * time.Millisecond)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	messages, errs := d.client().Events(ctx, "", "com.docker.compose.project=demo")
+	m, ok := <-messages
+	if !ok {
+		t.Fatalf("stream ended early: %v", <-errs)
+	}
+	if m.Action != "start" || m.Actor.Attributes[labelService] != "db" || m.TimeNano != 1700000000000000000 {
+		t.Fatalf("unexpected message: %+v", m)
+	}
+	for range messages {
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSinceParam(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for _, tc := range []struct {
+		since, want string
+	}{
+		{"10m", "1699999400"},
+		{"2023-11-14T22:13:20Z", "1700000000"},
+	} {
+		got, err := sinceParam(tc.since, now)
+		if err != nil || got != tc.want {
+			t.Errorf("sinceParam(%q) = %q, %v; want %q", tc.since, got, err, tc.want)
+		}
+	}
+	if _, err := sinceParam("yesterday", now); err == nil {
+		t.Error("expected an error for an unparseable --since")
+	}
+}
+
+func TestSplitImage(t *testing.T) {
+	for _, tc := range []struct {
+		image, name, tag string
+	}{
+		{"postgres", "postgres", "latest"},
+		{"postgres:16", "postgres", "16"},
+		{"localhost:5000/app", "localhost:5000/app", "latest"},
+		{"localhost:5000/app:v1", "localhost:5000/app", "v1"},
+		{"redis@sha256:abc", "redis", "sha256:abc"},
+	} {
+		name, tag := splitImage(tc.image)
+		if name != tc.name || tag != tc.tag {
+			t.Errorf("splitImage(%q) = %q, %q; want %q, %q", tc.image, name, tag, tc.name, tc.tag)
+		}
+	}
+}
+
+func TestNewClient_Hosts(t *testing.T) {
+	t.Setenv("DOCKER_TLS_VERIFY", "")
+	for _, host := range []string{"unix:///var/run/docker.sock", "tcp://127.0.0.1:2375"} {
+		if _, err := NewClient(host); err != nil {
+			t.Errorf("NewClient(%q): %v", host, err)
+		}
+	}
+	if _, err := NewClient("ssh://user@host"); err == nil {
+		t.Error("expected ssh:// hosts to be rejected")
+	}
+}
+
+func TestClient_ImagePullAuth(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+	config := `{"auths":{"https://registry.example.com/v1/":{"auth":"` + base64.StdEncoding.EncodeToString([]byte("dev:s3cret")) + `"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	d := newFakeDaemon(t)
+	d.handle("POST /images/create", func(w http.ResponseWriter, req *http.Request) {
+		got = append(got, req.Header.Get("X-Registry-Auth"))
+	})
+	for _, image := range []string{"registry.example.com/team/api:1.0", "postgres:16"} {
+		if err := d.client().ImagePull(context.Background(), image); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := base64.URLEncoding.DecodeString(got[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var auth AuthConfig
+	if err := json.Unmarshal(data, &auth); err != nil {
+		t.Fatal(err)
+	}
+	if auth.Username != "dev" || auth.Password != "s3cret" || auth.ServerAddress != "https://registry.example.com/v1/" {
+		t.Errorf("unexpected credential for the private registry: %+v", auth)
+	}
+	if got[1] != "" {
+		t.Errorf("expected Docker Hub to be pulled anonymously, got %q", got[1])
+	}
+}
+
+func TestRegistryHost(t *testing.T) {
+	for image, want := range map[string]string{
+		"postgres:16":                   "docker.io",
+		"bitnami/redis":                 "docker.io",
+		"ghcr.io/org/app:1":             "ghcr.io",
+		"localhost:5000/app":            "localhost:5000",
+		"localhost/app":                 "localhost",
+		"index.docker.io/library/nginx": "docker.io",
+	} {
+		if got := registryHost(image); got != want {
+			t.Errorf("registryHost(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dever-labs/devx/internal/compose"
+	"github.com/dever-labs/devx/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Labels docker compose puts on what it creates. Setting them too lets
+// `docker compose` and `devx ls` see the containers devx starts.
+const (
+	labelProject     = "com.docker.compose.project"
+	labelService     = "com.docker.compose.service"
+	labelNumber      = "com.docker.compose.container-number"
+	labelOneoff      = "com.docker.compose.oneoff"
+	labelWorkingDir  = "com.docker.compose.project.working_dir"
+	labelConfigFiles = "com.docker.compose.project.config_files"
+	labelConfigHash  = "com.docker.compose.config-hash"
+	labelNetwork     = "com.docker.compose.network"
+	labelVolume      = "com.docker.compose.volume"
+)
+
+// ContainerConfig is the body of a container create request.
+type ContainerConfig struct {
+	Image            string
+	Env              []string            `json:",omitempty"`
+	Cmd              []string            `json:",omitempty"`
+	WorkingDir       string              `json:",omitempty"`
+	User             string              `json:",omitempty"`
+	Tty              bool                `json:",omitempty"`
+	OpenStdin        bool                `json:",omitempty"`
+	StdinOnce        bool                `json:",omitempty"`
+	AttachStdin      bool                `json:",omitempty"`
+	AttachStdout     bool                `json:",omitempty"`
+	AttachStderr     bool                `json:",omitempty"`
+	Labels           map[string]string   `json:",omitempty"`
+	ExposedPorts     map[string]struct{} `json:",omitempty"`
+	Volumes          map[string]struct{} `json:",omitempty"`
+	Healthcheck      *Healthcheck        `json:",omitempty"`
+	HostConfig       HostConfig
+	NetworkingConfig NetworkingConfig
+}
+
+// Healthcheck is a container healthcheck; durations are in nanoseconds.
+type Healthcheck struct {
+	Test        []string
+	Interval    time.Duration `json:",omitempty"`
+	Timeout     time.Duration `json:",omitempty"`
+	StartPeriod time.Duration `json:",omitempty"`
+	Retries     int           `json:",omitempty"`
+}
+
+type HostConfig struct {
+	Binds        []string                 `json:",omitempty"`
+	PortBindings map[string][]PortBinding `json:",omitempty"`
+	NetworkMode  string                   `json:",omitempty"`
+	Privileged   bool                     `json:",omitempty"`
+}
+
+type PortBinding struct {
+	HostIP   string `json:"HostIp,omitempty"`
+	HostPort string `json:",omitempty"`
+}
+
+type NetworkingConfig struct {
+	EndpointsConfig map[string]EndpointConfig `json:",omitempty"`
+}
+
+type EndpointConfig struct {
+	Aliases []string `json:",omitempty"`
+}
+
+// project is a compose file loaded for the Engine API runtime.
+type project struct {
+	name string
+	// dir is the compose file's directory, against which relative paths
+	// in it are resolved.
+	dir        string
+	configFile string
+	file       compose.File
+}
+
+func loadProject(composePath, projectName string) (*project, error) {
+	abs, err := filepath.Abs(composePath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, err
+	}
+	p := &project{name: projectName, dir: filepath.Dir(abs), configFile: abs}
+	if err := yaml.Unmarshal(data, &p.file); err != nil {
+		return nil, fmt.Errorf("%s: %w", composePath, err)
+	}
+	return p, nil
+}
+
+func (p *project) networkName(name string) string { return p.name + "_" + name }
+
+func (p *project) volumeName(name string) string { return p.name + "_" + name }
+
+// containerName follows compose's <project>-<service>-<number> scheme.
+func (p *project) containerName(service string) string {
+	return p.name + "-" + service + "-1"
+}
+
+// containerConfig translates a compose service into a create request.
+func (p *project) containerConfig(name string, svc compose.Service) (*ContainerConfig, error) {
+	cfg := &ContainerConfig{
+		Image:      svc.Image,
+		Cmd:        unescapeList(svc.Command),
+		WorkingDir: svc.WorkingDir,
+		Labels: map[string]string{
+			labelProject:     p.name,
+			labelService:     name,
+			labelNumber:      "1",
+			labelOneoff:      "False",
+			labelWorkingDir:  p.dir,
+			labelConfigFiles: p.configFile,
+		},
+		HostConfig: HostConfig{Privileged: svc.Privileged},
+	}
+	for k, v := range svc.Labels {
+		cfg.Labels[k] = v
+	}
+	for _, k := range sortedKeys(svc.Environment) {
+		cfg.Env = append(cfg.Env, k+"="+strings.ReplaceAll(svc.Environment[k], "$$", "$"))
+	}
+
+	if hc := svc.Healthcheck; hc != nil {
+		h := &Healthcheck{Test: hc.Test, Retries: hc.Retries}
+		for _, d := range []struct {
+			value string
+			out   *time.Duration
+		}{{hc.Interval, &h.Interval}, {hc.Timeout, &h.Timeout}, {hc.StartPeriod, &h.StartPeriod}} {
+			if d.value == "" {
+				continue
+			}
+			parsed, err := time.ParseDuration(d.value)
+			if err != nil {
+				return nil, fmt.Errorf("service '%s' healthcheck: %w", name, err)
+			}
+			*d.out = parsed
+		}
+		cfg.Healthcheck = h
+	}
+
+	for _, port := range svc.Ports {
+		if err := addPort(cfg, port); err != nil {
+			return nil, fmt.Errorf("service '%s': %w", name, err)
+		}
+	}
+
+	for _, v := range svc.Volumes {
+		p.addVolume(cfg, v)
+	}
+	for _, secret := range svc.Secrets {
+		def, ok := p.file.Secrets[secret]
+		if !ok {
+			return nil, fmt.Errorf("service '%s': secret '%s' is not defined", name, secret)
+		}
+		cfg.HostConfig.Binds = append(cfg.HostConfig.Binds, p.hostPath(def.File)+":/run/secrets/"+secret+":ro")
+	}
+
+	networks := serviceNetworks(svc)
+	cfg.HostConfig.NetworkMode = p.networkName(networks[0])
+	cfg.NetworkingConfig.EndpointsConfig = map[string]EndpointConfig{}
+	for _, n := range networks {
+		cfg.NetworkingConfig.EndpointsConfig[p.networkName(n)] = EndpointConfig{Aliases: []string{name}}
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	cfg.Labels[labelConfigHash] = hex.EncodeToString(sum[:])
+	return cfg, nil
+}
+
+// addPort exposes and publishes one compose port entry.
+func addPort(cfg *ContainerConfig, port compose.Port) error {
+	var p config.Port
+	if port.Long != nil {
+		p = config.Port{
+			Container: config.PortRange{First: port.Long.Target},
+			HostIP:    port.Long.HostIP,
+			Protocol:  port.Long.Protocol,
+		}
+		if port.Long.Published != "" {
+			if err := yaml.Unmarshal([]byte(port.Long.Published), &p.Host); err != nil {
+				return err
+			}
+		}
+	} else {
+		var err error
+		if p, err = config.ParsePort(port.Short); err != nil {
+			return err
+		}
+	}
+
+	if cfg.ExposedPorts == nil {
+		cfg.ExposedPorts = map[string]struct{}{}
+	}
+	if cfg.HostConfig.PortBindings == nil {
+		cfg.HostConfig.PortBindings = map[string][]PortBinding{}
+	}
+	for i, container := range p.Container.Ports() {
+		key := strconv.Itoa(container) + "/" + p.Proto()
+		cfg.ExposedPorts[key] = struct{}{}
+		if p.Host.IsZero() {
+			continue
+		}
+		// Ranges of the same size map port for port; a single container
+		// port on a host range lets the daemon pick a port in it.
+		host := p.Host.String()
+		if p.Host.Size() == p.Container.Size() {
+			host = strconv.Itoa(p.Host.First + i)
+		}
+		cfg.HostConfig.PortBindings[key] = append(cfg.HostConfig.PortBindings[key], PortBinding{HostIP: p.HostIP, HostPort: host})
+	}
+	return nil
+}
+
+// addVolume mounts a compose volume entry: a named volume of the project,
+// a host path (relative to the compose file) or an anonymous volume.
+func (p *project) addVolume(cfg *ContainerConfig, spec string) {
+	parts := strings.Split(spec, ":")
+	if len(parts) == 1 {
+		if cfg.Volumes == nil {
+			cfg.Volumes = map[string]struct{}{}
+		}
+		cfg.Volumes[spec] = struct{}{}
+		return
+	}
+	source := parts[0]
+	if isHostPath(source) {
+		source = p.hostPath(source)
+	} else {
+		source = p.volumeName(source)
+	}
+	cfg.HostConfig.Binds = append(cfg.HostConfig.Binds, strings.Join(append([]string{source}, parts[1:]...), ":"))
+}
+
+func isHostPath(source string) bool {
+	return strings.HasPrefix(source, ".") || strings.HasPrefix(source, "/") || strings.HasPrefix(source, "~")
+}
+
+// hostPath resolves a path in the compose file to an absolute one.
+func (p *project) hostPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~"); ok {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, rest)
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(p.dir, path)
+}
+
+// serviceNetworks returns the networks a service joins; like compose,
+// services that name none join the project's default network.
+func serviceNetworks(svc compose.Service) []string {
+	if len(svc.Networks) == 0 {
+		return []string{"default"}
+	}
+	return svc.Networks
+}
+
+// networks returns the names of the networks services join.
+func (p *project) networks(services []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, name := range services {
+		for _, n := range serviceNetworks(p.file.Services[name]) {
+			if !seen[n] {
+				seen[n] = true
+				out = append(out, n)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// volumes returns the names of the named volumes services use.
+func (p *project) volumes(services []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, name := range services {
+		for _, v := range p.file.Services[name].Volumes {
+			source, _, ok := strings.Cut(v, ":")
+			if !ok || isHostPath(source) || seen[source] {
+				continue
+			}
+			seen[source] = true
+			out = append(out, source)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// order returns services, plus what they depend on when withDeps is set,
+// with every service after its dependencies.
+func (p *project) order(services []string, withDeps bool) ([]string, error) {
+	if len(services) == 0 {
+		services = sortedKeys(p.file.Services)
+		withDeps = true
+	}
+	selected := map[string]bool{}
+	for _, name := range services {
+		if _, ok := p.file.Services[name]; !ok {
+			return nil, fmt.Errorf("no such service: %s", name)
+		}
+		selected[name] = true
+	}
+
+	var out []string
+	state := map[string]int{} // 1 while visiting, 2 once done
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("dependency cycle at service '%s'", name)
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, dep := range sortedKeys(p.file.Services[name].DependsOn) {
+			if withDeps || selected[dep] {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = 2
+		out = append(out, name)
+		return nil
+	}
+	for _, name := range sortedKeys(selected) {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// unescapeList undoes compose's $$ escaping, which only compose itself
+// would otherwise undo.
+func unescapeList(list []string) []string {
+	if list == nil {
+		return nil
+	}
+	out := make([]string, len(list))
+	for i, s := range list {
+		out[i] = strings.ReplaceAll(s, "$$", "$")
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
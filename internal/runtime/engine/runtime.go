@@ -0,0 +1,827 @@
+package engine
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dever-labs/devx/internal/compose"
+	"github.com/dever-labs/devx/internal/runtime"
+)
+
+// stopTimeout is how long containers get to exit before they are killed,
+// the same default as compose.
+const stopTimeout = 10 * time.Second
+
+// Runtime runs the compose files devx generates through the Engine API,
+// creating the networks, volumes and containers compose would.
+type Runtime struct {
+	// Host is the daemon's address, as in DOCKER_HOST.
+	Host string
+	// Out receives progress messages; nil means os.Stderr.
+	Out io.Writer
+}
+
+func New() *Runtime {
+	return &Runtime{Host: HostFromEnv()}
+}
+
+func (r *Runtime) Name() string {
+	return "docker-engine"
+}
+
+func (r *Runtime) Detect(ctx context.Context) (bool, error) {
+	c, err := NewClient(r.Host)
+	if err != nil {
+		return false, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return c.Ping(ctx) == nil, nil
+}
+
+func (r *Runtime) out() io.Writer {
+	if r.Out != nil {
+		return r.Out
+	}
+	return os.Stderr
+}
+
+func (r *Runtime) Up(ctx context.Context, composePath string, projectName string, opts runtime.UpOptions) error {
+	c, err := NewClient(r.Host)
+	if err != nil {
+		return err
+	}
+	p, err := loadProject(composePath, projectName)
+	if err != nil {
+		return err
+	}
+	services, err := p.order(opts.Services, !opts.NoDeps)
+	if err != nil {
+		return err
+	}
+	for _, name := range services {
+		if p.file.Services[name].Build != nil {
+			return fmt.Errorf("service '%s' is built from source, which needs the docker compose plugin", name)
+		}
+	}
+
+	if err := createResources(ctx, c, p, services); err != nil {
+		return err
+	}
+
+	existing, err := r.containers(ctx, c, p.name, nil)
+	if err != nil {
+		return err
+	}
+	ids := map[string]string{}
+	for _, name := range services {
+		svc := p.file.Services[name]
+		for _, dep := range sortedKeys(svc.DependsOn) {
+			if svc.DependsOn[dep].Condition != compose.ConditionHealthy || ids[dep] == "" {
+				continue
+			}
+			if err := waitHealthy(ctx, c, ids[dep]); err != nil {
+				return fmt.Errorf("dependency '%s' of '%s': %w", dep, name, err)
+			}
+		}
+
+		cfg, err := p.containerConfig(name, svc)
+		if err != nil {
+			return err
+		}
+		image, err := ensureImage(ctx, c, svc.Image, opts.Pull)
+		if err != nil {
+			return err
+		}
+		id, err := r.upContainer(ctx, c, p, name, cfg, image, existing[name], opts.ForceRecreate)
+		if err != nil {
+			return fmt.Errorf("service '%s': %w", name, err)
+		}
+		ids[name] = id
+	}
+	return nil
+}
+
+// createResources creates the networks and named volumes services use.
+func createResources(ctx context.Context, c *Client, p *project, services []string) error {
+	for _, n := range p.networks(services) {
+		labels := map[string]string{labelProject: p.name, labelNetwork: n}
+		if err := c.NetworkCreate(ctx, p.networkName(n), labels); err != nil {
+			return fmt.Errorf("network %s: %w", p.networkName(n), err)
+		}
+	}
+	for _, v := range p.volumes(services) {
+		labels := map[string]string{labelProject: p.name, labelVolume: v}
+		if err := c.VolumeCreate(ctx, p.volumeName(v), labels); err != nil {
+			return fmt.Errorf("volume %s: %w", p.volumeName(v), err)
+		}
+	}
+	return nil
+}
+
+// upContainer starts the service's container, reusing the existing one
+// while its configuration and image are unchanged.
+func (r *Runtime) upContainer(ctx context.Context, c *Client, p *project, name string, cfg *ContainerConfig, image Image, existing []ContainerSummary, force bool) (string, error) {
+	for _, old := range existing {
+		if !force && old.Labels[labelConfigHash] == cfg.Labels[labelConfigHash] && old.ImageID == image.ID {
+			if old.State != "running" {
+				if err := c.ContainerStart(ctx, old.ID); err != nil {
+					return "", err
+				}
+				fmt.Fprintf(r.out(), "Container %s  Started\n", p.containerName(name))
+			}
+			return old.ID, nil
+		}
+		// Stop before recreating, as Down does, so the old container shuts
+		// down cleanly.
+		if old.State == "running" || old.State == "restarting" {
+			if err := c.ContainerStop(ctx, old.ID, stopTimeout); err != nil {
+				return "", err
+			}
+		}
+		if err := c.ContainerRemove(ctx, old.ID, false); err != nil {
+			return "", err
+		}
+	}
+	id, err := c.ContainerCreate(ctx, p.containerName(name), cfg)
+	if err != nil {
+		return "", err
+	}
+	if err := c.ContainerStart(ctx, id); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(r.out(), "Container %s  Started\n", p.containerName(name))
+	return id, nil
+}
+
+// ensureImage pulls image when it is missing, or always when pull is set.
+func ensureImage(ctx context.Context, c *Client, image string, pull bool) (Image, error) {
+	if !pull {
+		img, err := c.ImageInspect(ctx, image)
+		if err == nil {
+			return img, nil
+		}
+		if !IsNotFound(err) {
+			return Image{}, err
+		}
+	}
+	if err := c.ImagePull(ctx, image); err != nil {
+		return Image{}, err
+	}
+	return c.ImageInspect(ctx, image)
+}
+
+// waitHealthy waits for a container's healthcheck to pass, as compose does
+// for dependencies with condition service_healthy.
+func waitHealthy(ctx context.Context, c *Client, id string) error {
+	for {
+		raw, err := c.ContainerInspect(ctx, id)
+		if err != nil {
+			return err
+		}
+		var info struct {
+			State struct {
+				Status string
+				Health *struct{ Status string }
+			}
+		}
+		if err := json.Unmarshal(raw, &info); err != nil {
+			return err
+		}
+		switch {
+		case info.State.Health == nil:
+			return fmt.Errorf("container has no healthcheck")
+		case info.State.Health.Status == "healthy":
+			return nil
+		case info.State.Health.Status == "unhealthy":
+			return fmt.Errorf("container is unhealthy")
+		case info.State.Status == "exited" || info.State.Status == "dead":
+			return fmt.Errorf("container %s", info.State.Status)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// containers returns the project's containers keyed by service, limited to
+// services when it is not empty.
+func (r *Runtime) containers(ctx context.Context, c *Client, projectName string, services []string) (map[string][]ContainerSummary, error) {
+	list, err := c.ContainerList(ctx, labelProject+"="+projectName, labelOneoff+"=False")
+	if err != nil {
+		return nil, err
+	}
+	wanted := map[string]bool{}
+	for _, s := range services {
+		wanted[s] = true
+	}
+	out := map[string][]ContainerSummary{}
+	for _, ctr := range list {
+		svc := ctr.Labels[labelService]
+		if len(wanted) > 0 && !wanted[svc] {
+			continue
+		}
+		out[svc] = append(out[svc], ctr)
+	}
+	return out, nil
+}
+
+// each calls fn for the containers of services, or of the whole project
+// when services is empty, in service order.
+func (r *Runtime) each(ctx context.Context, projectName string, services []string, fn func(*Client, ContainerSummary) error) error {
+	c, err := NewClient(r.Host)
+	if err != nil {
+		return err
+	}
+	byService, err := r.containers(ctx, c, projectName, services)
+	if err != nil {
+		return err
+	}
+	for _, svc := range sortedKeys(byService) {
+		for _, ctr := range byService[svc] {
+			if err := fn(c, ctr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Runtime) Down(ctx context.Context, composePath string, projectName string, opts runtime.DownOptions) error {
+	err := r.each(ctx, projectName, opts.Services, func(c *Client, ctr ContainerSummary) error {
+		// Stop first, as compose does, so containers get stopTimeout to
+		// shut down cleanly rather than the SIGKILL a forced remove sends.
+		if ctr.State == "running" || ctr.State == "restarting" {
+			if err := c.ContainerStop(ctx, ctr.ID, stopTimeout); err != nil {
+				return err
+			}
+			fmt.Fprintf(r.out(), "Container %s  Stopped\n", containerName(ctr))
+		}
+		if err := c.ContainerRemove(ctx, ctr.ID, opts.Volumes); err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out(), "Container %s  Removed\n", containerName(ctr))
+		return nil
+	})
+	if err != nil || len(opts.Services) > 0 {
+		return err
+	}
+
+	p, err := loadProject(composePath, projectName)
+	if err != nil {
+		return err
+	}
+	c, err := NewClient(r.Host)
+	if err != nil {
+		return err
+	}
+	all := sortedKeys(p.file.Services)
+	for _, n := range p.networks(all) {
+		if err := c.NetworkRemove(ctx, p.networkName(n)); err != nil {
+			return err
+		}
+	}
+	if opts.Volumes {
+		for _, v := range p.volumes(all) {
+			if err := c.VolumeRemove(ctx, p.volumeName(v)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Runtime) Stop(ctx context.Context, composePath string, projectName string, services []string) error {
+	return r.each(ctx, projectName, services, func(c *Client, ctr ContainerSummary) error {
+		return c.ContainerStop(ctx, ctr.ID, stopTimeout)
+	})
+}
+
+func (r *Runtime) Restart(ctx context.Context, composePath string, projectName string, services []string) error {
+	return r.each(ctx, projectName, services, func(c *Client, ctr ContainerSummary) error {
+		return c.ContainerRestart(ctx, ctr.ID, stopTimeout)
+	})
+}
+
+// Logs merges the logs of the project's containers, each line prefixed by
+// the container as compose does: "db-1  | 2024-01-02T03:04:05Z message".
+func (r *Runtime) Logs(ctx context.Context, composePath string, projectName string, opts runtime.LogsOptions) (io.ReadCloser, error) {
+	var ctrs []ContainerSummary
+	if err := r.each(ctx, projectName, opts.Services, func(_ *Client, ctr ContainerSummary) error {
+		ctrs = append(ctrs, ctr)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	c, err := NewClient(r.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	width := 0
+	for _, ctr := range ctrs {
+		width = max(width, len(logPrefix(ctr)))
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	pr, pw := io.Pipe()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, ctr := range ctrs {
+		body, err := c.ContainerLogs(ctx, ctr.ID, LogsOptions{Follow: opts.Follow, Since: opts.Since, Tail: opts.Tail})
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		prefix := fmt.Sprintf("%-*s | ", width, logPrefix(ctr))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer body.Close()
+			lw := &lineWriter{prefix: prefix, mu: &mu, w: pw}
+			ew := lw
+			if opts.Stderr != nil {
+				ew = &lineWriter{prefix: prefix, mu: &mu, w: opts.Stderr}
+			}
+			_ = Demux(body, lw, ew)
+			lw.flush()
+			ew.flush()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		pw.Close()
+	}()
+	return &logsReader{PipeReader: pr, cancel: cancel}, nil
+}
+
+// logPrefix names a container in logs the way compose does, e.g. "db-1".
+func logPrefix(ctr ContainerSummary) string {
+	return ctr.Labels[labelService] + "-" + ctr.Labels[labelNumber]
+}
+
+// lineWriter writes whole lines, each with prefix, to a writer shared by
+// every container's log stream.
+type lineWriter struct {
+	prefix string
+	mu     *sync.Mutex
+	w      io.Writer
+	buf    []byte
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	for {
+		i := strings.IndexByte(string(l.buf), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if err := l.emit(l.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		l.buf = l.buf[i+1:]
+	}
+}
+
+func (l *lineWriter) flush() {
+	if len(l.buf) > 0 {
+		_ = l.emit(append(l.buf, '\n'))
+		l.buf = nil
+	}
+}
+
+func (l *lineWriter) emit(line []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.w.Write(append([]byte(l.prefix), line...))
+	return err
+}
+
+type logsReader struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (l *logsReader) Close() error {
+	l.cancel()
+	return l.PipeReader.Close()
+}
+
+// Exec runs a command in the service's running container. With a
+// terminal, the local one is put in raw mode and the container's is sized
+// to match; signals are not relayed, as the API has no way to send them to
+// an exec'd process, but Ctrl-C reaches it through the terminal.
+func (r *Runtime) Exec(ctx context.Context, composePath string, projectName string, service string, opts runtime.ExecOptions) (int, error) {
+	c, err := NewClient(r.Host)
+	if err != nil {
+		return 1, err
+	}
+	ctr, err := r.running(ctx, c, projectName, service)
+	if err != nil {
+		return 1, err
+	}
+
+	cfg := ExecConfig{Cmd: opts.Cmd, User: opts.User, WorkingDir: opts.Workdir, Env: opts.Env, Tty: opts.TTY}
+	restore, size := localTerminal(opts)
+	defer restore()
+	var resize func(string)
+	if size != nil {
+		resize = func(execID string) {
+			if h, w, ok := size(); ok {
+				_ = c.ExecResize(ctx, execID, h, w)
+			}
+		}
+	}
+	stdout, stderr := outputs(opts)
+	return c.Exec(ctx, ctr.ID, cfg, opts.Stdin, stdout, stderr, resize)
+}
+
+// Run runs a command in a new container of the service, once the services
+// it depends on are up, and removes the container when the command exits.
+// As with compose run, none of the service's ports are published, and
+// signals reach the command only through a terminal.
+func (r *Runtime) Run(ctx context.Context, composePath string, projectName string, service string, opts runtime.ExecOptions) (int, error) {
+	c, err := NewClient(r.Host)
+	if err != nil {
+		return 1, err
+	}
+	p, err := loadProject(composePath, projectName)
+	if err != nil {
+		return 1, err
+	}
+	svc, ok := p.file.Services[service]
+	if !ok {
+		return 1, fmt.Errorf("no such service: %s", service)
+	}
+	if svc.Build != nil {
+		return 1, fmt.Errorf("service '%s' is built from source, which needs the docker compose plugin", service)
+	}
+
+	if deps := sortedKeys(svc.DependsOn); len(deps) > 0 {
+		if err := r.Up(ctx, composePath, projectName, runtime.UpOptions{Services: deps}); err != nil {
+			return 1, err
+		}
+		for _, dep := range deps {
+			if svc.DependsOn[dep].Condition != compose.ConditionHealthy {
+				continue
+			}
+			ctr, err := r.running(ctx, c, projectName, dep)
+			if err == nil {
+				err = waitHealthy(ctx, c, ctr.ID)
+			}
+			if err != nil {
+				return 1, fmt.Errorf("dependency '%s' of '%s': %w", dep, service, err)
+			}
+		}
+	}
+	if err := createResources(ctx, c, p, []string{service}); err != nil {
+		return 1, err
+	}
+
+	cfg, err := p.containerConfig(service, svc)
+	if err != nil {
+		return 1, err
+	}
+	cfg.Labels[labelOneoff] = "True"
+	cfg.HostConfig.PortBindings = nil
+	if len(opts.Cmd) > 0 {
+		cfg.Cmd = opts.Cmd
+	}
+	if opts.User != "" {
+		cfg.User = opts.User
+	}
+	if opts.Workdir != "" {
+		cfg.WorkingDir = opts.Workdir
+	}
+	cfg.Env = append(cfg.Env, opts.Env...)
+	cfg.Tty = opts.TTY
+	cfg.OpenStdin, cfg.StdinOnce, cfg.AttachStdin = opts.Stdin != nil, opts.Stdin != nil, opts.Stdin != nil
+	cfg.AttachStdout, cfg.AttachStderr = true, true
+	if _, err := ensureImage(ctx, c, svc.Image, false); err != nil {
+		return 1, err
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return 1, err
+	}
+	id, err := c.ContainerCreate(ctx, p.name+"-"+service+"-run-"+hex.EncodeToString(suffix), cfg)
+	if err != nil {
+		return 1, err
+	}
+	defer func() {
+		// Removed even when ctx was cancelled, so nothing is left behind.
+		_ = c.ContainerRemove(context.Background(), id, true)
+	}()
+
+	conn, err := c.ContainerAttach(ctx, id, opts.Stdin != nil)
+	if err != nil {
+		return 1, err
+	}
+	defer conn.Close()
+	restore, size := localTerminal(opts)
+	defer restore()
+	if err := c.ContainerStart(ctx, id); err != nil {
+		return 1, err
+	}
+	if size != nil {
+		if h, w, ok := size(); ok {
+			_ = c.ContainerResize(ctx, id, h, w)
+		}
+	}
+	if opts.Stdin != nil {
+		go func() {
+			_, _ = io.Copy(conn, opts.Stdin)
+			_ = conn.CloseWrite()
+		}()
+	}
+	stdout, stderr := outputs(opts)
+	if err := copyOutput(conn, opts.TTY, stdout, stderr); err != nil {
+		return 1, err
+	}
+	return c.ContainerWait(ctx, id)
+}
+
+// localTerminal puts devx's terminal in raw mode when opts asks for a
+// terminal and stdin is one, returning a function that restores it and,
+// when stdout is a terminal too, one that reports its size.
+func localTerminal(opts runtime.ExecOptions) (restore func(), size func() (int, int, bool)) {
+	restore = func() {}
+	in, ok := opts.Stdin.(*os.File)
+	if !ok || !opts.TTY {
+		return restore, nil
+	}
+	if undo, err := makeRaw(in.Fd()); err == nil {
+		restore = undo
+	}
+	if out, ok := opts.Stdout.(*os.File); ok {
+		size = func() (int, int, bool) { return terminalSize(out.Fd()) }
+	}
+	return restore, size
+}
+
+// outputs returns opts' stdout and stderr, discarding those that are nil.
+func outputs(opts runtime.ExecOptions) (io.Writer, io.Writer) {
+	stdout, stderr := opts.Stdout, opts.Stderr
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+	return stdout, stderr
+}
+
+// running returns the service's running container.
+func (r *Runtime) running(ctx context.Context, c *Client, projectName, service string) (ContainerSummary, error) {
+	byService, err := r.containers(ctx, c, projectName, []string{service})
+	if err != nil {
+		return ContainerSummary{}, err
+	}
+	for _, ctr := range byService[service] {
+		if ctr.State == "running" {
+			return ctr, nil
+		}
+	}
+	return ContainerSummary{}, fmt.Errorf("service '%s' is not running", service)
+}
+
+func (r *Runtime) Status(ctx context.Context, composePath string, projectName string) ([]runtime.ServiceStatus, error) {
+	var results []runtime.ServiceStatus
+	err := r.each(ctx, projectName, nil, func(_ *Client, ctr ContainerSummary) error {
+		results = append(results, serviceStatus(ctr))
+		return nil
+	})
+	return results, err
+}
+
+// serviceStatus describes a listed container. The list endpoint reports
+// health only inside the status text, e.g. "Up 5 minutes (healthy)".
+func serviceStatus(ctr ContainerSummary) runtime.ServiceStatus {
+	st := runtime.ServiceStatus{Name: ctr.Labels[labelService], State: ctr.State}
+	switch {
+	case strings.Contains(ctr.Status, "(healthy)"):
+		st.Health = "healthy"
+	case strings.Contains(ctr.Status, "(unhealthy)"):
+		st.Health = "unhealthy"
+	case strings.Contains(ctr.Status, "(health: starting)"):
+		st.Health = "starting"
+	}
+	ports := append([]Port(nil), ctr.Ports...)
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].PrivatePort != ports[j].PrivatePort {
+			return ports[i].PrivatePort < ports[j].PrivatePort
+		}
+		return ports[i].IP < ports[j].IP
+	})
+	var published []string
+	for _, p := range ports {
+		st.Publishers = append(st.Publishers, runtime.Publisher{
+			URL:           p.IP,
+			TargetPort:    p.PrivatePort,
+			PublishedPort: p.PublicPort,
+			Protocol:      p.Type,
+		})
+		if p.PublicPort != 0 {
+			published = append(published, fmt.Sprintf("%s:%d->%d/%s", p.IP, p.PublicPort, p.PrivatePort, p.Type))
+		}
+	}
+	st.Ports = strings.Join(published, ", ")
+	return st
+}
+
+// Inspect reports the state of every container of service, including
+// stopped ones.
+func (r *Runtime) Inspect(ctx context.Context, composePath string, projectName string, service string) ([]runtime.ContainerState, error) {
+	var entries []json.RawMessage
+	err := r.each(ctx, projectName, []string{service}, func(c *Client, ctr ContainerSummary) error {
+		raw, err := c.ContainerInspect(ctx, ctr.ID)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, raw)
+		return nil
+	})
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	return runtime.ParseInspect(data)
+}
+
+// Copy copies src on the host, a file or a directory, to dst inside
+// service's container.
+func (r *Runtime) Copy(ctx context.Context, composePath string, projectName string, service string, src string, dst string) error {
+	c, err := NewClient(r.Host)
+	if err != nil {
+		return err
+	}
+	ctr, err := r.running(ctx, c, projectName, service)
+	if err != nil {
+		return err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeArchive(pw, src, path.Base(dst)))
+	}()
+	err = c.CopyTo(ctx, ctr.ID, path.Dir(dst), pr)
+	pr.Close()
+	return err
+}
+
+// writeArchive writes src to w as a tar archive whose root entry is named
+// name.
+func writeArchive(w io.Writer, src, name string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(name, filepath.ToSlash(rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// List reports every container with a devx.project label, in any project.
+func (r *Runtime) List(ctx context.Context) ([]runtime.Container, error) {
+	c, err := NewClient(r.Host)
+	if err != nil {
+		return nil, err
+	}
+	list, err := c.ContainerList(ctx, "devx.project")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]runtime.Container, 0, len(list))
+	for _, ctr := range list {
+		out = append(out, runtime.Container{Name: containerName(ctr), State: ctr.State, Labels: ctr.Labels})
+	}
+	return out, nil
+}
+
+// Events streams the daemon's lifecycle events for the project's devx
+// containers.
+func (r *Runtime) Events(ctx context.Context, composePath string, projectName string, opts runtime.EventsOptions) (<-chan runtime.Event, <-chan error) {
+	events := make(chan runtime.Event)
+	errs := make(chan error, 1)
+	c, err := NewClient(r.Host)
+	if err != nil {
+		close(events)
+		errs <- err
+		return events, errs
+	}
+	messages, merrs := c.Events(ctx, opts.Since, runtime.EventFilters(projectName)...)
+	go func() {
+		defer close(events)
+		for m := range messages {
+			if m.Type != "container" {
+				continue
+			}
+			e, ok := runtime.NormalizeDockerEvent(m.Action, m.Actor.ID, m.Actor.Attributes, m.TimeNano)
+			if !ok {
+				continue
+			}
+			select {
+			case events <- e:
+			case <-ctx.Done():
+			}
+		}
+		errs <- <-merrs
+	}()
+	return events, errs
+}
+
+func containerName(ctr ContainerSummary) string {
+	if len(ctr.Names) == 0 {
+		return ctr.ID
+	}
+	return strings.TrimPrefix(ctr.Names[0], "/")
+}
+
+func (r *Runtime) ResolveImageDigest(ctx context.Context, image string) (string, error) {
+	c, err := NewClient(r.Host)
+	if err != nil {
+		return "", err
+	}
+	img, err := c.ImageInspect(ctx, image)
+	if err != nil || repoDigest(img) == "" {
+		if img, err = ensureImage(ctx, c, image, true); err != nil {
+			return "", err
+		}
+	}
+	if digest := repoDigest(img); digest != "" {
+		return digest, nil
+	}
+	return "", fmt.Errorf("no digest found for %s", image)
+}
+
+// repoDigest returns the digest part of an image's first repo digest,
+// e.g. "sha256:…".
+func repoDigest(img Image) string {
+	for _, d := range img.RepoDigests {
+		if _, digest, ok := strings.Cut(d, "@"); ok {
+			return digest
+		}
+	}
+	return ""
+}
+
+var _ interface {
+	runtime.Runtime
+	runtime.Inspector
+	runtime.Copier
+	runtime.Runner
+	runtime.DigestResolver
+	runtime.Lister
+} = (*Runtime)(nil)
@@ -0,0 +1,446 @@
+package engine
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dever-labs/devx/internal/runtime"
+)
+
+const testCompose = `services:
+  api:
+    image: ghcr.io/acme/api:1.0
+    command: ["sh", "-c", "echo $$HOME"]
+    environment:
+      DB_URL: postgres://db:5432/app
+    ports:
+      - "8080:80"
+    volumes:
+      - ../src:/app
+    depends_on:
+      db:
+        condition: service_healthy
+    networks: [devx]
+    secrets: [token]
+  db:
+    image: postgres:16
+    ports:
+      - name: sql
+        target: 5432
+        published: "5432"
+    volumes:
+      - pgdata:/var/lib/postgresql/data
+    healthcheck:
+      test: ["CMD", "pg_isready"]
+      interval: 5s
+      retries: 3
+    networks: [devx]
+networks:
+  devx: {}
+volumes:
+  pgdata: {}
+secrets:
+  token:
+    file: secrets/token
+`
+
+func writeCompose(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "compose.yaml")
+	if err := os.WriteFile(path, []byte(testCompose), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRuntime_Up(t *testing.T) {
+	d := newFakeDaemon(t)
+	d.json("GET /networks", []any{})
+	d.json("POST /networks/create", map[string]string{"Id": "n1"})
+	d.json("POST /volumes/create", map[string]string{"Name": "demo_pgdata"})
+	d.json("GET /containers/json", []any{})
+	d.json("GET /images/postgres:16/json", map[string]string{"Id": "sha256:pg"})
+	d.json("GET /images/ghcr.io/acme/api:1.0/json", map[string]string{"Id": "sha256:api"})
+	d.handle("POST /containers/create", func(w http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"Id": req.URL.Query().Get("name")})
+	})
+	d.handle("POST /containers/demo-db-1/start", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	d.handle("POST /containers/demo-api-1/start", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	d.json("GET /containers/demo-db-1/json", map[string]any{"State": map[string]any{"Status": "running", "Health": map[string]string{"Status": "healthy"}}})
+
+	composePath := writeCompose(t)
+	var out bytes.Buffer
+	r := &Runtime{Host: d.host, Out: &out}
+	if err := r.Up(context.Background(), composePath, "demo", runtime.UpOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var order []string
+	for _, req := range d.requests {
+		if strings.HasPrefix(req, "POST ") {
+			order = append(order, req)
+		}
+	}
+	want := []string{
+		"POST /networks/create",
+		"POST /volumes/create",
+		"POST /containers/create",
+		"POST /containers/demo-db-1/start",
+		"POST /containers/create",
+		"POST /containers/demo-api-1/start",
+	}
+	if strings.Join(order, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("requests:\n%s\nwant:\n%s", strings.Join(order, "\n"), strings.Join(want, "\n"))
+	}
+	if !strings.Contains(out.String(), "Container demo-api-1  Started") {
+		t.Errorf("expected progress output, got %q", out.String())
+	}
+
+	// The fake keeps the last body per route: the api container's.
+	var cfg ContainerConfig
+	if err := json.Unmarshal(d.bodies["POST /containers/create"], &cfg); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Dir(composePath)
+	if strings.Join(cfg.Cmd, " ") != "sh -c echo $HOME" {
+		t.Errorf("expected $$ to be unescaped: %q", cfg.Cmd)
+	}
+	if strings.Join(cfg.Env, ",") != "DB_URL=postgres://db:5432/app" {
+		t.Errorf("unexpected env: %q", cfg.Env)
+	}
+	if b := cfg.HostConfig.PortBindings["80/tcp"]; len(b) != 1 || b[0].HostPort != "8080" {
+		t.Errorf("unexpected port bindings: %+v", cfg.HostConfig.PortBindings)
+	}
+	wantBinds := []string{filepath.Join(filepath.Dir(dir), "src") + ":/app", filepath.Join(dir, "secrets", "token") + ":/run/secrets/token:ro"}
+	if strings.Join(cfg.HostConfig.Binds, ",") != strings.Join(wantBinds, ",") {
+		t.Errorf("binds %q, want %q", cfg.HostConfig.Binds, wantBinds)
+	}
+	if cfg.HostConfig.NetworkMode != "demo_devx" || cfg.NetworkingConfig.EndpointsConfig["demo_devx"].Aliases[0] != "api" {
+		t.Errorf("unexpected networking: %s %+v", cfg.HostConfig.NetworkMode, cfg.NetworkingConfig)
+	}
+	if cfg.Labels[labelProject] != "demo" || cfg.Labels[labelService] != "api" || cfg.Labels[labelConfigHash] == "" {
+		t.Errorf("unexpected labels: %v", cfg.Labels)
+	}
+}
+
+func TestRuntime_UpReusesUnchangedContainers(t *testing.T) {
+	composePath := writeCompose(t)
+	p, err := loadProject(composePath, "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := p.containerConfig("db", p.file.Services["db"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := newFakeDaemon(t)
+	d.json("GET /networks", []map[string]string{{"Name": "demo_devx"}})
+	d.json("POST /volumes/create", map[string]string{"Name": "demo_pgdata"})
+	d.json("GET /containers/json", []ContainerSummary{{ID: "old", State: "running", ImageID: "sha256:pg", Labels: cfg.Labels}})
+	d.json("GET /images/postgres:16/json", map[string]string{"Id": "sha256:pg"})
+
+	r := &Runtime{Host: d.host, Out: io.Discard}
+	if err := r.Up(context.Background(), composePath, "demo", runtime.UpOptions{Services: []string{"db"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range d.requests {
+		if strings.HasPrefix(req, "POST /containers") || strings.HasPrefix(req, "DELETE ") {
+			t.Errorf("unexpected request for an unchanged container: %s", req)
+		}
+	}
+
+	d.requests = nil
+	d.handle("POST /containers/old/stop", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	d.handle("DELETE /containers/old", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	d.json("POST /containers/create", map[string]string{"Id": "new"})
+	d.handle("POST /containers/new/start", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	if err := r.Up(context.Background(), composePath, "demo", runtime.UpOptions{Services: []string{"db"}, ForceRecreate: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(strings.Join(d.requests, "\n"), "POST /containers/old/stop\nDELETE /containers/old") {
+		t.Errorf("expected the running container to be stopped, then recreated: %v", d.requests)
+	}
+}
+
+func TestRuntime_UpRejectsBuilds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "compose.yaml")
+	if err := os.WriteFile(path, []byte("services:\n  api:\n    build:\n      context: .\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	d := newFakeDaemon(t)
+	err := (&Runtime{Host: d.host}).Up(context.Background(), path, "demo", runtime.UpOptions{})
+	if err == nil || !strings.Contains(err.Error(), "compose plugin") {
+		t.Fatalf("expected a build error, got %v", err)
+	}
+}
+
+func TestRuntime_Run(t *testing.T) {
+	composePath := writeCompose(t)
+	p, err := loadProject(composePath, "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := p.containerConfig("db", p.file.Services["db"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := newFakeDaemon(t)
+	d.json("GET /networks", []map[string]string{{"Name": "demo_devx"}})
+	d.json("POST /volumes/create", map[string]string{"Name": "demo_pgdata"})
+	d.json("GET /containers/json", []ContainerSummary{{ID: "db1", State: "running", ImageID: "sha256:pg", Labels: db.Labels}})
+	d.json("GET /images/postgres:16/json", map[string]string{"Id": "sha256:pg"})
+	d.json("GET /images/ghcr.io/acme/api:1.0/json", map[string]string{"Id": "sha256:api"})
+	d.json("GET /containers/db1/json", map[string]any{"State": map[string]any{"Status": "running", "Health": map[string]string{"Status": "healthy"}}})
+	d.handle("POST /containers/create", func(w http.ResponseWriter, req *http.Request) {
+		if name := req.URL.Query().Get("name"); !strings.HasPrefix(name, "demo-api-run-") {
+			t.Errorf("unexpected container name %q", name)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"Id": "run1"})
+	})
+	d.handle("POST /containers/run1/attach", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("stdin") != "" {
+			t.Errorf("expected no stdin, got %s", req.URL.RawQuery)
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+		_, _ = rw.Write(frame(1, "migrated\n"))
+		_, _ = rw.Write(frame(2, "1 warning\n"))
+		_ = rw.Flush()
+	})
+	d.handle("POST /containers/run1/start", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	d.json("POST /containers/run1/wait", map[string]int{"StatusCode": 3})
+	d.handle("DELETE /containers/run1", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("v") != "1" {
+			t.Errorf("expected anonymous volumes to be removed: %s", req.URL.RawQuery)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r := &Runtime{Host: d.host, Out: io.Discard}
+	var stdout, stderr bytes.Buffer
+	code, err := r.Run(context.Background(), composePath, "demo", "api", runtime.ExecOptions{
+		Cmd:    []string{"./migrate", "up"},
+		Env:    []string{"VERBOSE=1"},
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 3 || stdout.String() != "migrated\n" || stderr.String() != "1 warning\n" {
+		t.Fatalf("code %d, stdout %q, stderr %q", code, stdout.String(), stderr.String())
+	}
+
+	got := strings.Join(d.requests, "\n")
+	for _, want := range []string{"GET /containers/db1/json", "POST /containers/run1/start", "DELETE /containers/run1"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s in:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "POST /containers/run1/attach") > strings.Index(got, "POST /containers/run1/start") {
+		t.Errorf("expected to attach before starting:\n%s", got)
+	}
+
+	var cfg ContainerConfig
+	if err := json.Unmarshal(d.bodies["POST /containers/create"], &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(cfg.Cmd, " ") != "./migrate up" || strings.Join(cfg.Env, ",") != "DB_URL=postgres://db:5432/app,VERBOSE=1" {
+		t.Errorf("unexpected command or env: %q %q", cfg.Cmd, cfg.Env)
+	}
+	if cfg.Labels[labelOneoff] != "True" || len(cfg.HostConfig.PortBindings) != 0 || cfg.OpenStdin {
+		t.Errorf("unexpected one-off config: %+v", cfg)
+	}
+}
+
+func TestProjectOrder(t *testing.T) {
+	p, err := loadProject(writeCompose(t), "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		services []string
+		withDeps bool
+		want     string
+	}{
+		{nil, false, "db,api"},
+		{[]string{"api"}, true, "db,api"},
+		{[]string{"api"}, false, "api"},
+	} {
+		got, err := p.order(tc.services, tc.withDeps)
+		if err != nil || strings.Join(got, ",") != tc.want {
+			t.Errorf("order(%v, %v) = %v, %v; want %s", tc.services, tc.withDeps, got, err, tc.want)
+		}
+	}
+	if _, err := p.order([]string{"web"}, true); err == nil {
+		t.Error("expected an error for an unknown service")
+	}
+}
+
+func TestRuntime_StatusAndList(t *testing.T) {
+	d := newFakeDaemon(t)
+	d.json("GET /containers/json", []ContainerSummary{
+		{ID: "a", Names: []string{"/demo-db-1"}, State: "running", Status: "Up 2 minutes (healthy)",
+			Ports:  []Port{{IP: "0.0.0.0", PrivatePort: 5432, PublicPort: 5432, Type: "tcp"}},
+			Labels: map[string]string{labelService: "db", "devx.project": "demo"}},
+		{ID: "b", Names: []string{"/demo-api-1"}, State: "exited", Status: "Exited (1) 3 seconds ago",
+			Labels: map[string]string{labelService: "api", "devx.project": "demo"}},
+	})
+	r := &Runtime{Host: d.host}
+
+	statuses, err := r.Status(context.Background(), "compose.yaml", "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 statuses, got %+v", statuses)
+	}
+	api, db := statuses[0], statuses[1]
+	if api.Name != "api" || api.State != "exited" || api.Health != "" {
+		t.Errorf("unexpected api status: %+v", api)
+	}
+	if db.Health != "healthy" || db.Ports != "0.0.0.0:5432->5432/tcp" || len(db.Publishers) != 1 || db.Publishers[0].PublishedPort != 5432 {
+		t.Errorf("unexpected db status: %+v", db)
+	}
+
+	list, err := r.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Name != "demo-db-1" || list[0].Labels["devx.project"] != "demo" {
+		t.Errorf("unexpected list: %+v", list)
+	}
+}
+
+func TestRuntime_Logs(t *testing.T) {
+	d := newFakeDaemon(t)
+	d.json("GET /containers/json", []ContainerSummary{
+		{ID: "a", State: "running", Labels: map[string]string{labelService: "db", labelNumber: "1"}},
+	})
+	d.handle("GET /containers/a/logs", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("tail") != "5" {
+			t.Errorf("expected tail=5: %s", req.URL.RawQuery)
+		}
+		_, _ = w.Write(frame(Stdout, "2024-01-02T03:04:05Z ready\n2024-01-02T03:04:06Z "))
+		_, _ = w.Write(frame(Stderr, "listening\n"))
+	})
+
+	rc, err := (&Runtime{Host: d.host}).Logs(context.Background(), "compose.yaml", "demo", runtime.LogsOptions{Tail: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	out, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "db-1 | 2024-01-02T03:04:05Z ready\ndb-1 | 2024-01-02T03:04:06Z listening\n"
+	if string(out) != want {
+		t.Fatalf("logs:\n%q\nwant:\n%q", out, want)
+	}
+
+	var stderr bytes.Buffer
+	rc, err = (&Runtime{Host: d.host}).Logs(context.Background(), "compose.yaml", "demo", runtime.LogsOptions{Services: []string{"db"}, Tail: 5, Stderr: &stderr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if out, err = io.ReadAll(rc); err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "db-1 | 2024-01-02T03:04:05Z ready\ndb-1 | 2024-01-02T03:04:06Z \n" || stderr.String() != "db-1 | listening\n" {
+		t.Fatalf("stdout %q, stderr %q", out, stderr.String())
+	}
+}
+
+func TestRuntime_Events(t *testing.T) {
+	d := newFakeDaemon(t)
+	var filters string
+	d.handle("GET /events", func(w http.ResponseWriter, req *http.Request) {
+		filters = req.URL.Query().Get("filters")
+		_, _ = w.Write([]byte(`{"Type":"container","Action":"exec_start: sh","Actor":{"ID":"a"},"timeNano":1700000000000000000}` + "\n"))
+		_, _ = w.Write([]byte(`{"Type":"container","Action":"die","Actor":{"ID":"a","Attributes":{"com.docker.compose.project":"demo","com.docker.compose.service":"db","name":"demo-db-1","exitCode":"1"}},"timeNano":1700000001000000000}` + "\n"))
+	})
+
+	events, errs := (&Runtime{Host: d.host}).Events(context.Background(), "compose.yaml", "demo", runtime.EventsOptions{})
+	var got []runtime.Event
+	for e := range events {
+		got = append(got, e)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Action != runtime.EventDie || got[0].Service != "db" || got[0].ExitCode == nil || *got[0].ExitCode != 1 {
+		t.Fatalf("unexpected events: %+v", got)
+	}
+	if filters != `{"label":["devx.project","com.docker.compose.project=demo"]}` {
+		t.Errorf("unexpected filters: %s", filters)
+	}
+}
+
+func TestRuntime_DownRemovesProject(t *testing.T) {
+	d := newFakeDaemon(t)
+	d.json("GET /containers/json", []ContainerSummary{{ID: "a", Names: []string{"/demo-db-1"}, State: "running", Labels: map[string]string{labelService: "db"}}})
+	for _, route := range []string{"POST /containers/a/stop", "DELETE /containers/a", "DELETE /networks/demo_devx", "DELETE /volumes/demo_pgdata"} {
+		d.handle(route, func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	}
+
+	r := &Runtime{Host: d.host, Out: io.Discard}
+	if err := r.Down(context.Background(), writeCompose(t), "demo", runtime.DownOptions{Volumes: true}); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(d.requests, "\n")
+	if !strings.Contains(got, "POST /containers/a/stop\nDELETE /containers/a") {
+		t.Errorf("expected the running container to be stopped before it is removed:\n%s", got)
+	}
+	for _, want := range []string{"DELETE /containers/a", "DELETE /networks/demo_devx", "DELETE /volumes/demo_pgdata"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s in:\n%s", want, got)
+		}
+	}
+}
+
+func TestWriteArchive(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "a.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeArchive(&buf, src, "app"); err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(&buf)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	if strings.Join(names, ",") != "app/,app/sub/,app/sub/a.txt" {
+		t.Errorf("unexpected entries: %v", names)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package engine
+
+import "errors"
+
+func makeRaw(uintptr) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
+
+func terminalSize(uintptr) (int, int, bool) {
+	return 0, 0, false
+}
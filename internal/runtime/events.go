@@ -0,0 +1,183 @@
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Container lifecycle events reported by Events.
+const (
+	EventStart   = "start"
+	EventDie     = "die"
+	EventHealth  = "health_status"
+	EventOOM     = "oom"
+	EventRestart = "restart"
+)
+
+// Event is a container lifecycle event, the same whichever runtime
+// reported it.
+type Event struct {
+	Time time.Time `json:"time"`
+	// Project is the compose project the container belongs to.
+	Project   string `json:"project"`
+	Service   string `json:"service"`
+	Container string `json:"container"`
+	// Action is one of EventStart, EventDie, EventHealth, EventOOM and
+	// EventRestart.
+	Action string `json:"action"`
+	// Health is healthy, unhealthy or starting, for health_status events.
+	Health string `json:"health,omitempty"`
+	// ExitCode is the container's exit code, for die events.
+	ExitCode *int `json:"exitCode,omitempty"`
+}
+
+type EventsOptions struct {
+	// Since replays events from this time, as a duration ("10m") or a
+	// timestamp; empty means only new events.
+	Since string
+}
+
+// Labels compose and devx set on every container, used to filter events.
+const (
+	labelProject     = "devx.project"
+	labelComposeProj = "com.docker.compose.project"
+	labelService     = "com.docker.compose.service"
+)
+
+// EventFilters returns the labels, as "key" or "key=value", that select the
+// devx containers of a compose project.
+func EventFilters(projectName string) []string {
+	return []string{labelProject, labelComposeProj + "=" + projectName}
+}
+
+// NormalizeDockerEvent turns a Docker container event, as the Engine API
+// and `docker events` report it, into an Event. It reports false for
+// actions other than the lifecycle events devx streams.
+func NormalizeDockerEvent(action string, id string, attrs map[string]string, timeNano int64) (Event, bool) {
+	action, detail, _ := strings.Cut(action, ":")
+	e := Event{
+		Time:      time.Unix(0, timeNano).UTC(),
+		Project:   attrs[labelComposeProj],
+		Service:   attrs[labelService],
+		Container: attrs["name"],
+		Action:    action,
+	}
+	if e.Container == "" {
+		e.Container = shortID(id)
+	}
+	switch action {
+	case EventStart, EventOOM, EventRestart:
+	case EventDie:
+		if code, err := strconv.Atoi(attrs["exitCode"]); err == nil {
+			e.ExitCode = &code
+		}
+	case EventHealth:
+		e.Health = strings.TrimSpace(detail)
+	default:
+		return Event{}, false
+	}
+	return e, true
+}
+
+type dockerEvent struct {
+	Type   string
+	Action string
+	Actor  struct {
+		ID         string
+		Attributes map[string]string
+	}
+	TimeNano int64 `json:"timeNano"`
+}
+
+// ParseDockerEvent decodes one line of `docker events --format '{{json .}}'`.
+// It reports false for events that are not container lifecycle events.
+func ParseDockerEvent(line []byte) (Event, bool, error) {
+	var d dockerEvent
+	if err := json.Unmarshal(line, &d); err != nil {
+		return Event{}, false, err
+	}
+	if d.Type != "" && d.Type != "container" {
+		return Event{}, false, nil
+	}
+	e, ok := NormalizeDockerEvent(d.Action, d.Actor.ID, d.Actor.Attributes, d.TimeNano)
+	return e, ok, nil
+}
+
+// CommandEvents runs cmd, an events command printing one event per line,
+// and sends each line parse accepts until ctx is cancelled or cmd exits.
+func CommandEvents(ctx context.Context, cmd *exec.Cmd, parse func(line []byte) (Event, bool, error)) (<-chan Event, <-chan error) {
+	events := make(chan Event)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(events)
+		errs <- scanCommand(ctx, cmd, func(line []byte) error {
+			e, ok, err := parse(line)
+			if err != nil || !ok {
+				return err
+			}
+			select {
+			case events <- e:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return events, errs
+}
+
+func scanCommand(ctx context.Context, cmd *exec.Cmd, fn func(line []byte) error) error {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	var scanErr error
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if scanErr = fn(line); scanErr != nil {
+			break
+		}
+	}
+	if scanErr != nil {
+		_ = cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case scanErr != nil:
+		return scanErr
+	case waitErr != nil:
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %s", waitErr, msg)
+		}
+		return waitErr
+	}
+	return nil
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,78 @@
+package runtime
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestParseDockerEvent(t *testing.T) {
+	attrs := `"Attributes":{"com.docker.compose.project":"demo","com.docker.compose.service":"db","devx.project":"demo","name":"demo-db-1"`
+	for _, tc := range []struct {
+		line   string
+		action string
+		health string
+		exit   int
+	}{
+		{`{"Type":"container","Action":"start","Actor":{"ID":"4c1a7f0e2b9d5e6f",` + attrs + `}},"timeNano":1700000000000000000}`, EventStart, "", -1},
+		{`{"Type":"container","Action":"die","Actor":{"ID":"4c1a7f0e2b9d5e6f",` + attrs + `,"exitCode":"137"}},"timeNano":1700000000000000000}`, EventDie, "", 137},
+		{`{"Type":"container","Action":"health_status: unhealthy","Actor":{"ID":"4c1a7f0e2b9d5e6f",` + attrs + `}},"timeNano":1700000000000000000}`, EventHealth, "unhealthy", -1},
+		{`{"Type":"container","Action":"oom","Actor":{"ID":"4c1a7f0e2b9d5e6f",` + attrs + `}},"timeNano":1700000000000000000}`, EventOOM, "", -1},
+	} {
+		e, ok, err := ParseDockerEvent([]byte(tc.line))
+		if err != nil || !ok {
+			t.Fatalf("ParseDockerEvent(%s) = %v, %v", tc.line, ok, err)
+		}
+		if e.Action != tc.action || e.Health != tc.health || e.Project != "demo" || e.Service != "db" || e.Container != "demo-db-1" {
+			t.Errorf("unexpected event: %+v", e)
+		}
+		if !e.Time.Equal(time.Unix(1700000000, 0)) {
+			t.Errorf("unexpected time %v", e.Time)
+		}
+		if (tc.exit < 0) != (e.ExitCode == nil) || (e.ExitCode != nil && *e.ExitCode != tc.exit) {
+			t.Errorf("%s: unexpected exit code %v", tc.action, e.ExitCode)
+		}
+	}
+
+	for _, line := range []string{
+		`{"Type":"container","Action":"exec_start: sh","Actor":{"ID":"abc"}}`,
+		`{"Type":"network","Action":"connect","Actor":{"ID":"abc"}}`,
+	} {
+		if _, ok, err := ParseDockerEvent([]byte(line)); ok || err != nil {
+			t.Errorf("expected %s to be skipped, got %v, %v", line, ok, err)
+		}
+	}
+}
+
+func TestCommandEvents(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	script := `echo '{"Type":"container","Action":"create","Actor":{"ID":"abc"}}'
+echo '{"Type":"container","Action":"start","Actor":{"ID":"abc","Attributes":{"name":"demo-api-1"}}}'
+echo 'not json'
+echo '{"Type":"container","Action":"start","Actor":{"ID":"def"}}'`
+	ctx := context.Background()
+	events, errs := CommandEvents(ctx, exec.CommandContext(ctx, "sh", "-c", script), ParseDockerEvent)
+
+	var got []Event
+	for e := range events {
+		got = append(got, e)
+	}
+	if len(got) != 1 || got[0].Container != "demo-api-1" {
+		t.Fatalf("expected only the first start event, got %+v", got)
+	}
+	if err := <-errs; err == nil {
+		t.Fatal("expected the malformed line to end the stream with an error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, errs = CommandEvents(ctx, exec.CommandContext(ctx, "sh", "-c", "exec sleep 10"), ParseDockerEvent)
+	time.AfterFunc(50*time.Millisecond, cancel)
+	for range events {
+	}
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package runtime
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+// ComposeExecArgs returns the `compose exec` flags for opts, without the
+// service and command. `compose run` takes the same ones.
+func ComposeExecArgs(opts ExecOptions) []string {
+	var args []string
+	if !opts.TTY {
+		args = append(args, "-T")
+	}
+	if opts.User != "" {
+		args = append(args, "--user", opts.User)
+	}
+	if opts.Workdir != "" {
+		args = append(args, "--workdir", opts.Workdir)
+	}
+	for _, env := range opts.Env {
+		args = append(args, "--env", env)
+	}
+	return args
+}
+
+// forwardedSignals are relayed to commands started by RunCommand.
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}
+
+// RunCommand runs cmd with opts' streams, relaying interrupt, terminate and
+// hangup signals devx receives to it, and returns its exit code.
+func RunCommand(cmd *exec.Cmd, opts ExecOptions) (int, error) {
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = opts.Stdin, opts.Stdout, opts.Stderr
+	if err := cmd.Start(); err != nil {
+		return 1, err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				_ = cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+	err := cmd.Wait()
+	signal.Stop(signals)
+	close(done)
+
+	if exit, ok := err.(*exec.ExitError); ok {
+		return exit.ExitCode(), nil
+	}
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
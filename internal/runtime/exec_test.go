@@ -0,0 +1,39 @@
+package runtime
+
+import (
+	"bytes"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComposeExecArgs(t *testing.T) {
+	got := ComposeExecArgs(ExecOptions{User: "root", Workdir: "/app", Env: []string{"A=1", "B=2"}})
+	want := []string{"-T", "--user", "root", "--workdir", "/app", "--env", "A=1", "--env", "B=2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := ComposeExecArgs(ExecOptions{TTY: true}); len(got) != 0 {
+		t.Fatalf("expected no flags with a terminal, got %v", got)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	var stdout, stderr bytes.Buffer
+	opts := ExecOptions{Stdin: strings.NewReader("hello\n"), Stdout: &stdout, Stderr: &stderr}
+	code, err := RunCommand(exec.Command("sh", "-c", "cat; echo oops >&2; exit 3"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 3 || stdout.String() != "hello\n" || stderr.String() != "oops\n" {
+		t.Fatalf("code %d, stdout %q, stderr %q", code, stdout.String(), stderr.String())
+	}
+
+	if _, err := RunCommand(exec.Command("devx-no-such-binary"), ExecOptions{}); err == nil {
+		t.Fatal("expected an error for a command that cannot start")
+	}
+}
@@ -1 +1,101 @@
 package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Config selects and configures a container runtime.
+type Config struct {
+	// Name is the runtime to use; empty means the first one detected.
+	Name string
+	// Binary is the runtime's CLI when it is not the one on PATH.
+	Binary string
+	// Context is the Docker context to use.
+	Context string
+	// Host is the daemon's address, as in DOCKER_HOST.
+	Host string
+}
+
+// Constructor returns a runtime configured by cfg.
+type Constructor func(cfg Config) Runtime
+
+// Factory creates runtimes by name, and picks the first available one
+// when none is named.
+type Factory struct {
+	names        []string
+	constructors map[string]Constructor
+}
+
+func NewFactory() *Factory {
+	return &Factory{constructors: map[string]Constructor{}}
+}
+
+// Register makes a runtime available under name. Runtimes are detected
+// in the order they are registered.
+func (f *Factory) Register(name string, c Constructor) {
+	if _, ok := f.constructors[name]; !ok {
+		f.names = append(f.names, name)
+	}
+	f.constructors[name] = c
+}
+
+// Names returns the registered runtimes in detection order.
+func (f *Factory) Names() []string {
+	return append([]string(nil), f.names...)
+}
+
+// New returns the runtime cfg names, without checking that it is
+// available.
+func (f *Factory) New(cfg Config) (Runtime, error) {
+	c, ok := f.constructors[cfg.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown container runtime '%s': use %s", cfg.Name, strings.Join(f.names, ", "))
+	}
+	return c(cfg), nil
+}
+
+// Selection is the runtime a Factory chose and why.
+type Selection struct {
+	Runtime Runtime
+	// Reason says where the choice came from, e.g. "--runtime" or
+	// "detected; docker is not available".
+	Reason string
+}
+
+// Select returns the runtime cfg names, checking that it is available;
+// reason says where the name came from and is reported when it is not.
+// Without a name, it returns the first registered runtime that is.
+func (f *Factory) Select(ctx context.Context, cfg Config, reason string) (Selection, error) {
+	if cfg.Name != "" {
+		rt, err := f.New(cfg)
+		if err != nil {
+			return Selection{}, fmt.Errorf("%w (from %s)", err, reason)
+		}
+		if ok, err := rt.Detect(ctx); !ok {
+			msg := fmt.Sprintf("container runtime '%s' (from %s) is not available", cfg.Name, reason)
+			if err != nil {
+				msg += ": " + err.Error()
+			}
+			return Selection{}, errors.New(msg)
+		}
+		return Selection{Runtime: rt, Reason: reason}, nil
+	}
+
+	var missing []string
+	for _, name := range f.names {
+		cfg.Name = name
+		rt := f.constructors[name](cfg)
+		if ok, _ := rt.Detect(ctx); ok {
+			reason := "detected"
+			if len(missing) > 0 {
+				reason += "; " + strings.Join(missing, ", ") + " not available"
+			}
+			return Selection{Runtime: rt, Reason: reason}, nil
+		}
+		missing = append(missing, name)
+	}
+	return Selection{}, fmt.Errorf("%w: tried %s", ErrNoRuntime, strings.Join(f.names, ", "))
+}
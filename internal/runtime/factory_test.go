@@ -0,0 +1,92 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeRuntime is a runtime that is available or not, recording the
+// configuration it was created with.
+type fakeRuntime struct {
+	cfg       Config
+	available bool
+}
+
+func (f *fakeRuntime) Name() string { return f.cfg.Name }
+
+func (f *fakeRuntime) Detect(context.Context) (bool, error) { return f.available, nil }
+
+func (f *fakeRuntime) Up(context.Context, string, string, UpOptions) error { return nil }
+
+func (f *fakeRuntime) Down(context.Context, string, string, DownOptions) error { return nil }
+
+func (f *fakeRuntime) Stop(context.Context, string, string, []string) error { return nil }
+
+func (f *fakeRuntime) Restart(context.Context, string, string, []string) error { return nil }
+
+func (f *fakeRuntime) Logs(context.Context, string, string, LogsOptions) (io.ReadCloser, error) {
+	return nil, nil
+}
+
+func (f *fakeRuntime) Exec(context.Context, string, string, string, ExecOptions) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeRuntime) Status(context.Context, string, string) ([]ServiceStatus, error) {
+	return nil, nil
+}
+
+func (f *fakeRuntime) Events(context.Context, string, string, EventsOptions) (<-chan Event, <-chan error) {
+	return nil, nil
+}
+
+func newTestFactory(available ...string) *Factory {
+	f := NewFactory()
+	for _, name := range []string{"docker", "podman", "docker-engine"} {
+		ok := false
+		for _, a := range available {
+			ok = ok || a == name
+		}
+		f.Register(name, func(cfg Config) Runtime { return &fakeRuntime{cfg: cfg, available: ok} })
+	}
+	return f
+}
+
+func TestFactory_SelectDetects(t *testing.T) {
+	sel, err := newTestFactory("podman", "docker-engine").Select(context.Background(), Config{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sel.Runtime.Name() != "podman" || sel.Reason != "detected; docker not available" {
+		t.Fatalf("got %s (%s)", sel.Runtime.Name(), sel.Reason)
+	}
+
+	_, err = newTestFactory().Select(context.Background(), Config{}, "")
+	if !errors.Is(err, ErrNoRuntime) || !strings.Contains(err.Error(), "docker, podman, docker-engine") {
+		t.Fatalf("expected ErrNoRuntime naming what was tried, got %v", err)
+	}
+}
+
+func TestFactory_SelectNamed(t *testing.T) {
+	f := newTestFactory("docker", "podman")
+	cfg := Config{Name: "podman", Binary: "/opt/podman/bin/podman"}
+	sel, err := f.Select(context.Background(), cfg, "DEVX_RUNTIME")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sel.Runtime.(*fakeRuntime).cfg; got != cfg || sel.Reason != "DEVX_RUNTIME" {
+		t.Fatalf("got %+v (%s)", got, sel.Reason)
+	}
+
+	_, err = f.Select(context.Background(), Config{Name: "docker-engine"}, "--runtime")
+	if err == nil || err.Error() != "container runtime 'docker-engine' (from --runtime) is not available" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = f.Select(context.Background(), Config{Name: "rkt"}, "--runtime")
+	if err == nil || err.Error() != "unknown container runtime 'rkt': use docker, podman, docker-engine (from --runtime)" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
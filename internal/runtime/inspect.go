@@ -0,0 +1,92 @@
+package runtime
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ContainerState is a container's low-level state as reported by
+// `docker inspect` or `podman inspect`.
+type ContainerState struct {
+	ID           string
+	Name         string
+	Status       string
+	ExitCode     int
+	OOMKilled    bool
+	RestartCount int
+	Error        string
+	StartedAt    string
+	FinishedAt   string
+	// Health is the container healthcheck's status, and HealthLog the
+	// output of its most recent runs, oldest first.
+	Health    string
+	HealthLog []HealthResult
+}
+
+// HealthResult is one run of a container healthcheck.
+type HealthResult struct {
+	Start    string
+	ExitCode int
+	Output   string
+}
+
+type inspectHealth struct {
+	Status string
+	Log    []struct {
+		Start    string
+		ExitCode int
+		Output   string
+	}
+}
+
+type inspectEntry struct {
+	ID           string `json:"Id"`
+	Name         string
+	RestartCount int
+	State        struct {
+		Status     string
+		ExitCode   int
+		OOMKilled  bool
+		Error      string
+		StartedAt  string
+		FinishedAt string
+		Health     *inspectHealth
+		// Podman before 4.3 reported the healthcheck under this key.
+		Healthcheck *inspectHealth
+	}
+}
+
+// ParseInspect decodes the JSON array printed by `docker inspect` and
+// `podman inspect` for one or more containers.
+func ParseInspect(out []byte) ([]ContainerState, error) {
+	var entries []inspectEntry
+	if err := json.Unmarshal(out, &entries); err != nil {
+		return nil, err
+	}
+	states := make([]ContainerState, 0, len(entries))
+	for _, e := range entries {
+		st := ContainerState{
+			ID:           e.ID,
+			Name:         strings.TrimPrefix(e.Name, "/"),
+			Status:       e.State.Status,
+			ExitCode:     e.State.ExitCode,
+			OOMKilled:    e.State.OOMKilled,
+			RestartCount: e.RestartCount,
+			Error:        e.State.Error,
+			StartedAt:    e.State.StartedAt,
+			FinishedAt:   e.State.FinishedAt,
+		}
+		h := e.State.Health
+		if h == nil {
+			h = e.State.Healthcheck
+		}
+		if h != nil {
+			st.Health = h.Status
+			for _, l := range h.Log {
+				st.HealthLog = append(st.HealthLog, HealthResult{Start: l.Start, ExitCode: l.ExitCode, Output: strings.TrimSpace(l.Output)})
+			}
+		}
+		states = append(states, st)
+	}
+	return states, nil
+}
@@ -0,0 +1,48 @@
+package runtime
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInspect(t *testing.T) {
+	out := []byte(`[
+  {
+    "Id": "abc123",
+    "Name": "/my-app-api-1",
+    "RestartCount": 2,
+    "State": {
+      "Status": "exited",
+      "ExitCode": 137,
+      "OOMKilled": true,
+      "Error": "",
+      "StartedAt": "2026-10-17T09:00:00Z",
+      "FinishedAt": "2026-10-17T09:00:05Z",
+      "Health": {
+        "Status": "unhealthy",
+        "Log": [{"Start": "2026-10-17T09:00:04Z", "ExitCode": 1, "Output": "wget: server returned error: HTTP/1.1 503\n"}]
+      }
+    }
+  },
+  {
+    "Id": "def456",
+    "Name": "my-app-db-1",
+    "State": {"Status": "running", "Healthcheck": {"Status": "starting"}}
+  }
+]`)
+	got, err := ParseInspect(out)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	want := []ContainerState{
+		{
+			ID: "abc123", Name: "my-app-api-1", Status: "exited", ExitCode: 137, OOMKilled: true, RestartCount: 2,
+			StartedAt: "2026-10-17T09:00:00Z", FinishedAt: "2026-10-17T09:00:05Z", Health: "unhealthy",
+			HealthLog: []HealthResult{{Start: "2026-10-17T09:00:04Z", ExitCode: 1, Output: "wget: server returned error: HTTP/1.1 503"}},
+		},
+		{ID: "def456", Name: "my-app-db-1", Status: "running", Health: "starting"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Container is one container devx created, in any project on the machine.
+type Container struct {
+	Name   string
+	State  string
+	Labels map[string]string
+}
+
+// Lister is implemented by runtimes that can list every container carrying
+// a devx.project label, across all compose projects.
+type Lister interface {
+	List(ctx context.Context) ([]Container, error)
+}
+
+type listEntry struct {
+	Names  any
+	State  string
+	Status string
+	Labels any
+}
+
+// ParseContainerList decodes `docker ps --format '{{json .}}'` output (one
+// object per line, names and labels as comma-separated strings) and
+// `podman ps --format json` output (a JSON array with a list of names and a
+// map of labels). nerdctl prints docker's format without State, which is
+// then taken from Status.
+func ParseContainerList(out []byte) ([]Container, error) {
+	var entries []listEntry
+	if err := json.Unmarshal(out, &entries); err != nil {
+		entries = nil
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			var entry listEntry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	containers := make([]Container, 0, len(entries))
+	for _, e := range entries {
+		c := Container{State: e.State, Labels: map[string]string{}}
+		if c.State == "" {
+			c.State = StateFromStatus(e.Status)
+		}
+		switch names := e.Names.(type) {
+		case string:
+			c.Name, _, _ = strings.Cut(names, ",")
+		case []any:
+			if len(names) > 0 {
+				c.Name, _ = names[0].(string)
+			}
+		}
+		switch labels := e.Labels.(type) {
+		case string:
+			for _, pair := range strings.Split(labels, ",") {
+				if k, v, ok := strings.Cut(pair, "="); ok {
+					c.Labels[k] = v
+				}
+			}
+		case map[string]any:
+			for k, v := range labels {
+				c.Labels[k], _ = v.(string)
+			}
+		}
+		containers = append(containers, c)
+	}
+	return containers, nil
+}
+
+// StateFromStatus turns a status text such as "Up 5 minutes" or
+// "Exited (0) 2 hours ago" into a container state such as "running" or
+// "exited".
+func StateFromStatus(status string) string {
+	word, _, _ := strings.Cut(strings.TrimSpace(status), " ")
+	switch strings.ToLower(word) {
+	case "":
+		return ""
+	case "up":
+		if strings.Contains(status, "(Paused)") {
+			return "paused"
+		}
+		return "running"
+	default:
+		return strings.ToLower(word)
+	}
+}
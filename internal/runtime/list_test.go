@@ -0,0 +1,53 @@
+package runtime
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseContainerList(t *testing.T) {
+	docker := []byte(`{"Names":"my-app-api-1","State":"running","Labels":"devx.project=my-app,devx.profile=local,devx.service=api"}
+{"Names":"my-app-feature-db-1","State":"exited","Labels":"devx.instance=feature,devx.project=my-app,devx.profile=ci,devx.service=db"}
+`)
+	podman := []byte(`[
+  {"Names": ["my-app-api-1"], "State": "running", "Labels": {"devx.project": "my-app", "devx.profile": "local", "devx.service": "api"}},
+  {"Names": ["my-app-feature-db-1"], "State": "exited", "Labels": {"devx.instance": "feature", "devx.project": "my-app", "devx.profile": "ci", "devx.service": "db"}}
+]`)
+	want := []Container{
+		{Name: "my-app-api-1", State: "running", Labels: map[string]string{"devx.project": "my-app", "devx.profile": "local", "devx.service": "api"}},
+		{Name: "my-app-feature-db-1", State: "exited", Labels: map[string]string{"devx.instance": "feature", "devx.project": "my-app", "devx.profile": "ci", "devx.service": "db"}},
+	}
+
+	nerdctl := []byte(`{"ID":"1a2b","Names":"my-app-api-1","Status":"Up","Labels":"devx.project=my-app,devx.profile=local,devx.service=api"}
+{"ID":"3c4d","Names":"my-app-feature-db-1","Status":"Exited (1) 2 minutes ago","Labels":"devx.instance=feature,devx.project=my-app,devx.profile=ci,devx.service=db"}
+`)
+
+	for name, out := range map[string][]byte{"docker": docker, "podman": podman, "nerdctl": nerdctl} {
+		got, err := ParseContainerList(out)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: got %+v, want %+v", name, got, want)
+		}
+	}
+
+	if got, err := ParseContainerList([]byte("")); err != nil || len(got) != 0 {
+		t.Fatalf("expected no containers, got %v, %v", got, err)
+	}
+}
+
+func TestStateFromStatus(t *testing.T) {
+	for status, want := range map[string]string{
+		"Up 5 minutes":           "running",
+		"Up":                     "running",
+		"Up 2 hours (Paused)":    "paused",
+		"Exited (0) 2 hours ago": "exited",
+		"Created":                "created",
+		"":                       "",
+	} {
+		if got := StateFromStatus(status); got != want {
+			t.Errorf("StateFromStatus(%q) = %q, want %q", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package runtime
+
+import (
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Log streams a LogEntry can come from.
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// LogEntry is one line of a container's output, parsed from Logs.
+type LogEntry struct {
+	// Time is when the container wrote the line; zero when it had no
+	// timestamp.
+	Time time.Time
+	// Container is the name Logs prefixed the line with, e.g. "api-1", and
+	// Service the compose service it belongs to.
+	Container string
+	Service   string
+	// Stream is StreamStdout or StreamStderr when the caller knows which
+	// one the line came from.
+	Stream  string
+	Message string
+}
+
+// ParseLogLine parses a line of Logs output, "<container> | <timestamp>
+// <message>" as compose logs --timestamps prints it. A line without the
+// prefix, such as the runtime's own messages, is all Message.
+func ParseLogLine(line, projectName string) LogEntry {
+	line = strings.TrimRight(line, "\r\n")
+	i := strings.IndexByte(line, '|')
+	if i <= 0 {
+		return LogEntry{Message: line}
+	}
+	container := strings.TrimSpace(line[:i])
+	if container == "" || strings.ContainsAny(container, " \t") {
+		return LogEntry{Message: line}
+	}
+	e := LogEntry{Container: container, Service: logService(container, projectName)}
+
+	rest := strings.TrimPrefix(line[i+1:], " ")
+	stamp, msg, _ := strings.Cut(rest, " ")
+	if t, err := time.Parse(time.RFC3339Nano, stamp); err == nil {
+		e.Time, e.Message = t, msg
+	} else {
+		e.Message = rest
+	}
+	return e
+}
+
+// logService recovers the service from a container name as compose and
+// podman-compose print it: "api-1", "[api]", "demo-api-1" or "demo_api_1".
+func logService(container, projectName string) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(container, "["), "]")
+	if projectName != "" {
+		for _, sep := range []string{"-", "_"} {
+			if rest, ok := strings.CutPrefix(name, projectName+sep); ok && rest != "" {
+				name = rest
+				break
+			}
+		}
+	}
+	if i := strings.LastIndexAny(name, "-_"); i > 0 && strings.Trim(name[i+1:], "0123456789") == "" && i < len(name)-1 {
+		name = name[:i]
+	}
+	return name
+}
+
+// CommandLogs starts cmd, a logs command, and returns its output. What it
+// writes to stderr goes to stderr, or into the output when that is nil.
+// The reader waits for cmd once the output ends, so by then all of its
+// stderr has been written.
+func CommandLogs(cmd *exec.Cmd, stderr io.Writer) (io.ReadCloser, error) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = stderr
+	if stderr == nil {
+		cmd.Stderr = cmd.Stdout
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &commandReader{cmd: cmd, rc: stdout}, nil
+}
+
+type commandReader struct {
+	cmd    *exec.Cmd
+	rc     io.ReadCloser
+	waited bool
+}
+
+func (c *commandReader) Read(p []byte) (int, error) {
+	n, err := c.rc.Read(p)
+	if err == io.EOF && !c.waited {
+		c.waited = true
+		_ = c.cmd.Wait()
+	}
+	return n, err
+}
+
+func (c *commandReader) Close() error {
+	if c.waited {
+		return nil
+	}
+	c.waited = true
+	_ = c.cmd.Process.Kill()
+	_ = c.cmd.Wait()
+	return nil
+}
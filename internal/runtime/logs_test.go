@@ -0,0 +1,59 @@
+package runtime
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestParseLogLine(t *testing.T) {
+	stamp := time.Date(2024, 1, 2, 3, 4, 5, 123000000, time.UTC)
+	for _, tc := range []struct {
+		line string
+		want LogEntry
+	}{
+		{"api-1  | 2024-01-02T03:04:05.123Z listening on :8080", LogEntry{Time: stamp, Container: "api-1", Service: "api", Message: "listening on :8080"}},
+		{"demo-db-1 |2024-01-02T03:04:05.123Z ready", LogEntry{Time: stamp, Container: "demo-db-1", Service: "db", Message: "ready"}},
+		{"demo_my_worker_2 | 2024-01-02T03:04:05.123Z a | b", LogEntry{Time: stamp, Container: "demo_my_worker_2", Service: "my_worker", Message: "a | b"}},
+		{"[cache] | no timestamp here", LogEntry{Container: "[cache]", Service: "cache", Message: "no timestamp here"}},
+		{"api-1  | ", LogEntry{Container: "api-1", Service: "api"}},
+		{"no such service: web", LogEntry{Message: "no such service: web"}},
+		{"Error response from daemon | details", LogEntry{Message: "Error response from daemon | details"}},
+	} {
+		if got := ParseLogLine(tc.line, "demo"); got != tc.want {
+			t.Errorf("ParseLogLine(%q) = %+v, want %+v", tc.line, got, tc.want)
+		}
+	}
+}
+
+func TestCommandLogs(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	var stderr bytes.Buffer
+	rc, err := CommandLogs(exec.Command("sh", "-c", "echo out; echo err >&2; sleep 0.1; echo late >&2"), &stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "out\n" || stderr.String() != "err\nlate\n" {
+		t.Fatalf("stdout %q, stderr %q", out, stderr.String())
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err = CommandLogs(exec.Command("sh", "-c", "echo out; echo err >&2"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if out, _ := io.ReadAll(rc); string(out) != "out\nerr\n" && string(out) != "err\nout\n" {
+		t.Fatalf("expected both streams in the output, got %q", out)
+	}
+}
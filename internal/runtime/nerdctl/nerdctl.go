@@ -0,0 +1,399 @@
+// Package nerdctl runs devx environments with nerdctl and its built-in
+// compose, for containerd hosts such as Rancher Desktop and Lima that have
+// neither docker nor podman.
+package nerdctl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dever-labs/devx/internal/runtime"
+)
+
+type Runtime struct {
+	Binary string
+}
+
+func New() *Runtime {
+	return &Runtime{Binary: "nerdctl"}
+}
+
+func (r *Runtime) Name() string {
+	return "nerdctl"
+}
+
+// Detect checks that nerdctl can reach containerd, not just that the CLI
+// is installed.
+func (r *Runtime) Detect(ctx context.Context) (bool, error) {
+	cmd := exec.CommandContext(ctx, r.Binary, "info", "--format", "{{.ServerVersion}}")
+	out, err := cmd.Output()
+	if err != nil {
+		return false, nil
+	}
+	return strings.TrimSpace(string(out)) != "", nil
+}
+
+// Up starts the services. nerdctl compose has no --no-deps, so
+// opts.NoDeps is ignored and dependencies start along with the services;
+// when devx starts waves, earlier waves have already started them.
+func (r *Runtime) Up(ctx context.Context, composePath string, projectName string, opts runtime.UpOptions) error {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "up", "-d"}
+	if opts.Build {
+		args = append(args, "--build")
+	}
+	if opts.Pull {
+		args = append(args, "--pull", "always")
+	}
+	if opts.ForceRecreate {
+		args = append(args, "--force-recreate")
+	}
+	args = append(args, opts.Services...)
+	return run(ctx, r.Binary, args...)
+}
+
+func (r *Runtime) Down(ctx context.Context, composePath string, projectName string, opts runtime.DownOptions) error {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "down"}
+	if len(opts.Services) > 0 {
+		args = []string{"compose", "-f", composePath, "-p", projectName, "rm", "--stop", "--force"}
+	}
+	if opts.Volumes {
+		args = append(args, "--volumes")
+	}
+	args = append(args, opts.Services...)
+	return run(ctx, r.Binary, args...)
+}
+
+func (r *Runtime) Stop(ctx context.Context, composePath string, projectName string, services []string) error {
+	args := append([]string{"compose", "-f", composePath, "-p", projectName, "stop"}, services...)
+	return run(ctx, r.Binary, args...)
+}
+
+func (r *Runtime) Restart(ctx context.Context, composePath string, projectName string, services []string) error {
+	args := append([]string{"compose", "-f", composePath, "-p", projectName, "restart"}, services...)
+	return run(ctx, r.Binary, args...)
+}
+
+func (r *Runtime) Logs(ctx context.Context, composePath string, projectName string, opts runtime.LogsOptions) (io.ReadCloser, error) {
+	if opts.Since != "" {
+		return nil, errors.New("nerdctl compose logs does not support --since")
+	}
+	args := []string{"compose", "-f", composePath, "-p", projectName, "logs", "--timestamps"}
+	if opts.Follow {
+		args = append(args, "--follow")
+	}
+	if opts.Tail > 0 {
+		args = append(args, "--tail", strconv.Itoa(opts.Tail))
+	}
+	args = append(args, opts.Services...)
+
+	return runtime.CommandLogs(exec.CommandContext(ctx, r.Binary, args...), opts.Stderr)
+}
+
+func (r *Runtime) Exec(ctx context.Context, composePath string, projectName string, service string, opts runtime.ExecOptions) (int, error) {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "exec"}
+	args = append(args, runtime.ComposeExecArgs(opts)...)
+	args = append(args, service)
+	args = append(args, opts.Cmd...)
+	return runtime.RunCommand(exec.CommandContext(ctx, r.Binary, args...), opts)
+}
+
+func (r *Runtime) Run(ctx context.Context, composePath string, projectName string, service string, opts runtime.ExecOptions) (int, error) {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "run", "--rm"}
+	args = append(args, runtime.ComposeExecArgs(opts)...)
+	args = append(args, service)
+	args = append(args, opts.Cmd...)
+	return runtime.RunCommand(exec.CommandContext(ctx, r.Binary, args...), opts)
+}
+
+func (r *Runtime) Status(ctx context.Context, composePath string, projectName string) ([]runtime.ServiceStatus, error) {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "ps", "--format", "json"}
+	out, err := exec.CommandContext(ctx, r.Binary, args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseStatus(out)
+}
+
+type psEntry struct {
+	Service    string
+	State      string
+	Status     string
+	Health     string
+	Publishers []runtime.Publisher
+	// Ports is the docker-style summary older releases print in place of
+	// Publishers, e.g. "0.0.0.0:8080->80/tcp".
+	Ports string
+}
+
+// parseStatus decodes `nerdctl compose ps --format json`, which prints a
+// JSON array, or one object per line in some releases. Releases without
+// State or Publishers have Status and Ports, which are parsed instead.
+func parseStatus(out []byte) ([]runtime.ServiceStatus, error) {
+	var entries []psEntry
+	if err := json.Unmarshal(out, &entries); err != nil {
+		entries = nil
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			var entry psEntry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	results := make([]runtime.ServiceStatus, 0, len(entries))
+	for _, e := range entries {
+		st := runtime.ServiceStatus{
+			Name:       e.Service,
+			State:      e.State,
+			Health:     e.Health,
+			Publishers: e.Publishers,
+		}
+		if st.State == "" {
+			st.State = runtime.StateFromStatus(e.Status)
+		}
+		if st.Publishers == nil && e.Ports != "" {
+			st.Publishers = parsePorts(e.Ports)
+		}
+		st.Ports = describePublishers(st.Publishers)
+		results = append(results, st)
+	}
+	return results, nil
+}
+
+// parsePorts reads a ports summary such as
+// "0.0.0.0:8080->80/tcp, 0.0.0.0:5353->53/udp".
+func parsePorts(s string) []runtime.Publisher {
+	var out []runtime.Publisher
+	for _, part := range strings.Split(s, ",") {
+		host, target, ok := strings.Cut(strings.TrimSpace(part), "->")
+		if !ok {
+			continue
+		}
+		port, protocol, _ := strings.Cut(target, "/")
+		i := strings.LastIndex(host, ":")
+		if i < 0 {
+			continue
+		}
+		p := runtime.Publisher{URL: host[:i], Protocol: protocol}
+		p.PublishedPort, _ = strconv.Atoi(host[i+1:])
+		p.TargetPort, _ = strconv.Atoi(port)
+		if p.Protocol == "" {
+			p.Protocol = "tcp"
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func describePublishers(publishers []runtime.Publisher) string {
+	var parts []string
+	for _, p := range publishers {
+		if p.PublishedPort == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d->%d/%s", p.URL, p.PublishedPort, p.TargetPort, p.Protocol))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Copy copies src on the host to dst inside service's container.
+func (r *Runtime) Copy(ctx context.Context, composePath string, projectName string, service string, src string, dst string) error {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "cp", src, service + ":" + dst}
+	out, err := exec.CommandContext(ctx, r.Binary, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Inspect reports the state of every container of service, including
+// stopped ones.
+func (r *Runtime) Inspect(ctx context.Context, composePath string, projectName string, service string) ([]runtime.ContainerState, error) {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "ps", "--all", "--quiet", service}
+	out, err := exec.CommandContext(ctx, r.Binary, args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	ids := strings.Fields(string(out))
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	out, err = exec.CommandContext(ctx, r.Binary, append([]string{"inspect"}, ids...)...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return runtime.ParseInspect(out)
+}
+
+// List reports every container with a devx.project label, in any project.
+func (r *Runtime) List(ctx context.Context) ([]runtime.Container, error) {
+	args := []string{"ps", "--all", "--filter", "label=devx.project", "--format", "{{json .}}"}
+	out, err := exec.CommandContext(ctx, r.Binary, args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return runtime.ParseContainerList(out)
+}
+
+// Events streams containerd's task events for the project's devx
+// containers. containerd reports no health checks and no restarts, and
+// its events carry no labels, so each container is inspected the first
+// time it is seen.
+func (r *Runtime) Events(ctx context.Context, composePath string, projectName string, opts runtime.EventsOptions) (<-chan runtime.Event, <-chan error) {
+	if opts.Since != "" {
+		events := make(chan runtime.Event)
+		errs := make(chan error, 1)
+		close(events)
+		errs <- errors.New("nerdctl events does not support --since")
+		return events, errs
+	}
+	labels := map[string]map[string]string{}
+	lookup := func(id string) map[string]string {
+		if l, ok := labels[id]; ok {
+			return l
+		}
+		out, err := exec.CommandContext(ctx, r.Binary, "inspect", "--format", "{{json .Config.Labels}}", id).Output()
+		var l map[string]string
+		if err == nil {
+			_ = json.Unmarshal(out, &l)
+		}
+		labels[id] = l
+		return l
+	}
+	cmd := exec.CommandContext(ctx, r.Binary, "events", "--format", "{{json .}}")
+	return runtime.CommandEvents(ctx, cmd, func(line []byte) (runtime.Event, bool, error) {
+		return parseEvent(line, projectName, lookup)
+	})
+}
+
+type taskEvent struct {
+	Timestamp time.Time
+	Topic     string
+	// Event is the containerd event, itself encoded as JSON.
+	Event string
+}
+
+var topicActions = map[string]string{
+	"/tasks/start": runtime.EventStart,
+	"/tasks/exit":  runtime.EventDie,
+	"/tasks/oom":   runtime.EventOOM,
+}
+
+// parseEvent decodes one line of `nerdctl events --format '{{json .}}'`,
+// keeping events for the devx containers of projectName. lookup returns a
+// container's labels.
+func parseEvent(line []byte, projectName string, lookup func(id string) map[string]string) (runtime.Event, bool, error) {
+	var t taskEvent
+	if err := json.Unmarshal(line, &t); err != nil {
+		return runtime.Event{}, false, err
+	}
+	action, ok := topicActions[t.Topic]
+	if !ok {
+		return runtime.Event{}, false, nil
+	}
+	var task struct {
+		ContainerID string `json:"container_id"`
+		ID          string `json:"id"`
+		ExitStatus  int    `json:"exit_status"`
+	}
+	if err := json.Unmarshal([]byte(t.Event), &task); err != nil {
+		return runtime.Event{}, false, err
+	}
+	// Exec'd processes exit with their own id; only the container's main
+	// process exiting means it died.
+	if action == runtime.EventDie && task.ID != "" && task.ID != task.ContainerID {
+		return runtime.Event{}, false, nil
+	}
+	labels := lookup(task.ContainerID)
+	if labels["devx.project"] == "" || labels["com.docker.compose.project"] != projectName {
+		return runtime.Event{}, false, nil
+	}
+
+	e := runtime.Event{
+		Time:      t.Timestamp.UTC(),
+		Project:   projectName,
+		Service:   labels["com.docker.compose.service"],
+		Container: labels["nerdctl/name"],
+		Action:    action,
+	}
+	if e.Container == "" {
+		e.Container = task.ContainerID
+		if len(e.Container) > 12 {
+			e.Container = e.Container[:12]
+		}
+	}
+	if action == runtime.EventDie {
+		code := task.ExitStatus
+		e.ExitCode = &code
+	}
+	return e, true, nil
+}
+
+func (r *Runtime) ResolveImageDigest(ctx context.Context, image string) (string, error) {
+	digest, err := resolveRepoDigest(ctx, r.Binary, image)
+	if err == nil {
+		return digest, nil
+	}
+
+	if err := run(ctx, r.Binary, "pull", image); err != nil {
+		return "", err
+	}
+
+	return resolveRepoDigest(ctx, r.Binary, image)
+}
+
+func resolveRepoDigest(ctx context.Context, binary string, image string) (string, error) {
+	cmd := exec.CommandContext(ctx, binary, "image", "inspect", "--mode", "dockercompat", "--format", "{{join .RepoDigests \"\\n\"}}", image)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return parseRepoDigest(out, image)
+}
+
+// parseRepoDigest returns the digest of the first "name@digest" line.
+func parseRepoDigest(out []byte, image string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(line, "@")
+		if len(parts) == 2 {
+			return parts[1], nil
+		}
+	}
+	return "", fmt.Errorf("no digest found for %s", image)
+}
+
+func run(ctx context.Context, binary string, args ...string) error {
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+var _ interface {
+	runtime.Runtime
+	runtime.Inspector
+	runtime.Copier
+	runtime.Runner
+	runtime.DigestResolver
+	runtime.Lister
+} = (*Runtime)(nil)
@@ -0,0 +1,141 @@
+package nerdctl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dever-labs/devx/internal/runtime"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseStatus(t *testing.T) {
+	for _, tc := range []struct {
+		fixture string
+		want    []runtime.ServiceStatus
+	}{
+		{
+			fixture: "ps-array.json",
+			want: []runtime.ServiceStatus{
+				{
+					Name: "db", State: "running", Health: "healthy", Ports: "0.0.0.0:5432->5432/tcp",
+					Publishers: []runtime.Publisher{{URL: "0.0.0.0", TargetPort: 5432, PublishedPort: 5432, Protocol: "tcp"}},
+				},
+				{Name: "worker", State: "exited", Publishers: []runtime.Publisher{}},
+			},
+		},
+		{
+			fixture: "ps-ndjson.json",
+			want: []runtime.ServiceStatus{
+				{
+					Name: "db", State: "running", Health: "starting", Ports: "127.0.0.1:15432->5432/tcp",
+					Publishers: []runtime.Publisher{{URL: "127.0.0.1", TargetPort: 5432, PublishedPort: 15432, Protocol: "tcp"}},
+				},
+				{
+					Name: "api", State: "running", Ports: "0.0.0.0:8080->8080/tcp",
+					Publishers: []runtime.Publisher{
+						{URL: "0.0.0.0", TargetPort: 8080, PublishedPort: 8080, Protocol: "tcp"},
+						{TargetPort: 9090, Protocol: "tcp"},
+					},
+				},
+			},
+		},
+		{
+			fixture: "ps-legacy.json",
+			want: []runtime.ServiceStatus{
+				{
+					Name: "db", State: "running", Ports: "0.0.0.0:5432->5432/tcp",
+					Publishers: []runtime.Publisher{{URL: "0.0.0.0", TargetPort: 5432, PublishedPort: 5432, Protocol: "tcp"}},
+				},
+				{
+					Name: "dns", State: "running", Ports: "0.0.0.0:8080->80/tcp, 127.0.0.1:5353->53/udp",
+					Publishers: []runtime.Publisher{
+						{URL: "0.0.0.0", TargetPort: 80, PublishedPort: 8080, Protocol: "tcp"},
+						{URL: "127.0.0.1", TargetPort: 53, PublishedPort: 5353, Protocol: "udp"},
+					},
+				},
+				{Name: "worker", State: "exited"},
+			},
+		},
+	} {
+		t.Run(tc.fixture, func(t *testing.T) {
+			got, err := parseStatus(readFixture(t, tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseStatus_Empty(t *testing.T) {
+	for _, out := range []string{"", "[]", "\n"} {
+		got, err := parseStatus([]byte(out))
+		if err != nil || len(got) != 0 {
+			t.Errorf("parseStatus(%q) = %+v, %v", out, got, err)
+		}
+	}
+	if _, err := parseStatus([]byte("not json")); err == nil {
+		t.Error("expected an error for output that is not JSON")
+	}
+}
+
+func TestParseRepoDigest(t *testing.T) {
+	out := []byte("\ndocker.io/library/postgres@sha256:4b7c1a\ndocker.io/library/postgres@sha256:ffff\n")
+	digest, err := parseRepoDigest(out, "postgres:16")
+	if err != nil || digest != "sha256:4b7c1a" {
+		t.Fatalf("got %q, %v", digest, err)
+	}
+	if _, err := parseRepoDigest([]byte("\n"), "app:dev"); err == nil {
+		t.Error("expected an error for a locally built image")
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	labels := map[string]map[string]string{
+		"4c1a7f0e2b9d5e6f": {"devx.project": "demo", "com.docker.compose.project": "demo", "com.docker.compose.service": "db", "nerdctl/name": "demo-db-1"},
+		"0a0b0c0d0e0f1a1b": {"devx.project": "other", "com.docker.compose.project": "other", "com.docker.compose.service": "db"},
+	}
+	lookups := map[string]int{}
+	lookup := func(id string) map[string]string {
+		lookups[id]++
+		return labels[id]
+	}
+
+	var got []runtime.Event
+	for _, line := range bytes.Split(bytes.TrimSpace(readFixture(t, "events.ndjson")), []byte("\n")) {
+		e, ok, err := parseEvent(line, "demo", lookup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			got = append(got, e)
+		}
+	}
+
+	exit := 137
+	want := []runtime.Event{
+		{Time: time.Date(2023, 11, 14, 22, 13, 21, 0, time.UTC), Project: "demo", Service: "db", Container: "demo-db-1", Action: runtime.EventStart},
+		{Time: time.Date(2023, 11, 14, 22, 13, 24, 0, time.UTC), Project: "demo", Service: "db", Container: "demo-db-1", Action: runtime.EventOOM},
+		{Time: time.Date(2023, 11, 14, 22, 13, 25, 0, time.UTC), Project: "demo", Service: "db", Container: "demo-db-1", Action: runtime.EventDie, ExitCode: &exit},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+	if lookups["0a0b0c0d0e0f1a1b"] != 1 {
+		t.Errorf("expected the other project's container to be looked up, got %v", lookups)
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dever-labs/devx/internal/runtime"
 )
@@ -43,14 +45,35 @@ func (r *Runtime) Up(ctx context.Context, composePath string, projectName string
 	if opts.Pull {
 		args = append(args, "--pull", "always")
 	}
+	if opts.NoDeps {
+		args = append(args, "--no-deps")
+	}
+	if opts.ForceRecreate {
+		args = append(args, "--force-recreate")
+	}
+	args = append(args, opts.Services...)
 	return run(ctx, r.Binary, args...)
 }
 
-func (r *Runtime) Down(ctx context.Context, composePath string, projectName string, removeVolumes bool) error {
+func (r *Runtime) Down(ctx context.Context, composePath string, projectName string, opts runtime.DownOptions) error {
 	args := []string{"compose", "-f", composePath, "-p", projectName, "down"}
-	if removeVolumes {
+	if len(opts.Services) > 0 {
+		args = []string{"compose", "-f", composePath, "-p", projectName, "rm", "--stop", "--force"}
+	}
+	if opts.Volumes {
 		args = append(args, "--volumes")
 	}
+	args = append(args, opts.Services...)
+	return run(ctx, r.Binary, args...)
+}
+
+func (r *Runtime) Stop(ctx context.Context, composePath string, projectName string, services []string) error {
+	args := append([]string{"compose", "-f", composePath, "-p", projectName, "stop"}, services...)
+	return run(ctx, r.Binary, args...)
+}
+
+func (r *Runtime) Restart(ctx context.Context, composePath string, projectName string, services []string) error {
+	args := append([]string{"compose", "-f", composePath, "-p", projectName, "restart"}, services...)
 	return run(ctx, r.Binary, args...)
 }
 
@@ -62,34 +85,28 @@ func (r *Runtime) Logs(ctx context.Context, composePath string, projectName stri
 	if opts.Since != "" {
 		args = append(args, "--since", opts.Since)
 	}
-	if opts.Service != "" {
-		args = append(args, opts.Service)
+	if opts.Tail > 0 {
+		args = append(args, "--tail", strconv.Itoa(opts.Tail))
 	}
+	args = append(args, opts.Services...)
 
-	cmd := exec.CommandContext(ctx, r.Binary, args...)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
-	}
-	cmd.Stderr = cmd.Stdout
-	if err := cmd.Start(); err != nil {
-		return nil, err
-	}
+	return runtime.CommandLogs(exec.CommandContext(ctx, r.Binary, args...), opts.Stderr)
+}
 
-	return newCommandReader(cmd, stdout), nil
+func (r *Runtime) Exec(ctx context.Context, composePath string, projectName string, service string, opts runtime.ExecOptions) (int, error) {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "exec"}
+	args = append(args, runtime.ComposeExecArgs(opts)...)
+	args = append(args, service)
+	args = append(args, opts.Cmd...)
+	return runtime.RunCommand(exec.CommandContext(ctx, r.Binary, args...), opts)
 }
 
-func (r *Runtime) Exec(ctx context.Context, composePath string, projectName string, service string, cmdArgs []string) (int, error) {
-	args := []string{"compose", "-f", composePath, "-p", projectName, "exec", "-T", service}
-	args = append(args, cmdArgs...)
-	cmd := exec.CommandContext(ctx, r.Binary, args...)
-	if err := cmd.Run(); err != nil {
-		if exit, ok := err.(*exec.ExitError); ok {
-			return exit.ExitCode(), nil
-		}
-		return 1, err
-	}
-	return 0, nil
+func (r *Runtime) Run(ctx context.Context, composePath string, projectName string, service string, opts runtime.ExecOptions) (int, error) {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "run", "--rm"}
+	args = append(args, runtime.ComposeExecArgs(opts)...)
+	args = append(args, service)
+	args = append(args, opts.Cmd...)
+	return runtime.RunCommand(exec.CommandContext(ctx, r.Binary, args...), opts)
 }
 
 func (r *Runtime) Status(ctx context.Context, composePath string, projectName string) ([]runtime.ServiceStatus, error) {
@@ -142,6 +159,114 @@ func (r *Runtime) Status(ctx context.Context, composePath string, projectName st
 	return results, nil
 }
 
+// Copy copies src on the host to dst inside service's container.
+func (r *Runtime) Copy(ctx context.Context, composePath string, projectName string, service string, src string, dst string) error {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "cp", src, service + ":" + dst}
+	out, err := exec.CommandContext(ctx, r.Binary, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Inspect reports the state of every container of service, including
+// stopped ones.
+func (r *Runtime) Inspect(ctx context.Context, composePath string, projectName string, service string) ([]runtime.ContainerState, error) {
+	args := []string{"compose", "-f", composePath, "-p", projectName, "ps", "--all", "--quiet", service}
+	out, err := exec.CommandContext(ctx, r.Binary, args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	ids := strings.Fields(string(out))
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	out, err = exec.CommandContext(ctx, r.Binary, append([]string{"inspect"}, ids...)...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return runtime.ParseInspect(out)
+}
+
+// List reports every container with a devx.project label, in any project.
+func (r *Runtime) List(ctx context.Context) ([]runtime.Container, error) {
+	args := []string{"ps", "--all", "--filter", "label=devx.project", "--format", "json"}
+	out, err := exec.CommandContext(ctx, r.Binary, args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return runtime.ParseContainerList(out)
+}
+
+// Events streams `podman events` for the project's devx containers.
+func (r *Runtime) Events(ctx context.Context, composePath string, projectName string, opts runtime.EventsOptions) (<-chan runtime.Event, <-chan error) {
+	args := []string{"events", "--format", "json", "--filter", "type=container"}
+	for _, label := range runtime.EventFilters(projectName) {
+		args = append(args, "--filter", "label="+label)
+	}
+	if opts.Since != "" {
+		args = append(args, "--since", opts.Since)
+	}
+	return runtime.CommandEvents(ctx, exec.CommandContext(ctx, r.Binary, args...), parseEvent)
+}
+
+type podmanEvent struct {
+	ID                string
+	Name              string
+	Status            string
+	Type              string
+	HealthStatus      string
+	ContainerExitCode *int
+	Attributes        map[string]string
+	// Time is an RFC 3339 string in podman 3 and Unix seconds, beside
+	// timeNano, in podman 4 and later.
+	Time     json.RawMessage
+	TimeNano int64 `json:"timeNano"`
+}
+
+// parseEvent decodes one line of `podman events --format json`, whose
+// statuses differ from docker's: a container that exits has "died".
+func parseEvent(line []byte) (runtime.Event, bool, error) {
+	var p podmanEvent
+	if err := json.Unmarshal(line, &p); err != nil {
+		return runtime.Event{}, false, err
+	}
+	if p.Type != "" && p.Type != "container" {
+		return runtime.Event{}, false, nil
+	}
+	e := runtime.Event{
+		Time:      eventTime(p),
+		Project:   p.Attributes["com.docker.compose.project"],
+		Service:   p.Attributes["com.docker.compose.service"],
+		Container: p.Name,
+		Action:    p.Status,
+	}
+	switch p.Status {
+	case runtime.EventStart, runtime.EventOOM, runtime.EventRestart:
+	case "died", runtime.EventDie:
+		e.Action = runtime.EventDie
+		e.ExitCode = p.ContainerExitCode
+	case runtime.EventHealth:
+		e.Health = p.HealthStatus
+	default:
+		return runtime.Event{}, false, nil
+	}
+	return e, true, nil
+}
+
+func eventTime(p podmanEvent) time.Time {
+	if p.TimeNano != 0 {
+		return time.Unix(0, p.TimeNano).UTC()
+	}
+	var seconds int64
+	if err := json.Unmarshal(p.Time, &seconds); err == nil {
+		return time.Unix(seconds, 0).UTC()
+	}
+	var t time.Time
+	_ = json.Unmarshal(p.Time, &t)
+	return t.UTC()
+}
+
 func (r *Runtime) ResolveImageDigest(ctx context.Context, image string) (string, error) {
 	digest, err := resolveRepoDigest(ctx, r.Binary, image)
 	if err == nil {
@@ -181,21 +306,3 @@ func run(ctx context.Context, binary string, args ...string) error {
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
-
-type commandReader struct {
-	cmd *exec.Cmd
-	rc  io.ReadCloser
-}
-
-func newCommandReader(cmd *exec.Cmd, rc io.ReadCloser) io.ReadCloser {
-	return &commandReader{cmd: cmd, rc: rc}
-}
-
-func (c *commandReader) Read(p []byte) (int, error) {
-	return c.rc.Read(p)
-}
-
-func (c *commandReader) Close() error {
-	_ = c.cmd.Process.Kill()
-	return c.rc.Close()
-}
@@ -0,0 +1,41 @@
+package podman
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dever-labs/devx/internal/runtime"
+)
+
+func TestParseEvent(t *testing.T) {
+	attrs := `"Attributes":{"com.docker.compose.project":"demo","com.docker.compose.service":"db","devx.project":"demo"}`
+	for _, tc := range []struct {
+		line   string
+		action string
+		health string
+		exit   int
+		time   time.Time
+	}{
+		{`{"ID":"4c1a7f0e","Name":"demo-db-1","Status":"start","Type":"container","time":1700000000,"timeNano":1700000000123456789,` + attrs + `}`, runtime.EventStart, "", -1, time.Unix(0, 1700000000123456789)},
+		{`{"ContainerExitCode":1,"ID":"4c1a7f0e","Name":"demo-db-1","Status":"died","Type":"container","time":1700000000,` + attrs + `}`, runtime.EventDie, "", 1, time.Unix(1700000000, 0)},
+		{`{"ID":"4c1a7f0e","Name":"demo-db-1","Status":"health_status","HealthStatus":"healthy","Type":"container","Time":"2023-11-14T22:13:20Z",` + attrs + `}`, runtime.EventHealth, "healthy", -1, time.Unix(1700000000, 0)},
+	} {
+		e, ok, err := parseEvent([]byte(tc.line))
+		if err != nil || !ok {
+			t.Fatalf("parseEvent(%s) = %v, %v", tc.line, ok, err)
+		}
+		if e.Action != tc.action || e.Health != tc.health || e.Project != "demo" || e.Service != "db" || e.Container != "demo-db-1" {
+			t.Errorf("unexpected event: %+v", e)
+		}
+		if !e.Time.Equal(tc.time) {
+			t.Errorf("%s: time %v, want %v", tc.action, e.Time, tc.time)
+		}
+		if (tc.exit < 0) != (e.ExitCode == nil) || (e.ExitCode != nil && *e.ExitCode != tc.exit) {
+			t.Errorf("%s: unexpected exit code %v", tc.action, e.ExitCode)
+		}
+	}
+
+	if _, ok, err := parseEvent([]byte(`{"ID":"4c1a7f0e","Status":"cleanup","Type":"container"}`)); ok || err != nil {
+		t.Errorf("expected cleanup events to be skipped, got %v, %v", ok, err)
+	}
+}
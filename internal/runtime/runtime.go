@@ -9,13 +9,55 @@ import (
 type UpOptions struct {
 	Build bool
 	Pull  bool
+	// Services limits the command to these services; empty means all.
+	Services []string
+	// NoDeps starts Services without also starting what they depend on.
+	NoDeps bool
+	// ForceRecreate recreates containers even when their configuration
+	// and image have not changed.
+	ForceRecreate bool
+}
+
+type DownOptions struct {
+	// Volumes also removes volumes: named volumes for the whole project,
+	// anonymous ones when Services is set.
+	Volumes bool
+	// Services stops and removes only these services' containers; empty
+	// means the whole project, including its network.
+	Services []string
 }
 
 type LogsOptions struct {
-	Service string
-	Follow  bool
-	Since   string
-	JSON    bool
+	// Services limits the logs to these services' containers; empty means
+	// the whole project.
+	Services []string
+	Follow   bool
+	Since    string
+	JSON     bool
+	// Tail limits output to the last Tail lines per container; 0 means all.
+	Tail int
+	// Stderr, when set, receives the lines containers wrote to stderr, in
+	// the same format as the returned reader, instead of that reader. All
+	// of them have been written by the time the reader reaches EOF.
+	Stderr io.Writer
+}
+
+type ExecOptions struct {
+	Cmd []string
+	// User runs Cmd as this user, e.g. "root" or "1000:1000".
+	User string
+	// Workdir is the directory Cmd runs in inside the container.
+	Workdir string
+	// Env sets variables for Cmd, as KEY=VALUE.
+	Env []string
+	// TTY allocates a pseudo-terminal; Stdin and Stdout should be the
+	// user's terminal.
+	TTY bool
+	// Stdin, Stdout and Stderr are connected to Cmd. A nil Stdin means
+	// none, and nil Stdout or Stderr discards that output.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
 }
 
 type ServiceStatus struct {
@@ -38,10 +80,35 @@ type Runtime interface {
 	Name() string
 	Detect(ctx context.Context) (bool, error)
 	Up(ctx context.Context, composePath string, projectName string, opts UpOptions) error
-	Down(ctx context.Context, composePath string, projectName string, removeVolumes bool) error
+	Down(ctx context.Context, composePath string, projectName string, opts DownOptions) error
+	Stop(ctx context.Context, composePath string, projectName string, services []string) error
+	Restart(ctx context.Context, composePath string, projectName string, services []string) error
 	Logs(ctx context.Context, composePath string, projectName string, opts LogsOptions) (io.ReadCloser, error)
-	Exec(ctx context.Context, composePath string, projectName string, service string, cmd []string) (int, error)
+	Exec(ctx context.Context, composePath string, projectName string, service string, opts ExecOptions) (int, error)
 	Status(ctx context.Context, composePath string, projectName string) ([]ServiceStatus, error)
+	// Events streams lifecycle events for the devx containers of the
+	// project until ctx is cancelled or the stream ends; the error channel
+	// then receives one value, ctx.Err() when it was cancelled.
+	Events(ctx context.Context, composePath string, projectName string, opts EventsOptions) (<-chan Event, <-chan error)
+}
+
+// Inspector is implemented by runtimes that can report why a service's
+// container stopped or is unhealthy.
+type Inspector interface {
+	Inspect(ctx context.Context, composePath string, projectName string, service string) ([]ContainerState, error)
+}
+
+// Copier is implemented by runtimes that can copy files from the host into
+// a service's running container.
+type Copier interface {
+	Copy(ctx context.Context, composePath string, projectName string, service string, src string, dst string) error
+}
+
+// Runner is implemented by runtimes that can run a command in a new
+// one-off container of a service, started with its dependencies, attached
+// to the project's network and removed once the command exits.
+type Runner interface {
+	Run(ctx context.Context, composePath string, projectName string, service string, opts ExecOptions) (int, error)
 }
 
 type DigestResolver interface {
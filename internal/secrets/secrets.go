@@ -0,0 +1,170 @@
+// Package secrets resolves the values of secrets declared in devx.yaml from
+// pluggable sources (environment, file, shell command, local encrypted store)
+// and writes them where the container runtime can mount them.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+
+	"github.com/dever-labs/devx/internal/config"
+)
+
+// Source looks up a secret value from a reference such as an environment
+// variable name, a file path or a command line.
+type Source interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// SourceFunc adapts a function to the Source interface.
+type SourceFunc func(ctx context.Context, ref string) (string, error)
+
+func (f SourceFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// Resolver maps secret source kinds to their implementation.
+type Resolver struct {
+	sources map[string]Source
+}
+
+// NewResolver returns a Resolver with the built-in env, file, command and
+// store sources. dir is the directory containing devx.yaml; relative file
+// paths and commands are resolved from there.
+func NewResolver(dir string) *Resolver {
+	r := &Resolver{sources: map[string]Source{}}
+	r.Register("env", SourceFunc(func(_ context.Context, ref string) (string, error) {
+		v, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", ref)
+		}
+		return v, nil
+	}))
+	r.Register("file", SourceFunc(func(_ context.Context, ref string) (string, error) {
+		path := ref
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}))
+	r.Register("command", SourceFunc(func(ctx context.Context, ref string) (string, error) {
+		return runCommand(ctx, dir, ref)
+	}))
+	r.Register("store", SourceFunc(func(_ context.Context, ref string) (string, error) {
+		store, err := OpenStore(filepath.Join(dir, StorePath))
+		if err != nil {
+			return "", err
+		}
+		v, ok := store.Get(ref)
+		if !ok {
+			return "", fmt.Errorf("%q is not in the local secret store — run 'devx secrets set %s'", ref, ref)
+		}
+		return v, nil
+	}))
+	return r
+}
+
+// Register adds or replaces the source used for kind.
+func (r *Resolver) Register(kind string, s Source) {
+	r.sources[kind] = s
+}
+
+// Resolve returns the value of the named secret.
+func (r *Resolver) Resolve(ctx context.Context, name string, spec config.Secret) (string, error) {
+	kind, ref := spec.Source()
+	if kind == "" {
+		return "", fmt.Errorf("secret '%s' has no source", name)
+	}
+	src, ok := r.sources[kind]
+	if !ok {
+		return "", fmt.Errorf("secret '%s': unknown source %q", name, kind)
+	}
+	v, err := src.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("secret '%s' (%s): %w", name, kind, err)
+	}
+	return v, nil
+}
+
+// ResolveProfile resolves every secret the profile references.
+func (r *Resolver) ResolveProfile(ctx context.Context, m *config.Manifest, prof *config.Profile) (map[string]string, error) {
+	values := map[string]string{}
+	for _, name := range config.ProfileSecrets(prof) {
+		spec, ok := m.Secrets[name]
+		if !ok {
+			return nil, fmt.Errorf("secret '%s' is not declared", name)
+		}
+		v, err := r.Resolve(ctx, name, spec)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = v
+	}
+	return values, nil
+}
+
+// WriteFiles writes each secret to dir/<name>, where compose secrets
+// reference them. Compose mounts a file secret with the host file's owner
+// and mode, so the files are world-readable for containers that run as
+// another user; dir itself is owner-only, which keeps other host users
+// out. Names that are not plain file names are refused, so no secret is
+// written outside dir.
+func WriteFiles(dir string, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	for name := range values {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("secret name '%s' is not a valid file name", name)
+		}
+	}
+	// MkdirAll and WriteFile keep the mode of what exists already and are
+	// subject to the umask, so both modes are set explicitly.
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		return err
+	}
+	for name, value := range values {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(value), 0644); err != nil {
+			return err
+		}
+		if err := os.Chmod(path, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runCommand(ctx context.Context, dir, command string) (string, error) {
+	var cmd *exec.Cmd
+	if goruntime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/c", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = dir
+	cmd.Stdin = os.Stdin // allow gpg/pass pinentry prompts
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
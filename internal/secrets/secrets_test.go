@@ -0,0 +1,117 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"testing"
+
+	"github.com/dever-labs/devx/internal/config"
+)
+
+func TestResolver_Sources(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "db.txt"), []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DEVX_TEST_SECRET", "from-env")
+
+	r := NewResolver(dir)
+	cases := map[string]config.Secret{
+		"from-env":  {Env: "DEVX_TEST_SECRET"},
+		"from-file": {File: "db.txt"},
+	}
+	if goruntime.GOOS != "windows" {
+		cases["from-command"] = config.Secret{Command: "echo from-command"}
+	}
+	for want, spec := range cases {
+		got, err := r.Resolve(context.Background(), "s", spec)
+		if err != nil {
+			t.Fatalf("%s: %v", want, err)
+		}
+		if got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+
+	_, err := r.Resolve(context.Background(), "db", config.Secret{Env: "DEVX_TEST_UNSET"})
+	if err == nil || !strings.Contains(err.Error(), "secret 'db' (env)") {
+		t.Fatalf("expected unset env error, got %v", err)
+	}
+}
+
+func TestResolver_Register(t *testing.T) {
+	r := NewResolver(t.TempDir())
+	r.Register("env", SourceFunc(func(_ context.Context, ref string) (string, error) {
+		return "vault:" + ref, nil
+	}))
+	got, err := r.Resolve(context.Background(), "s", config.Secret{Env: "X"})
+	if err != nil || got != "vault:X" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+}
+
+func TestStore_RoundTrip(t *testing.T) {
+	t.Setenv(KeyEnv, base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	path := filepath.Join(t.TempDir(), StorePath)
+
+	s, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Set("db_password", "hunter2")
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "hunter2") {
+		t.Fatalf("store file contains plaintext value")
+	}
+
+	s, err = OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := s.Get("db_password"); !ok || v != "hunter2" {
+		t.Fatalf("got %q, %v", v, ok)
+	}
+
+	t.Setenv(KeyEnv, base64.StdEncoding.EncodeToString([]byte(strings.Repeat("x", 32))))
+	if _, err := OpenStore(path); err == nil {
+		t.Fatalf("expected decrypt error with a different key")
+	}
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "secrets")
+	if err := WriteFiles(dir, map[string]string{"db_password": "hunter2"}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "db_password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if goruntime.GOOS != "windows" && info.Mode().Perm() != 0644 {
+		t.Fatalf("expected 0644 so containers running as another user can read it, got %v", info.Mode().Perm())
+	}
+	info, err = os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if goruntime.GOOS != "windows" && info.Mode().Perm() != 0700 {
+		t.Fatalf("expected the directory to be 0700, got %v", info.Mode().Perm())
+	}
+
+	for _, name := range []string{"../../.ssh/x", `..\x`, ".."} {
+		if err := WriteFiles(dir, map[string]string{name: "x"}); err == nil {
+			t.Fatalf("expected %q to be refused", name)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// StorePath is the location of the local encrypted store, relative to the
+// directory containing devx.yaml.
+const StorePath = ".devx/secrets.enc"
+
+// KeyEnv names the environment variable that may hold the base64-encoded
+// store key, e.g. in CI. Otherwise the key lives in ~/.devx/secrets.key.
+const KeyEnv = "DEVX_SECRETS_KEY"
+
+// Store is a small name → value map encrypted at rest with AES-256-GCM.
+// The key is per user, so a copied store file is useless on its own.
+type Store struct {
+	path   string
+	key    []byte
+	values map[string]string
+}
+
+// OpenStore loads the store at path, creating the user key on first use. A
+// missing store file yields an empty store.
+func OpenStore(path string) (*Store, error) {
+	key, err := loadKey()
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{path: path, key: key, values: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	plain, err := decrypt(key, data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt %s (was it created with a different key?): %w", path, err)
+	}
+	if err := json.Unmarshal(plain, &s.values); err != nil {
+		return nil, fmt.Errorf("secret store %s is corrupt: %w", path, err)
+	}
+	return s, nil
+}
+
+// Get returns the stored value for name.
+func (s *Store) Get(name string) (string, bool) {
+	v, ok := s.values[name]
+	return v, ok
+}
+
+// Set stores value under name. Call Save to persist.
+func (s *Store) Set(name, value string) {
+	s.values[name] = value
+}
+
+// Delete removes name. Call Save to persist.
+func (s *Store) Delete(name string) {
+	delete(s.values, name)
+}
+
+// Names returns the stored secret names in sorted order.
+func (s *Store) Names() []string {
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save encrypts and writes the store.
+func (s *Store) Save() error {
+	plain, err := json.Marshal(s.values)
+	if err != nil {
+		return err
+	}
+	data, err := encrypt(s.key, plain)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
+
+func loadKey() ([]byte, error) {
+	if env := os.Getenv(KeyEnv); env != "" {
+		key, err := base64.StdEncoding.DecodeString(env)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("%s must be a base64-encoded 32-byte key", KeyEnv)
+		}
+		return key, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	path := filepath.Join(home, ".devx", "secrets.key")
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("%s is not a valid key", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func encrypt(key, plain []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+func decrypt(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
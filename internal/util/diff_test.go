@@ -0,0 +1,40 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	b := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	want := `--- old
++++ new
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`
+	if got := UnifiedDiff("old", "new", a, b); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if got := UnifiedDiff("old", "new", a, a); got != "" {
+		t.Fatalf("expected no diff for equal input, got %q", got)
+	}
+}
+
+func TestUnifiedDiff_MergesCloseHunks(t *testing.T) {
+	a := strings.Repeat("x\n", 3) + "1\n" + strings.Repeat("x\n", 6) + "2\n"
+	b := strings.Repeat("x\n", 3) + "one\n" + strings.Repeat("x\n", 6) + "two\n"
+	if got := UnifiedDiff("a", "b", a, b); strings.Count(got, "@@ -") != 1 {
+		t.Fatalf("expected a single hunk, got:\n%s", got)
+	}
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"net/url"
+	"strings"
+)
+
+// credentialHints are substrings of variable names that hold credentials.
+var credentialHints = []string{"PASSWORD", "SECRET", "TOKEN", "KEY", "CREDENTIAL"}
+
+// MaskValue hides the value of a variable whose name suggests a credential,
+// and the password of any URL value, such as a database connection string.
+func MaskValue(name, value string) string {
+	if value == "" {
+		return value
+	}
+	upper := strings.ToUpper(name)
+	for _, hint := range credentialHints {
+		if strings.Contains(upper, hint) {
+			return "****"
+		}
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "****")
+			return strings.Replace(u.String(), "%2A%2A%2A%2A", "****", 1)
+		}
+	}
+	return value
+}
+
+// MaskEnv returns env with MaskValue applied to every value.
+func MaskEnv(env map[string]string) map[string]string {
+	if env == nil {
+		return nil
+	}
+	out := make(map[string]string, len(env))
+	for k, v := range env {
+		out[k] = MaskValue(k, v)
+	}
+	return out
+}
@@ -0,0 +1,19 @@
+package util
+
+import "testing"
+
+func TestMaskValue(t *testing.T) {
+	tests := []struct{ name, value, want string }{
+		{"POSTGRES_PASSWORD", "hunter2", "****"},
+		{"api_key", "abc", "****"},
+		{"DATABASE_URL", "postgres://app:hunter2@db:5432/app?sslmode=disable", "postgres://app:****@db:5432/app?sslmode=disable"},
+		{"REDIS_URL", "redis://cache:6379", "redis://cache:6379"},
+		{"LOG_LEVEL", "debug", "debug"},
+		{"SECRET", "", ""},
+	}
+	for _, tt := range tests {
+		if got := MaskValue(tt.name, tt.value); got != tt.want {
+			t.Errorf("%s=%s: got %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
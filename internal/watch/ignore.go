@@ -0,0 +1,164 @@
+package watch
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Ignore matches paths under a root directory against .dockerignore-style
+// patterns: globs with ** for any number of directories, ! to re-include,
+// and the last matching pattern winning. A pattern that matches a
+// directory also matches everything inside it.
+type Ignore struct {
+	root     string
+	patterns []ignorePattern
+	negates  bool
+}
+
+type ignorePattern struct {
+	re     *regexp.Regexp
+	negate bool
+}
+
+// NewIgnore returns an Ignore for patterns relative to root.
+func NewIgnore(root string, patterns []string) (*Ignore, error) {
+	ig := &Ignore{root: filepath.Clean(root)}
+	for _, p := range patterns {
+		if err := ig.add(p); err != nil {
+			return nil, err
+		}
+	}
+	return ig, nil
+}
+
+// LoadDockerignore reads dir/.dockerignore. A missing file ignores nothing.
+func LoadDockerignore(dir string) (*Ignore, error) {
+	f, err := os.Open(filepath.Join(dir, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return NewIgnore(dir, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	patterns, err := readPatterns(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name(), err)
+	}
+	return NewIgnore(dir, patterns)
+}
+
+func readPatterns(r io.Reader) ([]string, error) {
+	var patterns []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+func (ig *Ignore) add(pattern string) error {
+	negate := strings.HasPrefix(pattern, "!")
+	pattern = strings.TrimSpace(strings.TrimPrefix(pattern, "!"))
+	pattern = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(pattern)), "/")
+	if pattern == "" || pattern == "." {
+		return nil
+	}
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid ignore pattern '%s': %w", pattern, err)
+	}
+	ig.patterns = append(ig.patterns, ignorePattern{re: re, negate: negate})
+	ig.negates = ig.negates || negate
+	return nil
+}
+
+// compilePattern turns a glob into an anchored regular expression over
+// slash-separated paths.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		switch {
+		case ch == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			i++
+			if i+1 < len(pattern) && pattern[i+1] == '/' {
+				i++
+				b.WriteString("(.*/)?")
+			} else {
+				b.WriteString(".*")
+			}
+		case ch == '*':
+			b.WriteString("[^/]*")
+		case ch == '?':
+			b.WriteString("[^/]")
+		case ch == '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		case ch == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// Match reports whether path, absolute or relative to the working
+// directory, is ignored. Paths outside the root are never ignored.
+func (ig *Ignore) Match(path string) bool {
+	rel, err := filepath.Rel(ig.root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	ignored := false
+	for _, p := range ig.patterns {
+		if p.matches(rel) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// matches reports whether the pattern matches rel or one of its parent
+// directories.
+func (p ignorePattern) matches(rel string) bool {
+	for {
+		if p.re.MatchString(rel) {
+			return true
+		}
+		i := strings.LastIndexByte(rel, '/')
+		if i < 0 {
+			return false
+		}
+		rel = rel[:i]
+	}
+}
+
+// skipDir reports whether nothing under dir can be re-included, so a walk
+// may skip it.
+func (ig *Ignore) skipDir(dir string) bool {
+	return !ig.negates && ig.Match(dir)
+}
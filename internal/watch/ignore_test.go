@@ -0,0 +1,55 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnore_Match(t *testing.T) {
+	root := t.TempDir()
+	data := "# build output\nnode_modules\n/dist\n**/*.log\n!important.log\ntmp/*.swp\n\n"
+	if err := os.WriteFile(filepath.Join(root, ".dockerignore"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ig, err := LoadDockerignore(root)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	tests := map[string]bool{
+		"node_modules/react/index.js": true,
+		"dist/app.js":                 true,
+		"src/dist/app.js":             false,
+		"debug.log":                   true,
+		"logs/app/debug.log":          true,
+		"important.log":               false,
+		"tmp/.main.go.swp":            true,
+		"tmp/nested/a.swp":            false,
+		"src/main.go":                 false,
+	}
+	for rel, want := range tests {
+		if got := ig.Match(filepath.Join(root, rel)); got != want {
+			t.Errorf("%s: got %v, want %v", rel, got, want)
+		}
+	}
+	if ig.Match(filepath.Join(filepath.Dir(root), "node_modules")) {
+		t.Error("paths outside the root must not be ignored")
+	}
+}
+
+func TestLoadDockerignore_Missing(t *testing.T) {
+	ig, err := LoadDockerignore(t.TempDir())
+	if err != nil {
+		t.Fatalf("expected a missing .dockerignore to be fine: %v", err)
+	}
+	if ig.Match("anything") {
+		t.Error("expected nothing to be ignored")
+	}
+}
+
+func TestNewIgnore_InvalidPattern(t *testing.T) {
+	if _, err := NewIgnore(t.TempDir(), []string{"[abc"}); err == nil {
+		t.Fatal("expected an error for an unterminated class")
+	}
+}
@@ -0,0 +1,163 @@
+// Package watch reports file changes under a directory by polling, so it
+// behaves the same on every platform and filesystem, including bind mounts
+// and network drives where change notifications are unreliable.
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Change is a file that was created, modified or removed.
+type Change struct {
+	// Path is the file's absolute path.
+	Path    string
+	Removed bool
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	mode    fs.FileMode
+}
+
+// Watcher tracks the files under a root, which may also be a single file.
+type Watcher struct {
+	root   string
+	ignore []*Ignore
+	files  map[string]fileState
+}
+
+// NewWatcher records the current state of root. Files matched by any of
+// ignore are not tracked.
+func NewWatcher(root string, ignore ...*Ignore) (*Watcher, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{root: abs, ignore: ignore}
+	if w.files, err = w.scan(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Root returns the absolute path being watched.
+func (w *Watcher) Root() string { return w.root }
+
+// Poll rescans the root and returns what changed since the last scan,
+// sorted by path.
+func (w *Watcher) Poll() ([]Change, error) {
+	files, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	for path, st := range files {
+		if old, ok := w.files[path]; !ok || old != st {
+			changes = append(changes, Change{Path: path})
+		}
+	}
+	for path := range w.files {
+		if _, ok := files[path]; !ok {
+			changes = append(changes, Change{Path: path, Removed: true})
+		}
+	}
+	w.files = files
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func (w *Watcher) scan() (map[string]fileState, error) {
+	files := map[string]fileState{}
+	err := filepath.WalkDir(w.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files can disappear between listing a directory and reading
+			// them; the next scan sees the removal.
+			if os.IsNotExist(err) && path != w.root {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if path != w.root && w.skipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if w.ignored(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files[path] = fileState{modTime: info.ModTime(), size: info.Size(), mode: info.Mode()}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return files, nil
+	}
+	return files, err
+}
+
+func (w *Watcher) ignored(path string) bool {
+	for _, ig := range w.ignore {
+		if ig.Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) skipDir(path string) bool {
+	for _, ig := range w.ignore {
+		if ig.skipDir(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run polls the watchers every interval and calls fn with their combined
+// changes once none have been seen for debounce, so a burst of saves, a
+// branch switch or a formatter run is handled once. It returns when ctx is
+// cancelled.
+func Run(ctx context.Context, watchers []*Watcher, interval, debounce time.Duration, fn func([]Change)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	pending := map[string]Change{}
+	var last time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			for _, w := range watchers {
+				changes, err := w.Poll()
+				if err != nil {
+					return err
+				}
+				for _, c := range changes {
+					pending[c.Path] = c
+					last = now
+				}
+			}
+			if len(pending) == 0 || now.Sub(last) < debounce {
+				continue
+			}
+			batch := make([]Change, 0, len(pending))
+			for _, c := range pending {
+				batch = append(batch, c)
+			}
+			sort.Slice(batch, func(i, j int) bool { return batch[i].Path < batch[j].Path })
+			pending = map[string]Change{}
+			fn(batch)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "main.go"), "package main")
+	writeFile(t, filepath.Join(root, "old.go"), "package main")
+	writeFile(t, filepath.Join(root, "node_modules", "x.js"), "x")
+	ig, _ := NewIgnore(root, []string{"node_modules"})
+
+	w, err := NewWatcher(root, ig)
+	if err != nil {
+		t.Fatalf("new watcher failed: %v", err)
+	}
+	if changes, _ := w.Poll(); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+
+	writeFile(t, filepath.Join(root, "main.go"), "package main // edited")
+	writeFile(t, filepath.Join(root, "pkg", "new.go"), "package pkg")
+	writeFile(t, filepath.Join(root, "node_modules", "y.js"), "y")
+	if err := os.Remove(filepath.Join(root, "old.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := w.Poll()
+	if err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	want := []Change{
+		{Path: filepath.Join(root, "main.go")},
+		{Path: filepath.Join(root, "old.go"), Removed: true},
+		{Path: filepath.Join(root, "pkg", "new.go")},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("got %v, want %v", changes, want)
+	}
+}
+
+func TestRun_Debounces(t *testing.T) {
+	root := t.TempDir()
+	w, err := NewWatcher(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batches := make(chan []Change, 10)
+	go Run(ctx, []*Watcher{w}, 5*time.Millisecond, 50*time.Millisecond, func(c []Change) { batches <- c })
+
+	for i, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		writeFile(t, filepath.Join(root, name), string(rune('a'+i)))
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case batch := <-batches:
+		if len(batch) != 3 {
+			t.Fatalf("expected one batch of 3 changes, got %v", batch)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no changes reported")
+	}
+	select {
+	case batch := <-batches:
+		t.Fatalf("expected a single batch, got another: %v", batch)
+	case <-time.After(100 * time.Millisecond):
+	}
+}